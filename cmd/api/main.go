@@ -1,31 +1,211 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/gemini/go-blog-api/internal/config"
 	"github.com/gemini/go-blog-api/internal/database"
 	"github.com/gemini/go-blog-api/internal/handler"
+	"github.com/gemini/go-blog-api/internal/hashid"
+	"github.com/gemini/go-blog-api/internal/middleware"
+	"github.com/gemini/go-blog-api/internal/model"
+	"github.com/gemini/go-blog-api/internal/webhook"
 )
 
+// publishInterval is how often scheduled posts are checked for publishing.
+const publishInterval = time.Minute
+
 func main() {
+	cfg := config.Load()
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.LogLevel}))
+
+	// Shut down gracefully on interrupt or termination
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialize the in-memory database
-	db := database.NewMemoryStore()
+	opts := []database.Option{
+		database.WithMaxTags(handler.MaxTags),
+		database.WithMaxTitleLength(handler.MaxTitleLength),
+		database.WithMaxContentLength(handler.MaxContentLength),
+		database.WithMinContentLength(cfg.MinContentLength),
+		database.WithMaxPosts(cfg.MaxPosts),
+		database.WithMaxPostsPerAuthor(cfg.MaxPostsPerAuthor),
+		database.WithMaxResults(cfg.MaxResults),
+		database.WithLogger(logger),
+		database.WithMaxCommentsPerPost(cfg.MaxCommentsPerPost),
+		database.WithCommentDedupWindow(cfg.CommentDedupWindow),
+		database.WithMinUpdateInterval(cfg.MinUpdateInterval),
+	}
+	if cfg.SearchIndex {
+		opts = append(opts, database.WithSearchIndex())
+	}
+	if cfg.DefaultSort != "" {
+		opts = append(opts, database.WithDefaultSort(cfg.DefaultSort))
+	}
+	var db database.Store = database.NewMemoryStore(opts...)
+	if cfg.RecentCacheSize > 0 {
+		db = database.NewRecentCache(db, cfg.RecentCacheSize)
+	}
+
+	// Publish scheduled posts as they come due
+	go publishScheduled(ctx, db, publishInterval, logger)
 
 	// Initialize handlers
 	postHandler := handler.NewPostHandler(db)
+	postHandler.AllowedCategories = cfg.AllowedCategories
+	postHandler.DefaultCategory = cfg.DefaultCategory
+	postHandler.MinSearchTermLength = cfg.MinSearchTermLength
+	postHandler.MaxQueryFilters = cfg.MaxQueryFilters
+	postHandler.AdminToken = cfg.AdminToken
+	postHandler.AllowReset = cfg.AllowReset
+	postHandler.RequireJSONContentType = cfg.RequireJSONContentType
+	postHandler.StrictText = cfg.StrictText
+	postHandler.MinContentLength = cfg.MinContentLength
+	postHandler.MaxCommentLength = cfg.MaxCommentLength
+	postHandler.CommentPageSize = cfg.CommentPageSize
+	postHandler.ReviewMinWords = cfg.ReviewMinWords
+	postHandler.ReviewStaleAfter = cfg.ReviewStaleAfter
+	postHandler.AutoTagCount = cfg.AutoTagCount
+	postHandler.AutoTagStopwords = cfg.AutoTagStopwords
+	postHandler.EmptyListNoContent = cfg.EmptyListNoContent
+	postHandler.IDsAsStrings = cfg.IDsAsStrings
+	postHandler.BaseURL = cfg.BaseURL
+	postHandler.Logger = logger
+	if codec := hashid.New(cfg.IDSecret); codec != nil {
+		model.SetIDCodec(codec)
+		postHandler.IDCodec = codec
+	}
+	postHandler.Webhook = webhook.New(cfg.WebhookURL, cfg.WebhookSecret, logger)
 
-	// Setup the router
+	// Setup the router. Feeds may be read from any origin, unlike the API.
 	mux := http.NewServeMux()
+	feed := middleware.PublicCORS(cfg.CORSPublicFeeds)
 	mux.Handle("/posts/", postHandler)
-	mux.HandleFunc("/health", handler.HealthCheckHandler)
+	mux.HandleFunc("/tags", postHandler.ServeTags)
+	mux.HandleFunc("/tags/", postHandler.ServeTags)
+	mux.HandleFunc("/categories/", postHandler.ServeCategoryPosts)
+	mux.HandleFunc("/authors/", postHandler.ServeAuthorPosts)
+	mux.HandleFunc("/comments/", postHandler.ServeComments)
+	mux.HandleFunc("/export", postHandler.Export)
+	mux.Handle("/export/wxr", feed(http.HandlerFunc(postHandler.ExportWXR)))
+	mux.Handle("/feed.atom", feed(http.HandlerFunc(postHandler.AtomFeed)))
+	mux.HandleFunc("/import/wxr", postHandler.ImportWXR)
+	mux.HandleFunc("/admin/reindex", postHandler.Reindex)
+	mux.HandleFunc("/admin/repair-ids", postHandler.RepairIDs)
+	mux.HandleFunc("/admin/reset", postHandler.Reset)
+	mux.Handle("/health", handler.NewHealthHandler(db, time.Now()))
+	mux.HandleFunc("/", handler.NotFound)
+
+	trustedProxies, err := middleware.ParseCIDRs(cfg.TrustedProxies)
+	if err != nil {
+		logger.Error("invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+
+	// Wrap the router with request IDs, client IP resolution, security
+	// headers, structured request logging, the canonical host redirect,
+	// compression, rate and concurrency limiting, CORS, read-only mode, and a
+	// request timeout, outermost first
+	h := middleware.Chain(
+		middleware.Default(logger, trustedProxies, cfg.SlowRequestThreshold),
+		middleware.CanonicalHost(cfg.CanonicalHost),
+		middleware.Gzip(middleware.GzipOptions{
+			Level:        cfg.GzipLevel,
+			MinSize:      cfg.GzipMinSize,
+			ContentTypes: cfg.GzipContentTypes,
+		}),
+		middleware.RateLimit(cfg.RateLimit),
+		middleware.ConcurrencyLimit(cfg.MaxConcurrentRequests),
+		middleware.CORS(middleware.CORSOptions{
+			AllowedOrigins:   cfg.CORSAllowedOrigins,
+			AllowCredentials: cfg.CORSAllowCredentials,
+			MaxAge:           cfg.CORSMaxAge,
+		}),
+		middleware.ReadOnly(cfg.ReadOnly, "/posts/sync", "/posts/lookup", "/posts/preview"),
+		middleware.Timeout(cfg.RequestTimeout),
+	)(mux)
 
 	// Configure the server
 	server := &http.Server{
-		Addr:    ":8080",
-		Handler: mux,
+		Addr:    cfg.Addr,
+		Handler: h,
 	}
+	postHandler.Draining = drainOnShutdown(server)
 
-	log.Println("Server starting on port 8080...")
-	log.Fatal(server.ListenAndServe())
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		logger.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("server starting", "addr", cfg.Addr)
+	if err := serve(ctx, server, ln, cfg.ShutdownTimeout); err != nil {
+		logger.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("server stopped")
+}
+
+// drainOnShutdown returns a channel that is closed when server begins
+// shutting down, telling long-lived responses to end.
+func drainOnShutdown(server *http.Server) <-chan struct{} {
+	draining := make(chan struct{})
+	server.RegisterOnShutdown(func() { close(draining) })
+	return draining
+}
+
+// serve serves on ln until ctx is done, then shuts the server down, waiting
+// up to timeout for in-flight requests before closing their connections.
+func serve(ctx context.Context, server *http.Server, ln net.Listener, timeout time.Duration) error {
+	errc := make(chan error, 1)
+	go func() { errc <- server.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// publishScheduled publishes due scheduled posts every interval until ctx is
+// done.
+func publishScheduled(ctx context.Context, store database.Store, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var now time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+		ids, err := store.PublishDue(ctx, now)
+		if err != nil {
+			logger.Error("failed to publish scheduled posts", "error", err)
+			continue
+		}
+		if len(ids) > 0 {
+			logger.Info("published scheduled posts", "count", len(ids), "ids", ids)
+		}
+	}
 }
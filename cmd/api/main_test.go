@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeDrainsStreams(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &http.Server{}
+	draining := drainOnShutdown(server)
+	// A stream that would otherwise stay open for the whole shutdown
+	// timeout.
+	server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: hello\n\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-draining:
+		case <-time.After(time.Minute):
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serve(ctx, server, ln, time.Minute) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body := bufio.NewReader(resp.Body)
+	if line, err := body.ReadString('\n'); err != nil || line != "data: hello\n" {
+		t.Fatalf("first event = %q, %v", line, err)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serve() error = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve() did not return after shutdown began")
+	}
+	// The handler returned, so the rest of the stream arrives and ends.
+	if rest, err := io.ReadAll(body); err != nil || string(rest) != "\n" {
+		t.Errorf("rest of stream = %q, %v, want %q", rest, err, "\n")
+	}
+}
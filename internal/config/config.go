@@ -0,0 +1,301 @@
+package config
+
+import (
+	"compress/gzip"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/handler"
+)
+
+// Config holds the server configuration.
+type Config struct {
+	// Addr is the address the HTTP server listens on.
+	Addr string
+	// AllowedCategories restricts post categories when non-empty.
+	AllowedCategories []string
+	// DefaultCategory is given to new posts without a category; empty
+	// leaves them uncategorized.
+	DefaultCategory string
+	// MinSearchTermLength is the shortest accepted search term.
+	MinSearchTermLength int
+	// MaxQueryFilters caps the filters one listing request may combine.
+	MaxQueryFilters int
+	// CORSAllowedOrigins enables CORS for these origins when non-empty.
+	CORSAllowedOrigins []string
+	// CORSAllowCredentials allows credentialed cross-origin requests.
+	CORSAllowCredentials bool
+	// CORSMaxAge is how long browsers may cache preflight responses.
+	CORSMaxAge time.Duration
+	// CORSPublicFeeds lets any origin read the feed routes, whatever
+	// CORSAllowedOrigins says.
+	CORSPublicFeeds bool
+	// GzipLevel is the response compression level; zero disables
+	// compression.
+	GzipLevel int
+	// GzipMinSize is the smallest response body that is compressed.
+	GzipMinSize int
+	// GzipContentTypes lists the media types that are compressed; defaults
+	// are used when it is empty.
+	GzipContentTypes []string
+	// RequestTimeout bounds how long a request may run; zero disables it.
+	RequestTimeout time.Duration
+	// SlowRequestThreshold is the duration at which a request is logged
+	// as slow; zero disables it.
+	SlowRequestThreshold time.Duration
+	// ShutdownTimeout bounds how long shutdown waits for in-flight
+	// requests to finish.
+	ShutdownTimeout time.Duration
+	// AdminToken is the bearer token for admin-only endpoints; they are
+	// disabled when it is empty.
+	AdminToken string
+	// AllowReset enables the admin endpoint that wipes the store.
+	AllowReset bool
+	// RequireJSONContentType rejects write bodies not sent as
+	// application/json.
+	RequireJSONContentType bool
+	// StrictText rejects posts with invalid UTF-8 or control characters in
+	// their text fields.
+	StrictText bool
+	// MaxCommentLength is the longest accepted comment; zero means
+	// unlimited.
+	MaxCommentLength int
+	// CommentPageSize is the default page size of comment listings; zero
+	// lists every comment.
+	CommentPageSize int
+	// MaxPostsPerAuthor caps each author's posts; zero means unlimited.
+	MaxPostsPerAuthor int
+	// ReviewMinWords and ReviewStaleAfter are the review queue's short
+	// content and staleness thresholds; zero disables either.
+	ReviewMinWords   int
+	ReviewStaleAfter time.Duration
+	// AutoTagCount caps the tags extracted by POST /posts?autoTag=true,
+	// and AutoTagStopwords, when set, replaces the words it skips.
+	AutoTagCount     int
+	AutoTagStopwords []string
+	// TrustedProxies lists the CIDR ranges whose forwarding headers are
+	// trusted to carry the client IP.
+	TrustedProxies []string
+	// CanonicalHost is the host, or scheme and host, that requests for
+	// other hosts are redirected to; empty disables the redirect.
+	CanonicalHost string
+	// BaseURL is the scheme and host of post permalinks; empty uses each
+	// request's own.
+	BaseURL string
+	// RateLimit caps requests per minute from each client IP; zero
+	// disables it.
+	RateLimit int
+	// MaxPosts caps how many posts the store holds; zero means unlimited.
+	MaxPosts int
+	// MinContentLength is the fewest characters a post's content may have;
+	// zero disables the check.
+	MinContentLength int
+	// MaxResults caps the posts one store listing returns, below the API's
+	// page size; zero means unlimited.
+	MaxResults int
+	// MaxCommentsPerPost caps how many comments one post may have; zero
+	// means unlimited.
+	MaxCommentsPerPost int
+	// CommentDedupWindow is how long a repeated comment by the same author
+	// on the same post returns the first instead of adding another; zero
+	// disables it.
+	CommentDedupWindow time.Duration
+	// MinUpdateInterval is the shortest time allowed between updates of
+	// one post; zero disables it.
+	MinUpdateInterval time.Duration
+	// MaxConcurrentRequests caps requests in flight at once; zero disables
+	// it.
+	MaxConcurrentRequests int
+	// ReadOnly rejects every write request while set.
+	ReadOnly bool
+	// IDSecret enables post ID obfuscation, keying the tokens that replace
+	// integer IDs; IDs are plain integers when it is empty.
+	IDSecret string
+	// IDsAsStrings serializes integer IDs as JSON strings for clients that
+	// would round IDs beyond 2^53.
+	IDsAsStrings bool
+	// RecentCacheSize caches the newest published posts behind GET
+	// /posts/recent; zero disables the cache.
+	RecentCacheSize int
+	// SearchIndex has the store keep an inverted index for term searches.
+	SearchIndex bool
+	// DefaultSort is the order of post listings that name none: newest,
+	// oldest, updated, or home. Empty keeps ascending ID order.
+	DefaultSort string
+	// EmptyListNoContent answers empty post listings with 204 instead of
+	// 200 and [].
+	EmptyListNoContent bool
+	// WebhookURL receives post events; webhooks are disabled when it is
+	// empty.
+	WebhookURL string
+	// WebhookSecret signs webhook bodies in the X-Signature header.
+	WebhookSecret string
+	// LogLevel is the minimum level logged.
+	LogLevel slog.Level
+}
+
+// Load reads the configuration from environment variables, falling back to
+// defaults for anything unset.
+func Load() Config {
+	cfg := Config{
+		Addr:                   ":8080",
+		DefaultCategory:        "uncategorized",
+		MinSearchTermLength:    handler.DefaultMinSearchTermLength,
+		MaxQueryFilters:        handler.DefaultMaxQueryFilters,
+		RequestTimeout:         30 * time.Second,
+		SlowRequestThreshold:   500 * time.Millisecond,
+		ShutdownTimeout:        10 * time.Second,
+		RequireJSONContentType: true,
+		CORSPublicFeeds:        true,
+		MaxCommentLength:       handler.DefaultMaxCommentLength,
+		ReviewMinWords:         handler.DefaultReviewMinWords,
+		ReviewStaleAfter:       handler.DefaultReviewStaleAfter,
+		AutoTagCount:           handler.DefaultAutoTagCount,
+	}
+
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Addr = ":" + port
+	}
+	cfg.AllowedCategories = splitList(os.Getenv("ALLOWED_CATEGORIES"))
+	if category, ok := os.LookupEnv("DEFAULT_CATEGORY"); ok {
+		cfg.DefaultCategory = strings.TrimSpace(category)
+	}
+	// A default outside the allowlist would make every post created without
+	// a category fail validation, so none is applied instead.
+	if len(cfg.AllowedCategories) > 0 && !containsFold(cfg.AllowedCategories, cfg.DefaultCategory) {
+		cfg.DefaultCategory = ""
+	}
+	if n, err := strconv.Atoi(os.Getenv("SEARCH_MIN_TERM_LENGTH")); err == nil && n >= 0 {
+		cfg.MinSearchTermLength = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("MAX_QUERY_FILTERS")); err == nil && n >= 0 {
+		cfg.MaxQueryFilters = n
+	}
+
+	cfg.CORSAllowedOrigins = splitList(os.Getenv("CORS_ALLOWED_ORIGINS"))
+	cfg.CORSAllowCredentials, _ = strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS"))
+	if n, err := strconv.Atoi(os.Getenv("CORS_MAX_AGE")); err == nil && n >= 0 {
+		cfg.CORSMaxAge = time.Duration(n) * time.Second
+	}
+	if b, err := strconv.ParseBool(os.Getenv("CORS_PUBLIC_FEEDS")); err == nil {
+		cfg.CORSPublicFeeds = b
+	}
+
+	if n, err := strconv.Atoi(os.Getenv("GZIP_LEVEL")); err == nil && n >= gzip.DefaultCompression && n <= gzip.BestCompression {
+		cfg.GzipLevel = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("GZIP_MIN_SIZE")); err == nil && n >= 0 {
+		cfg.GzipMinSize = n
+	}
+	cfg.GzipContentTypes = splitList(os.Getenv("GZIP_CONTENT_TYPES"))
+
+	if n, err := strconv.Atoi(os.Getenv("REQUEST_TIMEOUT")); err == nil && n >= 0 {
+		cfg.RequestTimeout = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("SLOW_REQUEST_MS")); err == nil && n >= 0 {
+		cfg.SlowRequestThreshold = time.Duration(n) * time.Millisecond
+	}
+	if n, err := strconv.Atoi(os.Getenv("SHUTDOWN_TIMEOUT")); err == nil && n >= 0 {
+		cfg.ShutdownTimeout = time.Duration(n) * time.Second
+	}
+
+	if n, err := strconv.Atoi(os.Getenv("COMMENT_MAX_LENGTH")); err == nil && n >= 0 {
+		cfg.MaxCommentLength = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("COMMENT_PAGE_SIZE")); err == nil && n >= 0 && n <= 100 {
+		cfg.CommentPageSize = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("MAX_POSTS_PER_AUTHOR")); err == nil && n >= 0 {
+		cfg.MaxPostsPerAuthor = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("REVIEW_MIN_WORDS")); err == nil && n >= 0 {
+		cfg.ReviewMinWords = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("REVIEW_STALE_DAYS")); err == nil && n >= 0 {
+		cfg.ReviewStaleAfter = time.Duration(n) * 24 * time.Hour
+	}
+	if n, err := strconv.Atoi(os.Getenv("AUTO_TAG_COUNT")); err == nil && n >= 0 {
+		cfg.AutoTagCount = n
+	}
+	cfg.AutoTagStopwords = splitList(os.Getenv("AUTO_TAG_STOPWORDS"))
+	cfg.TrustedProxies = splitList(os.Getenv("TRUSTED_PROXIES"))
+	cfg.CanonicalHost = strings.TrimSpace(os.Getenv("CANONICAL_HOST"))
+	cfg.BaseURL = strings.TrimSuffix(strings.TrimSpace(os.Getenv("BASE_URL")), "/")
+	if n, err := strconv.Atoi(os.Getenv("RATE_LIMIT")); err == nil && n >= 0 {
+		cfg.RateLimit = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("MAX_POSTS")); err == nil && n >= 0 {
+		cfg.MaxPosts = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("MIN_CONTENT_LENGTH")); err == nil && n >= 0 {
+		cfg.MinContentLength = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("STORE_MAX_RESULTS")); err == nil && n >= 0 {
+		cfg.MaxResults = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("MAX_COMMENTS_PER_POST")); err == nil && n >= 0 {
+		cfg.MaxCommentsPerPost = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("COMMENT_DEDUP_WINDOW")); err == nil && n >= 0 {
+		cfg.CommentDedupWindow = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("MIN_UPDATE_INTERVAL")); err == nil && n >= 0 {
+		cfg.MinUpdateInterval = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("MAX_CONCURRENT_REQUESTS")); err == nil && n >= 0 {
+		cfg.MaxConcurrentRequests = n
+	}
+	cfg.ReadOnly, _ = strconv.ParseBool(os.Getenv("READ_ONLY"))
+	cfg.EmptyListNoContent, _ = strconv.ParseBool(os.Getenv("EMPTY_LIST_NO_CONTENT"))
+	cfg.StrictText, _ = strconv.ParseBool(os.Getenv("STRICT_TEXT"))
+	cfg.SearchIndex, _ = strconv.ParseBool(os.Getenv("SEARCH_INDEX"))
+	switch sort := strings.TrimSpace(os.Getenv("DEFAULT_SORT")); sort {
+	case "newest", "oldest", "updated", "home":
+		cfg.DefaultSort = sort
+	}
+	if n, err := strconv.Atoi(os.Getenv("RECENT_CACHE_SIZE")); err == nil && n >= 0 {
+		cfg.RecentCacheSize = n
+	}
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(v)); err == nil {
+			cfg.LogLevel = level
+		}
+	}
+	cfg.AdminToken = os.Getenv("ADMIN_TOKEN")
+	cfg.AllowReset, _ = strconv.ParseBool(os.Getenv("ALLOW_RESET"))
+	cfg.IDSecret = os.Getenv("ID_SECRET")
+	cfg.IDsAsStrings, _ = strconv.ParseBool(os.Getenv("IDS_AS_STRINGS"))
+	cfg.WebhookURL = os.Getenv("WEBHOOK_URL")
+	cfg.WebhookSecret = os.Getenv("WEBHOOK_SECRET")
+	if b, err := strconv.ParseBool(os.Getenv("REQUIRE_JSON_CONTENT_TYPE")); err == nil {
+		cfg.RequireJSONContentType = b
+	}
+
+	return cfg
+}
+
+// splitList parses a comma-separated list, trimming whitespace and dropping
+// empty entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// containsFold reports whether list holds s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
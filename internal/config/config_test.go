@@ -0,0 +1,255 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("PORT", "")
+		t.Setenv("ALLOWED_CATEGORIES", "")
+		t.Setenv("SEARCH_MIN_TERM_LENGTH", "")
+		t.Setenv("MAX_QUERY_FILTERS", "")
+		t.Setenv("DEFAULT_CATEGORY", "")
+		os.Unsetenv("DEFAULT_CATEGORY")
+
+		cfg := Load()
+		if cfg.Addr != ":8080" {
+			t.Errorf("Load() Addr = %q, want %q", cfg.Addr, ":8080")
+		}
+		if len(cfg.AllowedCategories) != 0 {
+			t.Errorf("Load() AllowedCategories = %v, want none", cfg.AllowedCategories)
+		}
+		if cfg.DefaultCategory != "uncategorized" {
+			t.Errorf("Load() DefaultCategory = %q, want %q", cfg.DefaultCategory, "uncategorized")
+		}
+		if cfg.MinSearchTermLength != 2 {
+			t.Errorf("Load() MinSearchTermLength = %d, want %d", cfg.MinSearchTermLength, 2)
+		}
+		if cfg.MaxQueryFilters != 8 {
+			t.Errorf("Load() MaxQueryFilters = %d, want %d", cfg.MaxQueryFilters, 8)
+		}
+		if cfg.RequestTimeout != 30*time.Second {
+			t.Errorf("Load() RequestTimeout = %v, want %v", cfg.RequestTimeout, 30*time.Second)
+		}
+		if cfg.SlowRequestThreshold != 500*time.Millisecond {
+			t.Errorf("Load() SlowRequestThreshold = %v, want %v", cfg.SlowRequestThreshold, 500*time.Millisecond)
+		}
+		if cfg.ShutdownTimeout != 10*time.Second {
+			t.Errorf("Load() ShutdownTimeout = %v, want %v", cfg.ShutdownTimeout, 10*time.Second)
+		}
+		if !cfg.RequireJSONContentType {
+			t.Error("Load() RequireJSONContentType = false, want true")
+		}
+		if !cfg.CORSPublicFeeds {
+			t.Error("Load() CORSPublicFeeds = false, want true")
+		}
+		if cfg.MaxCommentLength != 2000 {
+			t.Errorf("Load() MaxCommentLength = %d, want %d", cfg.MaxCommentLength, 2000)
+		}
+		if cfg.ReviewMinWords != 100 || cfg.ReviewStaleAfter != 365*24*time.Hour {
+			t.Errorf("Load() review thresholds = %d, %v; want 100, %v", cfg.ReviewMinWords, cfg.ReviewStaleAfter, 365*24*time.Hour)
+		}
+		if cfg.AutoTagCount != 5 || cfg.AutoTagStopwords != nil {
+			t.Errorf("Load() auto-tagging = %d, %v; want 5 and the default stopwords", cfg.AutoTagCount, cfg.AutoTagStopwords)
+		}
+		if cfg.LogLevel != slog.LevelInfo {
+			t.Errorf("Load() LogLevel = %v, want %v", cfg.LogLevel, slog.LevelInfo)
+		}
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		t.Setenv("PORT", "9090")
+		t.Setenv("ALLOWED_CATEGORIES", " Technology, Travel ,,Food")
+		t.Setenv("SEARCH_MIN_TERM_LENGTH", "3")
+		t.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example.com,https://b.example.com")
+		t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+		t.Setenv("CORS_MAX_AGE", "600")
+		t.Setenv("CORS_PUBLIC_FEEDS", "false")
+		t.Setenv("REQUEST_TIMEOUT", "5")
+		t.Setenv("SLOW_REQUEST_MS", "250")
+		t.Setenv("SHUTDOWN_TIMEOUT", "3")
+		t.Setenv("REQUIRE_JSON_CONTENT_TYPE", "false")
+		t.Setenv("COMMENT_MAX_LENGTH", "500")
+		t.Setenv("COMMENT_PAGE_SIZE", "20")
+		t.Setenv("MAX_POSTS_PER_AUTHOR", "25")
+		t.Setenv("REVIEW_MIN_WORDS", "300")
+		t.Setenv("REVIEW_STALE_DAYS", "90")
+		t.Setenv("AUTO_TAG_COUNT", "3")
+		t.Setenv("AUTO_TAG_STOPWORDS", "post, blog")
+		t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.1")
+		t.Setenv("CANONICAL_HOST", " https://blog.example.com ")
+		t.Setenv("BASE_URL", "https://blog.example.com/")
+		t.Setenv("RATE_LIMIT", "120")
+		t.Setenv("LOG_LEVEL", "debug")
+		t.Setenv("MAX_CONCURRENT_REQUESTS", "64")
+		t.Setenv("READ_ONLY", "true")
+		t.Setenv("MAX_POSTS", "1000")
+		t.Setenv("STORE_MAX_RESULTS", "5000")
+		t.Setenv("MIN_CONTENT_LENGTH", "200")
+		t.Setenv("MAX_COMMENTS_PER_POST", "50")
+		t.Setenv("COMMENT_DEDUP_WINDOW", "10")
+		t.Setenv("MIN_UPDATE_INTERVAL", "5")
+		t.Setenv("DEFAULT_CATEGORY", " travel ")
+		t.Setenv("WEBHOOK_URL", "https://hooks.example.com/blog")
+		t.Setenv("WEBHOOK_SECRET", "s3cret")
+		t.Setenv("EMPTY_LIST_NO_CONTENT", "true")
+		t.Setenv("STRICT_TEXT", "true")
+		t.Setenv("ID_SECRET", "hush")
+		t.Setenv("IDS_AS_STRINGS", "true")
+		t.Setenv("SEARCH_INDEX", "true")
+		t.Setenv("DEFAULT_SORT", "home")
+		t.Setenv("MAX_QUERY_FILTERS", "3")
+		t.Setenv("ALLOW_RESET", "true")
+		t.Setenv("RECENT_CACHE_SIZE", "20")
+		t.Setenv("GZIP_LEVEL", "6")
+		t.Setenv("GZIP_MIN_SIZE", "2048")
+		t.Setenv("GZIP_CONTENT_TYPES", "application/json, text/plain")
+
+		cfg := Load()
+		if cfg.Addr != ":9090" {
+			t.Errorf("Load() Addr = %q, want %q", cfg.Addr, ":9090")
+		}
+		want := []string{"Technology", "Travel", "Food"}
+		if !reflect.DeepEqual(cfg.AllowedCategories, want) {
+			t.Errorf("Load() AllowedCategories = %v, want %v", cfg.AllowedCategories, want)
+		}
+		if cfg.MinSearchTermLength != 3 {
+			t.Errorf("Load() MinSearchTermLength = %d, want %d", cfg.MinSearchTermLength, 3)
+		}
+		if len(cfg.CORSAllowedOrigins) != 2 || !cfg.CORSAllowCredentials || cfg.CORSMaxAge != 10*time.Minute {
+			t.Errorf("Load() CORS = %v/%v/%v, want two origins with credentials and 10m max age",
+				cfg.CORSAllowedOrigins, cfg.CORSAllowCredentials, cfg.CORSMaxAge)
+		}
+		if cfg.CORSPublicFeeds {
+			t.Error("Load() CORSPublicFeeds = true, want false")
+		}
+		if cfg.RequestTimeout != 5*time.Second {
+			t.Errorf("Load() RequestTimeout = %v, want %v", cfg.RequestTimeout, 5*time.Second)
+		}
+		if cfg.SlowRequestThreshold != 250*time.Millisecond {
+			t.Errorf("Load() SlowRequestThreshold = %v, want %v", cfg.SlowRequestThreshold, 250*time.Millisecond)
+		}
+		if cfg.ShutdownTimeout != 3*time.Second {
+			t.Errorf("Load() ShutdownTimeout = %v, want %v", cfg.ShutdownTimeout, 3*time.Second)
+		}
+		if cfg.RequireJSONContentType {
+			t.Error("Load() RequireJSONContentType = true, want false")
+		}
+		if cfg.MaxCommentLength != 500 {
+			t.Errorf("Load() MaxCommentLength = %d, want %d", cfg.MaxCommentLength, 500)
+		}
+		if cfg.CommentPageSize != 20 {
+			t.Errorf("Load() CommentPageSize = %d, want %d", cfg.CommentPageSize, 20)
+		}
+		if cfg.MaxPostsPerAuthor != 25 {
+			t.Errorf("Load() MaxPostsPerAuthor = %d, want %d", cfg.MaxPostsPerAuthor, 25)
+		}
+		if cfg.ReviewMinWords != 300 || cfg.ReviewStaleAfter != 90*24*time.Hour {
+			t.Errorf("Load() review thresholds = %d, %v; want 300, %v", cfg.ReviewMinWords, cfg.ReviewStaleAfter, 90*24*time.Hour)
+		}
+		if cfg.AutoTagCount != 3 || !reflect.DeepEqual(cfg.AutoTagStopwords, []string{"post", "blog"}) {
+			t.Errorf("Load() auto-tagging = %d, %v; want 3, [post blog]", cfg.AutoTagCount, cfg.AutoTagStopwords)
+		}
+		if want := []string{"10.0.0.0/8", "192.168.1.1"}; !reflect.DeepEqual(cfg.TrustedProxies, want) {
+			t.Errorf("Load() TrustedProxies = %v, want %v", cfg.TrustedProxies, want)
+		}
+		if cfg.CanonicalHost != "https://blog.example.com" {
+			t.Errorf("Load() CanonicalHost = %q, want %q", cfg.CanonicalHost, "https://blog.example.com")
+		}
+		if cfg.BaseURL != "https://blog.example.com" {
+			t.Errorf("Load() BaseURL = %q, want %q", cfg.BaseURL, "https://blog.example.com")
+		}
+		if cfg.RateLimit != 120 {
+			t.Errorf("Load() RateLimit = %d, want %d", cfg.RateLimit, 120)
+		}
+		if cfg.MaxConcurrentRequests != 64 {
+			t.Errorf("Load() MaxConcurrentRequests = %d, want %d", cfg.MaxConcurrentRequests, 64)
+		}
+		if cfg.MaxPosts != 1000 {
+			t.Errorf("Load() MaxPosts = %d, want %d", cfg.MaxPosts, 1000)
+		}
+		if cfg.MaxResults != 5000 {
+			t.Errorf("Load() MaxResults = %d, want %d", cfg.MaxResults, 5000)
+		}
+		if cfg.MinContentLength != 200 {
+			t.Errorf("Load() MinContentLength = %d, want %d", cfg.MinContentLength, 200)
+		}
+		if cfg.MaxCommentsPerPost != 50 {
+			t.Errorf("Load() MaxCommentsPerPost = %d, want %d", cfg.MaxCommentsPerPost, 50)
+		}
+		if cfg.CommentDedupWindow != 10*time.Second {
+			t.Errorf("Load() CommentDedupWindow = %v, want %v", cfg.CommentDedupWindow, 10*time.Second)
+		}
+		if cfg.MinUpdateInterval != 5*time.Second {
+			t.Errorf("Load() MinUpdateInterval = %v, want %v", cfg.MinUpdateInterval, 5*time.Second)
+		}
+		if !cfg.ReadOnly {
+			t.Error("Load() ReadOnly = false, want true")
+		}
+		if cfg.LogLevel != slog.LevelDebug {
+			t.Errorf("Load() LogLevel = %v, want %v", cfg.LogLevel, slog.LevelDebug)
+		}
+		if cfg.DefaultCategory != "travel" {
+			t.Errorf("Load() DefaultCategory = %q, want %q", cfg.DefaultCategory, "travel")
+		}
+		if cfg.IDSecret != "hush" {
+			t.Errorf("Load() IDSecret = %q, want %q", cfg.IDSecret, "hush")
+		}
+		if !cfg.EmptyListNoContent {
+			t.Error("Load() EmptyListNoContent = false, want true")
+		}
+		if !cfg.StrictText {
+			t.Error("Load() StrictText = false, want true")
+		}
+		if !cfg.IDsAsStrings {
+			t.Error("Load() IDsAsStrings = false, want true")
+		}
+		if !cfg.SearchIndex {
+			t.Error("Load() SearchIndex = false, want true")
+		}
+		if cfg.DefaultSort != "home" {
+			t.Errorf("Load() DefaultSort = %q, want %q", cfg.DefaultSort, "home")
+		}
+		if cfg.MaxQueryFilters != 3 {
+			t.Errorf("Load() MaxQueryFilters = %d, want %d", cfg.MaxQueryFilters, 3)
+		}
+		if !cfg.AllowReset {
+			t.Error("Load() AllowReset = false, want true")
+		}
+		if cfg.RecentCacheSize != 20 {
+			t.Errorf("Load() RecentCacheSize = %d, want %d", cfg.RecentCacheSize, 20)
+		}
+		if cfg.GzipLevel != 6 || cfg.GzipMinSize != 2048 {
+			t.Errorf("Load() gzip = level %d, min size %d, want 6, 2048", cfg.GzipLevel, cfg.GzipMinSize)
+		}
+		if want := []string{"application/json", "text/plain"}; !reflect.DeepEqual(cfg.GzipContentTypes, want) {
+			t.Errorf("Load() GzipContentTypes = %v, want %v", cfg.GzipContentTypes, want)
+		}
+		if cfg.WebhookURL != "https://hooks.example.com/blog" || cfg.WebhookSecret != "s3cret" {
+			t.Errorf("Load() webhook = %q/%q, want the configured URL and secret", cfg.WebhookURL, cfg.WebhookSecret)
+		}
+	})
+
+	t.Run("default category disabled", func(t *testing.T) {
+		t.Setenv("DEFAULT_CATEGORY", "")
+
+		if cfg := Load(); cfg.DefaultCategory != "" {
+			t.Errorf("Load() DefaultCategory = %q, want empty", cfg.DefaultCategory)
+		}
+	})
+
+	t.Run("default category not allowed", func(t *testing.T) {
+		t.Setenv("ALLOWED_CATEGORIES", "go,rust")
+		t.Setenv("DEFAULT_CATEGORY", "")
+		os.Unsetenv("DEFAULT_CATEGORY")
+
+		if cfg := Load(); cfg.DefaultCategory != "" {
+			t.Errorf("Load() DefaultCategory = %q, want empty outside ALLOWED_CATEGORIES", cfg.DefaultCategory)
+		}
+	})
+}
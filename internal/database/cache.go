@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// RecentCache wraps a Store and keeps the result of RecentPosts for its
+// configured size, so repeated homepage requests don't query the store.
+// Every method that writes posts drops the cached result; any Store method
+// added later that changes a post's status, creation time, or deletion must
+// do the same. Comment methods pass through untouched.
+type RecentCache struct {
+	Store
+	size int
+
+	mu    sync.Mutex
+	posts []*model.Post
+	valid bool
+	// generation increases on every invalidation, so a result read from
+	// the store while a write was in progress is never cached.
+	generation uint64
+}
+
+// NewRecentCache returns s wrapped with a cache of its newest size published
+// posts.
+func NewRecentCache(s Store, size int) *RecentCache {
+	return &RecentCache{Store: s, size: size}
+}
+
+// RecentPosts returns up to n published posts, newest first, from the cache
+// when n is within its size.
+func (c *RecentCache) RecentPosts(ctx context.Context, n int) ([]*model.Post, error) {
+	if n > c.size {
+		return c.Store.RecentPosts(ctx, n)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.valid {
+		posts := clip(c.posts, n)
+		c.mu.Unlock()
+		return posts, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	posts, err := c.Store.RecentPosts(ctx, c.size)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.posts, c.valid = posts, true
+	}
+	return clip(posts, n), nil
+}
+
+// clip returns a copy of at most the first n posts.
+func clip(posts []*model.Post, n int) []*model.Post {
+	posts = posts[:min(n, len(posts))]
+	return append(make([]*model.Post, 0, len(posts)), posts...)
+}
+
+// invalidate drops the cached posts.
+func (c *RecentCache) invalidate() {
+	c.mu.Lock()
+	c.posts, c.valid = nil, false
+	c.generation++
+	c.mu.Unlock()
+}
+
+// The methods below write posts through to the wrapped Store and then drop
+// the cache, whether or not the write succeeded.
+
+func (c *RecentCache) CreatePost(ctx context.Context, post *model.Post) (int64, error) {
+	defer c.invalidate()
+	return c.Store.CreatePost(ctx, post)
+}
+
+func (c *RecentCache) UpdatePost(ctx context.Context, id int64, post *model.Post) (*model.Post, error) {
+	defer c.invalidate()
+	return c.Store.UpdatePost(ctx, id, post)
+}
+
+func (c *RecentCache) UpsertPost(ctx context.Context, id int64, post *model.Post) (*model.Post, bool, error) {
+	defer c.invalidate()
+	return c.Store.UpsertPost(ctx, id, post)
+}
+
+func (c *RecentCache) DeletePost(ctx context.Context, id int64) error {
+	defer c.invalidate()
+	return c.Store.DeletePost(ctx, id)
+}
+
+func (c *RecentCache) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	defer c.invalidate()
+	return c.Store.PurgeDeleted(ctx, before)
+}
+
+func (c *RecentCache) PublishPost(ctx context.Context, id int64) (*model.Post, error) {
+	defer c.invalidate()
+	return c.Store.PublishPost(ctx, id)
+}
+
+func (c *RecentCache) UnpublishPost(ctx context.Context, id int64) (*model.Post, error) {
+	defer c.invalidate()
+	return c.Store.UnpublishPost(ctx, id)
+}
+
+func (c *RecentCache) TouchPost(ctx context.Context, id int64) (*model.Post, error) {
+	defer c.invalidate()
+	return c.Store.TouchPost(ctx, id)
+}
+
+func (c *RecentCache) PinPost(ctx context.Context, id int64, position int) (*model.Post, error) {
+	defer c.invalidate()
+	return c.Store.PinPost(ctx, id, position)
+}
+
+func (c *RecentCache) UnpinPost(ctx context.Context, id int64) (*model.Post, error) {
+	defer c.invalidate()
+	return c.Store.UnpinPost(ctx, id)
+}
+
+func (c *RecentCache) BulkPublish(ctx context.Context, ids []int64) ([]BulkResult, error) {
+	defer c.invalidate()
+	return c.Store.BulkPublish(ctx, ids)
+}
+
+func (c *RecentCache) BulkSetStatus(ctx context.Context, ids []int64, status string) ([]BulkResult, error) {
+	defer c.invalidate()
+	return c.Store.BulkSetStatus(ctx, ids, status)
+}
+
+func (c *RecentCache) Reset(ctx context.Context) error {
+	defer c.invalidate()
+	return c.Store.Reset(ctx)
+}
+
+func (c *RecentCache) RecomputeDerived(ctx context.Context) (int, error) {
+	defer c.invalidate()
+	return c.Store.RecomputeDerived(ctx)
+}
+
+func (c *RecentCache) PublishDue(ctx context.Context, now time.Time) ([]int64, error) {
+	defer c.invalidate()
+	return c.Store.PublishDue(ctx, now)
+}
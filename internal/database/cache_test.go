@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// countingStore counts calls to RecentPosts on the wrapped store.
+type countingStore struct {
+	Store
+	calls int
+}
+
+func (s *countingStore) RecentPosts(ctx context.Context, n int) ([]*model.Post, error) {
+	s.calls++
+	return s.Store.RecentPosts(ctx, n)
+}
+
+func recentTitles(t *testing.T, store Store, n int) []string {
+	t.Helper()
+	posts, err := store.RecentPosts(context.Background(), n)
+	if err != nil {
+		t.Fatalf("RecentPosts() error = %v", err)
+	}
+	titles := make([]string, 0, len(posts))
+	for _, post := range posts {
+		titles = append(titles, post.Title)
+	}
+	return titles
+}
+
+func TestRecentCache(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingStore{Store: NewMemoryStore()}
+	cache := NewRecentCache(inner, 3)
+
+	cache.CreatePost(ctx, &model.Post{Title: "First", Content: "Content"})
+	draftID, _ := cache.CreatePost(ctx, &model.Post{Title: "Draft", Content: "Content", Status: model.StatusDraft})
+
+	recentTitles(t, cache, 3)
+	recentTitles(t, cache, 2)
+	if inner.calls != 1 {
+		t.Errorf("store was queried %d times, want 1 for repeated requests", inner.calls)
+	}
+
+	t.Run("publish", func(t *testing.T) {
+		cache.PublishPost(ctx, draftID)
+		if got := recentTitles(t, cache, 3); len(got) != 2 || got[0] != "Draft" {
+			t.Errorf("RecentPosts() after publish = %v, want the published draft first", got)
+		}
+	})
+
+	t.Run("scheduled post comes due", func(t *testing.T) {
+		publishAt := time.Now().Add(time.Hour)
+		cache.CreatePost(ctx, &model.Post{Title: "Scheduled", Content: "Content", Status: model.StatusScheduled, PublishAt: &publishAt})
+		if got := recentTitles(t, cache, 3); len(got) != 2 {
+			t.Errorf("RecentPosts() before due = %v, want 2 posts", got)
+		}
+		cache.PublishDue(ctx, publishAt)
+		if got := recentTitles(t, cache, 3); len(got) != 3 {
+			t.Errorf("RecentPosts() after due = %v, want 3 posts", got)
+		}
+	})
+
+	t.Run("unpublish and delete", func(t *testing.T) {
+		cache.UnpublishPost(ctx, draftID)
+		cache.DeletePost(ctx, 1)
+		if got := recentTitles(t, cache, 3); len(got) != 1 || got[0] != "Scheduled" {
+			t.Errorf("RecentPosts() = %v, want only the scheduled post", got)
+		}
+	})
+
+	t.Run("beyond the cache size", func(t *testing.T) {
+		calls := inner.calls
+		recentTitles(t, cache, 10)
+		if inner.calls != calls+1 {
+			t.Error("request beyond the cache size was not passed through")
+		}
+	})
+}
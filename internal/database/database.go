@@ -1,12 +1,258 @@
 package database
 
-import "github.com/gemini/go-blog-api/internal/model"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
 
-// Store defines the interface for database operations.
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+var (
+	// ErrPostNotFound is returned, possibly wrapped, when a post does not exist.
+	ErrPostNotFound = errors.New("not found")
+	// ErrAlreadyPublished is returned when publishing a post that is already published.
+	ErrAlreadyPublished = errors.New("post is already published")
+	// ErrAlreadyDraft is returned when unpublishing a post that is already a draft.
+	ErrAlreadyDraft = errors.New("post is already a draft")
+	// ErrNotPinned is returned when unpinning a post that is not pinned.
+	ErrNotPinned = errors.New("post is not pinned")
+	// ErrTooManyTags is returned when a post exceeds the store's tag limit.
+	ErrTooManyTags = errors.New("post has too many tags")
+	// ErrTitleTooLong and ErrContentTooLong are returned when a post's title
+	// or content exceeds the store's length limit.
+	ErrTitleTooLong   = errors.New("post title is too long")
+	ErrContentTooLong = errors.New("post content is too long")
+	// ErrContentTooShort is returned when a post's content is below the
+	// store's minimum length.
+	ErrContentTooShort = errors.New("post content is too short")
+	// ErrStoreFull is returned when creating a post in a store at capacity.
+	ErrStoreFull = errors.New("store is full")
+	// ErrAuthorLimitReached is returned when creating a post for an author
+	// who already has the store's most posts per author.
+	ErrAuthorLimitReached = errors.New("author has reached the post limit")
+	// ErrSlugTaken is returned when an explicit slug belongs to another post.
+	ErrSlugTaken = errors.New("slug is already in use")
+	// ErrCommentNotFound is returned, possibly wrapped, when a comment does
+	// not exist on the given post.
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrTooManyComments is returned when adding a comment to a post at the
+	// store's comment limit.
+	ErrTooManyComments = errors.New("post has reached the comment limit")
+	// ErrCommentNotDeleted is returned when restoring a comment that is not
+	// deleted.
+	ErrCommentNotDeleted = errors.New("comment is not deleted")
+	// ErrInvalidParent is returned when a reply's parent comment does not
+	// exist on the same post.
+	ErrInvalidParent = errors.New("parent comment does not exist on this post")
+	// ErrRevisionNotFound is returned, possibly wrapped, when a revision
+	// does not exist on the given post.
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrInvalidTransition is returned, possibly wrapped, when a post cannot
+	// move from its current status to the requested one.
+	ErrInvalidTransition = errors.New("status transition not allowed")
+	// ErrUpdateThrottled is returned, wrapped in an UpdateThrottledError,
+	// when a post is updated again before the store's minimum interval
+	// between updates has passed.
+	ErrUpdateThrottled = errors.New("post was updated too recently")
+)
+
+// UpdateThrottledError reports a throttled update and how long to wait
+// before the post may be updated again. It wraps ErrUpdateThrottled.
+type UpdateThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *UpdateThrottledError) Error() string {
+	return fmt.Sprintf("%v; retry after %v", ErrUpdateThrottled, e.RetryAfter)
+}
+
+func (e *UpdateThrottledError) Unwrap() error {
+	return ErrUpdateThrottled
+}
+
+// StatusDeleted is the CountByStatus key for soft-deleted posts.
+const StatusDeleted = "deleted"
+
+// Outcomes reported in a BulkResult.
+const (
+	BulkCreated   = "created"
+	BulkPublished = "published"
+	BulkUpdated   = "updated"
+	BulkSkipped   = "skipped"
+	BulkRejected  = "rejected"
+	BulkNotFound  = "not_found"
+)
+
+// BulkResult reports the outcome of a bulk operation for a single post, or
+// for a single comment of BulkAddComments.
+type BulkResult struct {
+	ID     int64  `json:"id"`
+	Result string `json:"result"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// TagCount is a tag or category name and the number of published posts
+// using it.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// AuthorStats totals an author's published posts: how many there are, the
+// words in them, and the approved comments left on them.
+type AuthorStats struct {
+	Posts    int `json:"posts"`
+	Words    int `json:"words"`
+	Comments int `json:"comments"`
+}
+
+// TagConflict groups the spellings of a tag that differ only in case or
+// surrounding whitespace. Tag is the lowercased, trimmed name they share,
+// and each form counts the posts using it verbatim.
+type TagConflict struct {
+	Tag   string     `json:"tag"`
+	Forms []TagCount `json:"forms"`
+}
+
+// Store defines the interface for database operations. Every method takes
+// the request context and returns its error once the context is done.
 type Store interface {
-	CreatePost(post *model.Post) (int64, error)
-	GetPost(id int64) (*model.Post, error)
-	GetAllPosts(term string) ([]*model.Post, error)
-	UpdatePost(id int64, post *model.Post) (*model.Post, error)
-	DeletePost(id int64) error
+	CreatePost(ctx context.Context, post *model.Post) (int64, error)
+	GetPost(ctx context.Context, id int64) (*model.Post, error)
+	// GetPostsByIDs batch-loads the listed posts, keyed by ID. Posts that
+	// do not exist or are in the trash are left out of the map.
+	GetPostsByIDs(ctx context.Context, ids []int64) (map[int64]*model.Post, error)
+	// GetAllPosts returns the page of posts selected by the filter and the
+	// total number of matching posts before pagination.
+	GetAllPosts(ctx context.Context, filter PostFilter) ([]*model.Post, int, error)
+	// UpdatePost replaces the editable fields of a post. It fails with an
+	// UpdateThrottledError when the store limits how often a post may be
+	// updated, as does UpsertPost when it updates.
+	UpdatePost(ctx context.Context, id int64, post *model.Post) (*model.Post, error)
+	// UpsertPost updates the post with the given ID or creates it under that
+	// ID, reporting whether it was created.
+	UpsertPost(ctx context.Context, id int64, post *model.Post) (*model.Post, bool, error)
+	// DeletePost soft-deletes a post. Other methods treat it as missing
+	// until PurgeDeleted removes it for good.
+	DeletePost(ctx context.Context, id int64) error
+	// PurgeDeleted permanently removes posts soft-deleted before the given
+	// time and returns how many were removed.
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+	// ListDeleted returns a page of soft-deleted posts, most recently
+	// deleted first, and the total number of soft-deleted posts. A zero
+	// limit means no limit.
+	ListDeleted(ctx context.Context, limit, offset int) ([]*model.Post, int, error)
+	PublishPost(ctx context.Context, id int64) (*model.Post, error)
+	UnpublishPost(ctx context.Context, id int64) (*model.Post, error)
+	// TouchPost marks a post as updated now without changing its content
+	// or recording a revision.
+	TouchPost(ctx context.Context, id int64) (*model.Post, error)
+	// PinPost pins a post at a 1-based position, shifting posts pinned at
+	// or after it down. Positions past the last pin append; pinning an
+	// already pinned post moves it.
+	PinPost(ctx context.Context, id int64, position int) (*model.Post, error)
+	// UnpinPost unpins a post, closing the gap in the pin order.
+	UnpinPost(ctx context.Context, id int64) (*model.Post, error)
+	// BulkPublish publishes every listed draft atomically, reporting the
+	// outcome for each ID in order.
+	BulkPublish(ctx context.Context, ids []int64) ([]BulkResult, error)
+	// BulkSetStatus moves every listed post to status atomically, checking
+	// each transition and reporting the outcome for each ID in order.
+	BulkSetStatus(ctx context.Context, ids []int64, status string) ([]BulkResult, error)
+	// SlugExists returns the ID of the post using slug, or 0 if none does.
+	SlugExists(ctx context.Context, slug string) (int64, error)
+	// FindByNormalizedTitle returns a published post whose title matches
+	// once case, punctuation, and whitespace are ignored, or nil if none does.
+	FindByNormalizedTitle(ctx context.Context, title string) (*model.Post, error)
+	CountPosts(ctx context.Context) (int, error)
+	// Reset deletes every post, comment, and revision and restarts post and
+	// comment IDs at 1.
+	Reset(ctx context.Context) error
+	// RecomputeDerived refreshes every post's derived fields, such as
+	// WordCount and a missing slug, and returns how many posts changed.
+	// Changed posts get a new UpdatedAt.
+	RecomputeDerived(ctx context.Context) (int, error)
+	// ReviewQueue returns the published posts that criteria flags for
+	// review, those with the most reasons first and then by ascending ID.
+	ReviewQueue(ctx context.Context, criteria ReviewCriteria) ([]ReviewItem, error)
+	// RepairNextID raises the next post ID past every stored post, which
+	// keeps the next create from colliding after posts were loaded behind
+	// the store's back. It returns the next ID before and after.
+	RepairNextID(ctx context.Context) (previous, next int64, err error)
+	// CountByStatus tallies posts by status in one pass. Soft-deleted posts
+	// are counted only under StatusDeleted, and every status is present
+	// even when its count is zero.
+	CountByStatus(ctx context.Context) (map[string]int, error)
+	// CountPostsByAuthor counts an author's posts in any status, matching
+	// the author case-insensitively.
+	CountPostsByAuthor(ctx context.Context, author string) (int, error)
+	// AuthorStats totals an author's published posts, matching the author
+	// case-insensitively. An unknown author has zero stats.
+	AuthorStats(ctx context.Context, author string) (AuthorStats, error)
+	// PublishedBounds returns the earliest and latest CreatedAt among
+	// published posts, or nils if there are none.
+	PublishedBounds(ctx context.Context) (first, last *time.Time, err error)
+	// PublishDue publishes every scheduled post whose PublishAt is at or
+	// before now, returning their IDs in ascending order.
+	PublishDue(ctx context.Context, now time.Time) ([]int64, error)
+	// ListTags returns the tags on published posts, grouped
+	// case-insensitively, ordered by descending count.
+	ListTags(ctx context.Context) ([]TagCount, error)
+	// TagConflicts returns the tags of live posts, drafts included, that
+	// are spelled more than one way, most used first.
+	TagConflicts(ctx context.Context) ([]TagConflict, error)
+	// SuggestTags returns up to limit tags from ListTags containing q,
+	// ignoring case, with those starting with q first.
+	SuggestTags(ctx context.Context, q string, limit int) ([]TagCount, error)
+	// SuggestCategories returns up to limit categories of published posts
+	// starting with q, ignoring case, most used first.
+	SuggestCategories(ctx context.Context, q string, limit int) ([]TagCount, error)
+	// RecentPosts returns up to n published posts, newest first.
+	RecentPosts(ctx context.Context, n int) ([]*model.Post, error)
+	// MostCommented returns copies of up to n published posts with approved
+	// comments, with CommentCount set, most commented first and newest
+	// first among ties.
+	MostCommented(ctx context.Context, n int) ([]*model.Post, error)
+	// Neighbors returns the published posts created immediately before and
+	// after the post with the given ID, or nil at either end of the
+	// timeline. The post itself may be in any status.
+	Neighbors(ctx context.Context, id int64) (prev, next *model.Post, err error)
+	// OnThisDay returns the published posts created on the month and day of
+	// date in earlier years, comparing in date's location, newest first.
+	OnThisDay(ctx context.Context, date time.Time) ([]*model.Post, error)
+
+	// AddComment stores a pending comment on the post with the given ID,
+	// setting its ID, post ID, status, and creation time. A reply's ParentID
+	// must name a comment on the same post.
+	AddComment(ctx context.Context, postID int64, comment *model.Comment) (*model.Comment, error)
+	// BulkAddComments stores comments on the post with the given ID
+	// atomically, reporting the outcome for each in order. Unlike
+	// AddComment it keeps a comment's status and creation time when set,
+	// for migrations, and skips deduplication.
+	BulkAddComments(ctx context.Context, postID int64, comments []*model.Comment) ([]BulkResult, error)
+	// GetComment returns the comment with the given ID on any post. Deleted
+	// comments and those on deleted posts are not found.
+	GetComment(ctx context.Context, commentID int64) (*model.Comment, error)
+	// GetComments returns the page of a post's comments selected by the
+	// filter, oldest first, and the total number of matching comments.
+	GetComments(ctx context.Context, postID int64, filter CommentFilter) ([]*model.Comment, int, error)
+	// CommentsForPosts batch-loads up to perPost approved comments, oldest
+	// first, for each listed post. Posts without any are left out of the
+	// map.
+	CommentsForPosts(ctx context.Context, postIDs []int64, perPost int) (map[int64][]*model.Comment, error)
+	// DeleteComment soft-deletes a comment. Comment listings show a deleted
+	// comment as a tombstone with DeletedCommentContent while a listed
+	// comment replies to it, and leave it out otherwise.
+	DeleteComment(ctx context.Context, postID, commentID int64) error
+	// RestoreComment undoes DeleteComment.
+	RestoreComment(ctx context.Context, postID, commentID int64) (*model.Comment, error)
+	ApproveComment(ctx context.Context, postID, commentID int64) (*model.Comment, error)
+	RejectComment(ctx context.Context, postID, commentID int64) (*model.Comment, error)
+
+	// GetRevisions returns a post's revisions, oldest first.
+	GetRevisions(ctx context.Context, postID int64) ([]*model.Revision, error)
+	// GetRevision returns the revision of a post with the given number.
+	GetRevision(ctx context.Context, postID int64, number int) (*model.Revision, error)
 }
@@ -0,0 +1,58 @@
+package database
+
+import "time"
+
+// commentKey identifies comments that count as the same submission.
+type commentKey struct {
+	postID  int64
+	author  string
+	content string
+}
+
+type dedupEntry struct {
+	key       commentKey
+	commentID int64
+	at        time.Time
+}
+
+// commentDedup remembers the comments added within the last window so a
+// repeated submission can be answered with the comment it repeats. It is not
+// safe for concurrent use; MemoryStore guards it with its lock.
+type commentDedup struct {
+	window time.Duration
+	recent map[commentKey]dedupEntry
+	// queue holds the entries oldest first, so expired ones are dropped
+	// from its front.
+	queue []dedupEntry
+}
+
+func newCommentDedup(window time.Duration) *commentDedup {
+	return &commentDedup{window: window, recent: make(map[commentKey]dedupEntry)}
+}
+
+// lookup returns the ID of the comment added under key within the window.
+func (d *commentDedup) lookup(key commentKey, now time.Time) (int64, bool) {
+	d.expire(now)
+	entry, ok := d.recent[key]
+	return entry.commentID, ok
+}
+
+// record remembers that the comment with id was added under key at now.
+func (d *commentDedup) record(key commentKey, id int64, now time.Time) {
+	entry := dedupEntry{key: key, commentID: id, at: now}
+	d.recent[key] = entry
+	d.queue = append(d.queue, entry)
+}
+
+// expire drops the entries older than the window. An expired queue entry
+// leaves the map alone if a later comment has replaced it there.
+func (d *commentDedup) expire(now time.Time) {
+	n := 0
+	for ; n < len(d.queue) && now.Sub(d.queue[n].at) >= d.window; n++ {
+		old := d.queue[n]
+		if entry := d.recent[old.key]; entry.commentID == old.commentID {
+			delete(d.recent, old.key)
+		}
+	}
+	d.queue = d.queue[n:]
+}
@@ -0,0 +1,335 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// Sort orders accepted by PostFilter.Sort.
+const (
+	SortNewest  = "newest"
+	SortOldest  = "oldest"
+	SortUpdated = "updated"
+	// SortHome orders posts for a homepage: pinned posts in pin order,
+	// then featured posts, then the rest, newest first within each group.
+	// Drafts are left out even when the filter's Status asks for them.
+	SortHome = "home"
+	// SortPublishAt orders posts by PublishAt, soonest first, with posts
+	// that have none last.
+	SortPublishAt = "publishAt"
+	// SortRelevance orders posts by how well they match Term, the order a
+	// search gets by default.
+	SortRelevance = "relevance"
+)
+
+// PostFilter selects, orders, and paginates posts in GetAllPosts. Zero-valued
+// fields apply no restriction.
+type PostFilter struct {
+	// Term matches posts whose title, content, or category contains it,
+	// ignoring case. It may quote phrases and exclude words; see
+	// SearchQuery for the grammar.
+	Term string
+	// IncludeComments also matches Term against the content of each post's
+	// approved comments.
+	IncludeComments bool
+	// Authors matches posts by any of the listed authors, ignoring case.
+	Authors []string
+	// Category and Tag match posts with that category or tag, ignoring case.
+	Category string
+	Tag      string
+	// Uncategorized matches only posts whose category is blank.
+	Uncategorized bool
+	// HasImage matches only posts with an image URL.
+	HasImage bool
+	// LinksTo matches posts whose raw content contains it, ignoring case,
+	// such as the URL of a page they link to.
+	LinksTo string
+	// Status matches posts with that status. It defaults to published.
+	Status string
+	// CreatedAfter and CreatedBefore bound CreatedAt; CreatedAfter is
+	// inclusive and CreatedBefore exclusive.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// UpdatedBefore matches posts last updated before it, exclusive. It
+	// makes the default order least recently updated first.
+	UpdatedBefore time.Time
+	// PublishAfter matches posts whose PublishAt is after it, exclusive.
+	// Posts without a PublishAt don't match.
+	PublishAfter time.Time
+	// IDFrom and IDTo bound the post ID, inclusive. Setting either makes
+	// the default order ascending ID regardless of the store's default.
+	IDFrom int64
+	IDTo   int64
+
+	// Sort is one of the Sort constants. When empty, the store's default
+	// order applies, which is ascending ID unless configured otherwise,
+	// UpdatedBefore is set, or Term orders posts by relevance.
+	Sort string
+	// Limit caps the number of posts returned; zero means no limit. Offset
+	// skips that many matching posts first.
+	Limit  int
+	Offset int
+
+	// search is Term parsed. Stores fill it in before scanning posts so
+	// that Term is parsed once; query parses it when it is nil.
+	search *SearchQuery
+	// commentMatches holds, for each included phrase of Term, the IDs of
+	// posts with an approved comment containing it. Stores fill it in one
+	// pass over the comments before scanning posts when IncludeComments is
+	// set.
+	commentMatches map[string]map[int64]bool
+}
+
+// Matches reports whether a post satisfies every criterion in the filter.
+// Soft-deleted posts never match.
+func (f PostFilter) Matches(post *model.Post) bool {
+	if post.DeletedAt != nil {
+		return false
+	}
+	status := f.Status
+	if status == "" {
+		status = model.StatusPublished
+	}
+	if post.Status != status || f.Sort == SortHome && post.Status == model.StatusDraft {
+		return false
+	}
+	if len(f.Authors) > 0 && !containsFold(f.Authors, post.Author) {
+		return false
+	}
+	if f.Category != "" && !strings.EqualFold(strings.TrimSpace(post.Category), f.Category) {
+		return false
+	}
+	if f.Tag != "" && !containsFold(post.Tags, f.Tag) {
+		return false
+	}
+	if f.Uncategorized && strings.TrimSpace(post.Category) != "" {
+		return false
+	}
+	if f.HasImage && post.ImageURL == "" {
+		return false
+	}
+	if !f.PublishAfter.IsZero() && (post.PublishAt == nil || !post.PublishAt.After(f.PublishAfter)) {
+		return false
+	}
+	if f.LinksTo != "" && !strings.Contains(strings.ToLower(post.Content), strings.ToLower(f.LinksTo)) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && post.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !post.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	if !f.UpdatedBefore.IsZero() && !post.UpdatedAt.Before(f.UpdatedBefore) {
+		return false
+	}
+	if (f.IDFrom > 0 && post.ID < f.IDFrom) || (f.IDTo > 0 && post.ID > f.IDTo) {
+		return false
+	}
+	if f.Term != "" {
+		query := f.query()
+		for _, phrase := range query.Include {
+			if !postContains(post, phrase) && !f.commentMatches[phrase][post.ID] {
+				return false
+			}
+		}
+		for _, phrase := range query.Exclude {
+			if postContains(post, phrase) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// apply sorts the matching posts and returns the requested page. Pinned
+// posts always come first. Without an explicit sort, posts are ordered by
+// UpdatedAt ascending when filtering for stale posts, by relevance when
+// searching, and by ID otherwise. Every order breaks ties by ID so that page
+// boundaries are stable across requests.
+func (f PostFilter) apply(posts []*model.Post) []*model.Post {
+	switch f.Sort {
+	case SortNewest:
+		sortByCreated(posts, true)
+	case SortOldest:
+		sortByCreated(posts, false)
+	case SortHome:
+		// Pinned posts are moved to the front below.
+		sort.Slice(posts, func(i, j int) bool {
+			a, b := posts[i], posts[j]
+			if a.Featured != b.Featured {
+				return a.Featured
+			}
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID > b.ID
+		})
+	case SortUpdated:
+		sort.Slice(posts, func(i, j int) bool {
+			if !posts[i].UpdatedAt.Equal(posts[j].UpdatedAt) {
+				return posts[i].UpdatedAt.After(posts[j].UpdatedAt)
+			}
+			return posts[i].ID > posts[j].ID
+		})
+	case SortRelevance:
+		f.sortByRelevance(posts)
+	case SortPublishAt:
+		sort.Slice(posts, func(i, j int) bool {
+			a, b := posts[i].PublishAt, posts[j].PublishAt
+			switch {
+			case a == nil || b == nil:
+				if (a == nil) != (b == nil) {
+					return b == nil
+				}
+			case !a.Equal(*b):
+				return a.Before(*b)
+			}
+			return posts[i].ID < posts[j].ID
+		})
+	default:
+		if !f.UpdatedBefore.IsZero() {
+			sort.Slice(posts, func(i, j int) bool {
+				if !posts[i].UpdatedAt.Equal(posts[j].UpdatedAt) {
+					return posts[i].UpdatedAt.Before(posts[j].UpdatedAt)
+				}
+				return posts[i].ID < posts[j].ID
+			})
+			break
+		}
+		if f.Term != "" {
+			f.sortByRelevance(posts)
+			break
+		}
+		sort.Slice(posts, func(i, j int) bool { return posts[i].ID < posts[j].ID })
+	}
+
+	// Pinned posts lead in pin order; the rest keep the order above.
+	sort.SliceStable(posts, func(i, j int) bool {
+		a, b := posts[i].PinOrder, posts[j].PinOrder
+		return a != nil && (b == nil || *a < *b)
+	})
+
+	if f.Offset >= len(posts) {
+		return posts[:0]
+	}
+	posts = posts[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(posts) {
+		posts = posts[:f.Limit]
+	}
+	return posts
+}
+
+// sortByRelevance orders posts by how well they match Term, best first,
+// breaking ties by ascending ID.
+func (f PostFilter) sortByRelevance(posts []*model.Post) {
+	scores := make(map[int64]relevanceScore, len(posts))
+	for _, post := range posts {
+		scores[post.ID] = f.relevance(post)
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		a, b := posts[i], posts[j]
+		if scores[a.ID] != scores[b.ID] {
+			return scores[a.ID].better(scores[b.ID])
+		}
+		return a.ID < b.ID
+	})
+}
+
+// relevanceScore counts a post's matches against the phrases of Term in
+// tiers, from the title down to the content and approved comments.
+type relevanceScore struct {
+	title, taxonomy, body int
+}
+
+// better reports whether s ranks above t. Tiers are compared in order, so
+// one more title match outranks any number of matches elsewhere.
+func (s relevanceScore) better(t relevanceScore) bool {
+	if s.title != t.title {
+		return s.title > t.title
+	}
+	if s.taxonomy != t.taxonomy {
+		return s.taxonomy > t.taxonomy
+	}
+	return s.body > t.body
+}
+
+// relevance scores a post against the phrases of Term by where each
+// matches: the title counts most, then the category and tags, then the
+// content and approved comments.
+func (f PostFilter) relevance(post *model.Post) relevanceScore {
+	var score relevanceScore
+	for _, phrase := range f.query().Include {
+		if strings.Contains(strings.ToLower(post.Title), phrase) {
+			score.title++
+		}
+		if strings.Contains(strings.ToLower(post.Category), phrase) {
+			score.taxonomy++
+		}
+		for _, tag := range post.Tags {
+			if strings.Contains(strings.ToLower(tag), phrase) {
+				score.taxonomy++
+				break
+			}
+		}
+		if strings.Contains(strings.ToLower(post.Content), phrase) {
+			score.body++
+		}
+		if f.commentMatches[phrase][post.ID] {
+			score.body++
+		}
+	}
+	return score
+}
+
+// query returns Term parsed.
+func (f PostFilter) query() *SearchQuery {
+	if f.search != nil {
+		return f.search
+	}
+	query := ParseSearch(f.Term)
+	return &query
+}
+
+// sortByCreated orders posts by CreatedAt, breaking ties by ID.
+func sortByCreated(posts []*model.Post, newestFirst bool) {
+	sort.Slice(posts, func(i, j int) bool {
+		a, b := posts[i], posts[j]
+		if newestFirst {
+			a, b = b, a
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// CommentFilter selects and paginates a post's comments in GetComments.
+type CommentFilter struct {
+	// Status matches comments with that moderation status; empty matches
+	// every status.
+	Status string
+	// NewestFirst orders comments newest first instead of oldest first.
+	NewestFirst bool
+	// Limit caps the number of comments returned; zero means no limit.
+	// Offset skips that many matching comments first.
+	Limit  int
+	Offset int
+}
+
+// page returns the filter's slice of comments, which must already be in
+// order.
+func (f CommentFilter) page(comments []*model.Comment) []*model.Comment {
+	if f.Offset >= len(comments) {
+		return comments[:0]
+	}
+	comments = comments[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(comments) {
+		comments = comments[:f.Limit]
+	}
+	return comments
+}
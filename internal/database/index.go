@@ -0,0 +1,121 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// searchIndex is an inverted index from lowercase word tokens in a post's
+// title, content, and category to the IDs of the posts containing them. It
+// is not safe for concurrent use; MemoryStore guards it with its lock.
+type searchIndex struct {
+	postings map[string]map[int64]struct{}
+	// tokens holds each indexed post's tokens so it can be removed.
+	tokens map[int64][]string
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[int64]struct{}),
+		tokens:   make(map[int64][]string),
+	}
+}
+
+// isWordRune reports whether r is part of a token.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// tokenize splits s on anything but letters and digits and returns its
+// distinct lowercase tokens.
+func tokenize(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !isWordRune(r) })
+	seen := make(map[string]bool, len(fields))
+	tokens := fields[:0]
+	for _, field := range fields {
+		if !seen[field] {
+			seen[field] = true
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
+
+// add indexes post, replacing any earlier entry for its ID.
+func (ix *searchIndex) add(post *model.Post) {
+	ix.remove(post.ID)
+	tokens := tokenize(post.Title + " " + post.Content + " " + post.Category)
+	for _, token := range tokens {
+		ids, ok := ix.postings[token]
+		if !ok {
+			ids = make(map[int64]struct{})
+			ix.postings[token] = ids
+		}
+		ids[post.ID] = struct{}{}
+	}
+	ix.tokens[post.ID] = tokens
+}
+
+// remove drops the post with the given ID from the index.
+func (ix *searchIndex) remove(id int64) {
+	for _, token := range ix.tokens[id] {
+		delete(ix.postings[token], id)
+		if len(ix.postings[token]) == 0 {
+			delete(ix.postings, token)
+		}
+	}
+	delete(ix.tokens, id)
+}
+
+// match returns the IDs of posts that may contain every lowercase phrase in
+// include, in their own fields or, per comments, in an approved comment. It
+// narrows by the phrases candidates can answer exactly and reports false if
+// there are none, in which case the caller must scan.
+func (ix *searchIndex) match(include []string, comments map[string]map[int64]bool) (map[int64]bool, bool) {
+	var ids map[int64]bool
+	for _, phrase := range include {
+		found, ok := ix.candidates(phrase)
+		if !ok {
+			continue
+		}
+		for id := range comments[phrase] {
+			found[id] = true
+		}
+		if ids != nil {
+			for id := range ids {
+				if !found[id] {
+					delete(ids, id)
+				}
+			}
+		} else {
+			ids = found
+		}
+	}
+	return ids, ids != nil
+}
+
+// candidates returns the IDs of posts whose title, content, or category
+// contains term, ignoring case. A run of letters and digits can only occur
+// inside a single token, so the result is exact for such terms. For any
+// other term it reports false and the caller must scan instead.
+func (ix *searchIndex) candidates(term string) (map[int64]bool, bool) {
+	term = strings.ToLower(term)
+	for _, r := range term {
+		if !isWordRune(r) {
+			return nil, false
+		}
+	}
+
+	ids := make(map[int64]bool)
+	for token, postings := range ix.postings {
+		if !strings.Contains(token, term) {
+			continue
+		}
+		for id := range postings {
+			ids[id] = true
+		}
+	}
+	return ids, true
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// searchIDs returns the IDs of the posts matching term, in order.
+func searchIDs(t testing.TB, store *MemoryStore, term string) []int64 {
+	t.Helper()
+	posts, _, err := store.GetAllPosts(context.Background(), PostFilter{Term: term})
+	if err != nil {
+		t.Fatalf("GetAllPosts(%q) error = %v", term, err)
+	}
+	ids := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
+
+func TestSearchIndexAgreesWithScan(t *testing.T) {
+	ctx := context.Background()
+	scan := NewMemoryStore()
+	indexed := NewMemoryStore(WithSearchIndex())
+
+	posts := []model.Post{
+		{Title: "Learning Go", Content: "Goroutines and channels.", Category: "Golang"},
+		{Title: "Rust ownership", Content: "Borrowing, lifetimes & more."},
+		{Title: "Café notes", Content: "Über-strong espresso.", Category: "Food"},
+		{Title: "Draft", Content: "e-mail me at go@example.com", Status: model.StatusDraft},
+	}
+	for _, store := range []*MemoryStore{scan, indexed} {
+		for _, post := range posts {
+			post := post
+			store.CreatePost(ctx, &post)
+		}
+		store.UpdatePost(ctx, 2, &model.Post{Title: "Rust borrowing", Content: "No more lifetimes."})
+		store.DeletePost(ctx, 3)
+	}
+
+	for _, term := range []string{"go", "GO", "rout", "lang", "lifetimes", "ownership", "café", "über", "e-mail", "mail", "@example", "missing", `"learning go"`, "go -golang", `-"no more"`, `rust -"no more"`} {
+		want := searchIDs(t, scan, term)
+		if got := searchIDs(t, indexed, term); !reflect.DeepEqual(got, want) {
+			t.Errorf("indexed search for %q = %v, scan = %v", term, got, want)
+		}
+	}
+
+	t.Run("purged posts", func(t *testing.T) {
+		indexed.remove(1)
+		if got := searchIDs(t, indexed, "goroutines"); len(got) != 0 {
+			t.Errorf("indexed search after remove = %v, want none", got)
+		}
+		if _, ok := indexed.index.tokens[1]; ok {
+			t.Error("index kept tokens for a removed post")
+		}
+	})
+}
+
+func BenchmarkGetAllPostsTerm(b *testing.B) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+	}{
+		{"scan", nil},
+		{"indexed", []Option{WithSearchIndex()}},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			store := NewMemoryStore(tt.opts...)
+			for i := 0; i < 5000; i++ {
+				store.CreatePost(context.Background(), &model.Post{
+					Title:   fmt.Sprintf("Post %d", i),
+					Content: fmt.Sprintf("Some longer body text for post number %d, about topic%d.", i, i%500),
+				})
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				searchIDs(b, store, "topic42")
+			}
+		})
+	}
+}
@@ -1,108 +1,1847 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gemini/go-blog-api/internal/model"
 )
 
+// scanCheckInterval is how many posts a scan visits between checks for a
+// cancelled context.
+const scanCheckInterval = 256
+
 // MemoryStore is an in-memory implementation of the Store interface.
 type MemoryStore struct {
 	mu     sync.RWMutex
 	posts  map[int64]*model.Post
 	nextID int64
+
+	// slugs maps each post's slug to its ID.
+	slugs map[string]int64
+
+	comments      map[int64]*model.Comment
+	nextCommentID int64
+
+	// revisions holds each post's revisions, oldest first.
+	revisions map[int64][]*model.Revision
+
+	maxTags          int
+	maxTitleLength   int
+	maxContentLength int
+	minContentLength int
+	maxPosts         int
+	maxPerAuthor     int
+	maxComments      int
+	maxResults       int
+	idGen            IDGenerator
+	defaultSort      string
+
+	// index, when set, answers term searches without scanning every post.
+	index *searchIndex
+	// dedup, when set, suppresses repeated comments within its window.
+	dedup *commentDedup
+	// minUpdateInterval, when set, is the shortest time allowed between
+	// updates of a post; lastUpdated holds when each was last updated.
+	minUpdateInterval time.Duration
+	lastUpdated       map[int64]time.Time
+
+	// now reports the current time for timestamps the store assigns.
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+// NewMemoryStore creates and returns a new MemoryStore. Without options it
+// assigns sequential IDs starting at 1 and places no limits on posts.
+func NewMemoryStore(opts ...Option) *MemoryStore {
+	s := &MemoryStore{
+		posts:         make(map[int64]*model.Post),
+		nextID:        1,
+		slugs:         make(map[string]int64),
+		comments:      make(map[int64]*model.Comment),
+		nextCommentID: 1,
+		revisions:     make(map[int64][]*model.Revision),
+		lastUpdated:   make(map[int64]time.Time),
+		now:           time.Now,
+		logger:        slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// checkPost enforces the store's invariants on a post about to be written.
+func (s *MemoryStore) checkPost(post *model.Post) error {
+	switch {
+	case s.maxTags > 0 && len(post.Tags) > s.maxTags:
+		return ErrTooManyTags
+	case s.maxTitleLength > 0 && utf8.RuneCountInString(post.Title) > s.maxTitleLength:
+		return ErrTitleTooLong
+	case s.maxContentLength > 0 && utf8.RuneCountInString(post.Content) > s.maxContentLength:
+		return ErrContentTooLong
+	}
+	return nil
 }
 
-// NewMemoryStore creates and returns a new MemoryStore.
-func NewMemoryStore() *MemoryStore {
-	return &MemoryStore{
-		posts:  make(map[int64]*model.Post),
-		nextID: 1,
+// checkMinContent enforces the minimum content length on a post about to
+// replace existing, which is nil for a new post. Content an update leaves
+// unchanged is not checked, so posts stored before the minimum was raised
+// can still be retagged.
+func (s *MemoryStore) checkMinContent(post, existing *model.Post) error {
+	if s.minContentLength <= 0 || (existing != nil && existing.Content == post.Content) {
+		return nil
+	}
+	if utf8.RuneCountInString(post.Content) < s.minContentLength {
+		return ErrContentTooShort
 	}
+	return nil
 }
 
 // CreatePost adds a new post to the store.
-func (s *MemoryStore) CreatePost(post *model.Post) (int64, error) {
+func (s *MemoryStore) CreatePost(ctx context.Context, post *model.Post) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := s.checkPost(post); err != nil {
+		return 0, err
+	}
+	if err := s.checkMinContent(post, nil); err != nil {
+		return 0, err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	post.ID = s.nextID
-	post.CreatedAt = time.Now().UTC()
-	post.UpdatedAt = time.Now().UTC()
+	if s.full() {
+		return 0, ErrStoreFull
+	}
 
-	s.posts[post.ID] = post
-	s.nextID++
+	id := s.nextID
+	if s.idGen != nil {
+		id = s.idGen()
+		if _, exists := s.posts[id]; exists || id <= 0 {
+			return 0, fmt.Errorf("generated post id %d is invalid or already in use", id)
+		}
+	}
+	if s.slugTaken(post.Slug, id) {
+		return 0, ErrSlugTaken
+	}
+
+	post.ID = id
+	if err := s.insert(post); err != nil {
+		return 0, err
+	}
 
 	return post.ID, nil
 }
 
+// full reports whether the store is at its post capacity. The caller must
+// hold the lock.
+func (s *MemoryStore) full() bool {
+	return s.maxPosts > 0 && len(s.posts) >= s.maxPosts
+}
+
+// insert stores a new post under its ID, setting its timestamps, default
+// status, derived fields, and a slug derived from the title if it has none.
+// It fails with ErrAuthorLimitReached, storing nothing, if the post's author
+// is at the limit. The caller must hold the write lock.
+func (s *MemoryStore) insert(post *model.Post) error {
+	if s.authorFull(post.Author) {
+		return ErrAuthorLimitReached
+	}
+	if post.Slug == "" {
+		post.Slug = s.uniqueSlug(model.Slugify(post.Title))
+	}
+	s.slugs[post.Slug] = post.ID
+	post.Derive()
+	post.PinOrder = nil
+	post.CreatedAt = s.now().UTC()
+
+	// Posts are published immediately unless created as drafts. Imported
+	// posts arrive with their original publication date, which is kept and
+	// used as their creation time too.
+	if post.Status == "" {
+		post.Status = model.StatusPublished
+	}
+	switch {
+	case post.Status != model.StatusPublished:
+		post.PublishedAt = nil
+	case post.PublishedAt != nil:
+		post.CreatedAt = post.PublishedAt.UTC()
+		publishedAt := post.CreatedAt
+		post.PublishedAt = &publishedAt
+	default:
+		publishedAt := post.CreatedAt
+		post.PublishedAt = &publishedAt
+	}
+	post.UpdatedAt = post.CreatedAt
+
+	s.posts[post.ID] = post
+	if s.index != nil {
+		s.index.add(post)
+	}
+	if post.ID >= s.nextID {
+		s.nextID = post.ID + 1
+	}
+	s.recordRevision(post)
+	return nil
+}
+
+// authorFull reports whether author already has the most posts an author
+// may have, counting drafts but not the trash. Posts without an author are
+// never limited. The caller must hold the lock.
+func (s *MemoryStore) authorFull(author string) bool {
+	if s.maxPerAuthor <= 0 || strings.TrimSpace(author) == "" {
+		return false
+	}
+	return s.countByAuthor(author) >= s.maxPerAuthor
+}
+
+// recordRevision appends a snapshot of the post's current fields to its
+// revision history. The caller must hold the write lock.
+func (s *MemoryStore) recordRevision(post *model.Post) {
+	revisions := s.revisions[post.ID]
+	s.revisions[post.ID] = append(revisions, &model.Revision{
+		Number:    len(revisions) + 1,
+		PostID:    post.ID,
+		Title:     post.Title,
+		Content:   post.Content,
+		Category:  post.Category,
+		Tags:      append([]string(nil), post.Tags...),
+		CreatedAt: post.UpdatedAt,
+	})
+}
+
 // GetPost retrieves a post by its ID.
-func (s *MemoryStore) GetPost(id int64) (*model.Post, error) {
+func (s *MemoryStore) GetPost(ctx context.Context, id int64) (*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	post, ok := s.posts[id]
+	post, ok := s.livePost(id)
 	if !ok {
-		return nil, fmt.Errorf("post with id %d not found", id)
+		return nil, errPostNotFound(id)
 	}
 	return post, nil
 }
 
-// GetAllPosts retrieves all posts, with an optional search term filter.
-func (s *MemoryStore) GetAllPosts(term string) ([]*model.Post, error) {
+// GetPostsByIDs retrieves the listed posts that exist and are not in the
+// trash, keyed by ID.
+func (s *MemoryStore) GetPostsByIDs(ctx context.Context, ids []int64) (map[int64]*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	posts := make([]*model.Post, 0, len(s.posts))
-	lowerTerm := strings.ToLower(term)
+	posts := make(map[int64]*model.Post, len(ids))
+	for _, id := range ids {
+		if post, ok := s.livePost(id); ok {
+			posts[id] = post
+		}
+	}
+	return posts, nil
+}
 
-	for _, post := range s.posts {
-		if term == "" ||
-			strings.Contains(strings.ToLower(post.Title), lowerTerm) ||
-			strings.Contains(strings.ToLower(post.Content), lowerTerm) ||
-			strings.Contains(strings.ToLower(post.Category), lowerTerm) {
+// GetAllPosts retrieves the posts matching the filter, along with the total
+// number of matches before pagination. No more than WithMaxResults posts are
+// returned.
+func (s *MemoryStore) GetAllPosts(ctx context.Context, filter PostFilter) ([]*model.Post, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if filter.Term != "" {
+		query := ParseSearch(filter.Term)
+		filter.search = &query
+	}
+	if filter.IncludeComments && filter.search != nil {
+		filter.commentMatches = s.commentMatches(filter.search.Include)
+	}
+
+	candidates := s.posts
+	if s.index != nil && filter.search != nil {
+		if ids, ok := s.index.match(filter.search.Include, filter.commentMatches); ok {
+			candidates = make(map[int64]*model.Post, len(ids))
+			for id := range ids {
+				if post, ok := s.posts[id]; ok {
+					candidates[id] = post
+				}
+			}
+		}
+	}
+
+	posts := make([]*model.Post, 0, len(candidates))
+	scanned := 0
+	for _, post := range candidates {
+		// Stop scanning a large store once the caller has gone away.
+		if scanned++; scanned%scanCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, 0, err
+			}
+		}
+		if filter.Matches(post) {
 			posts = append(posts, post)
 		}
 	}
 
-	return posts, nil
+	if filter.Sort == "" && filter.UpdatedBefore.IsZero() && filter.IDFrom == 0 && filter.IDTo == 0 {
+		filter.Sort = s.defaultSort
+	}
+	total := len(posts)
+	if s.maxResults > 0 && (filter.Limit == 0 || filter.Limit > s.maxResults) {
+		if total-filter.Offset > s.maxResults {
+			s.logger.Warn("post listing clamped",
+				"limit", filter.Limit, "offset", filter.Offset, "matches", total, "max", s.maxResults)
+		}
+		filter.Limit = s.maxResults
+	}
+	return filter.apply(posts), total, nil
+}
+
+// commentMatches returns, for each lowercase phrase, the IDs of posts with an
+// approved comment whose content contains it, ignoring case. The caller must
+// hold s.mu.
+func (s *MemoryStore) commentMatches(phrases []string) map[string]map[int64]bool {
+	matches := make(map[string]map[int64]bool, len(phrases))
+	for _, phrase := range phrases {
+		matches[phrase] = make(map[int64]bool)
+	}
+	for _, comment := range s.comments {
+		if comment.Status != model.CommentApproved || comment.DeletedAt != nil {
+			continue
+		}
+		content := strings.ToLower(comment.Content)
+		for _, phrase := range phrases {
+			if strings.Contains(content, phrase) {
+				matches[phrase][comment.PostID] = true
+			}
+		}
+	}
+	return matches
 }
 
 // UpdatePost updates an existing post.
-func (s *MemoryStore) UpdatePost(id int64, post *model.Post) (*model.Post, error) {
+func (s *MemoryStore) UpdatePost(ctx context.Context, id int64, post *model.Post) (*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.checkPost(post); err != nil {
+		return nil, err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	existingPost, ok := s.posts[id]
+	existingPost, ok := s.livePost(id)
 	if !ok {
-		return nil, fmt.Errorf("post with id %d not found", id)
+		return nil, errPostNotFound(id)
+	}
+	if s.slugTaken(post.Slug, id) {
+		return nil, ErrSlugTaken
+	}
+	if err := s.checkMinContent(post, existingPost); err != nil {
+		return nil, err
+	}
+	if err := s.throttleUpdate(id); err != nil {
+		return nil, err
 	}
 
-	// Update fields
-	existingPost.Title = post.Title
-	existingPost.Content = post.Content
-	existingPost.Category = post.Category
-	existingPost.Tags = post.Tags
-	existingPost.UpdatedAt = time.Now().UTC()
-
-	s.posts[id] = existingPost
+	s.applyUpdate(existingPost, post)
 
 	return existingPost, nil
 }
 
-// DeletePost removes a post from the store.
-func (s *MemoryStore) DeletePost(id int64) error {
+// UpsertPost updates the post with the given ID, or creates it under that ID
+// if it does not exist. It reports whether the post was created. Creating a
+// post advances the next ID past it so later creates don't collide.
+func (s *MemoryStore) UpsertPost(ctx context.Context, id int64, post *model.Post) (*model.Post, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	if err := s.checkPost(post); err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.slugTaken(post.Slug, id) {
+		return nil, false, ErrSlugTaken
+	}
+	existingPost, ok := s.livePost(id)
+	if err := s.checkMinContent(post, existingPost); err != nil {
+		return nil, false, err
+	}
+	if ok {
+		if err := s.throttleUpdate(id); err != nil {
+			return nil, false, err
+		}
+		s.applyUpdate(existingPost, post)
+		return existingPost, false, nil
+	}
+
+	// A soft-deleted post under this ID is replaced, as if purged. Limits
+	// are checked before removing it, so a rejected upsert keeps it.
+	if _, ok := s.posts[id]; !ok && s.full() {
+		return nil, false, ErrStoreFull
+	}
+	if s.authorFull(post.Author) {
+		return nil, false, ErrAuthorLimitReached
+	}
+	s.remove(id)
+	post.ID = id
+	if err := s.insert(post); err != nil {
+		return nil, false, err
+	}
+	return post, true, nil
+}
+
+// throttleUpdate returns an UpdateThrottledError if the post was updated
+// less than the minimum interval ago, and otherwise records the update. The
+// caller must hold the write lock.
+func (s *MemoryStore) throttleUpdate(id int64) error {
+	if s.minUpdateInterval <= 0 {
+		return nil
+	}
+	now := s.now()
+	if last, ok := s.lastUpdated[id]; ok {
+		if wait := s.minUpdateInterval - now.Sub(last); wait > 0 {
+			return &UpdateThrottledError{RetryAfter: wait}
+		}
+	}
+	s.lastUpdated[id] = now
+	return nil
+}
+
+// applyUpdate copies the editable fields of post onto existing. The slug is
+// kept unless post sets a new one. The caller must hold the write lock.
+func (s *MemoryStore) applyUpdate(existing, post *model.Post) {
+	if post.Slug != "" && post.Slug != existing.Slug {
+		delete(s.slugs, existing.Slug)
+		existing.Slug = post.Slug
+		s.slugs[existing.Slug] = existing.ID
+	}
+	existing.Title = post.Title
+	existing.Content = post.Content
+	existing.Category = post.Category
+	existing.Tags = post.Tags
+	existing.AuthorName = post.AuthorName
+	existing.Translations = post.Translations
+	existing.ImageURL = post.ImageURL
+	existing.AllowComments = post.AllowComments
+	existing.Featured = post.Featured
+	existing.PublishAt = post.PublishAt
+	existing.Derive()
+	existing.UpdatedAt = s.now().UTC()
+	if s.index != nil {
+		s.index.add(existing)
+	}
+	s.recordRevision(existing)
+}
+
+// DeletePost soft-deletes a post by setting its DeletedAt. The post is
+// hidden from every other method and its slug is freed, but it and its
+// comments are kept until PurgeDeleted removes them.
+func (s *MemoryStore) DeletePost(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, ok := s.posts[id]
+	post, ok := s.livePost(id)
 	if !ok {
-		return fmt.Errorf("post with id %d not found", id)
+		return errPostNotFound(id)
+	}
+
+	now := s.now().UTC()
+	s.unpin(post)
+	post.DeletedAt = &now
+	delete(s.slugs, post.Slug)
+	return nil
+}
+
+// PurgeDeleted permanently removes the posts soft-deleted before the given
+// time, along with their comments, and returns how many were removed.
+func (s *MemoryStore) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for id, post := range s.posts {
+		if post.DeletedAt != nil && post.DeletedAt.Before(before) {
+			s.remove(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// ListDeleted returns a page of soft-deleted posts ordered by DeletedAt,
+// newest first, along with the total number of soft-deleted posts.
+func (s *MemoryStore) ListDeleted(ctx context.Context, limit, offset int) ([]*model.Post, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := make([]*model.Post, 0)
+	for _, post := range s.posts {
+		if post.DeletedAt != nil {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].DeletedAt.Equal(*posts[j].DeletedAt) {
+			return posts[i].DeletedAt.After(*posts[j].DeletedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+
+	total := len(posts)
+	if offset >= total {
+		return posts[:0], total, nil
+	}
+	posts = posts[offset:]
+	if limit > 0 && limit < len(posts) {
+		posts = posts[:limit]
+	}
+	return posts, total, nil
+}
+
+// livePost returns the post with the given ID unless it does not exist or
+// has been soft-deleted. The caller must hold the lock.
+func (s *MemoryStore) livePost(id int64) (*model.Post, bool) {
+	post, ok := s.posts[id]
+	if !ok || post.DeletedAt != nil {
+		return nil, false
 	}
+	return post, true
+}
 
+// remove permanently deletes a post and its comments, if it exists. The
+// caller must hold the write lock.
+func (s *MemoryStore) remove(id int64) {
+	post, ok := s.posts[id]
+	if !ok {
+		return
+	}
+	if s.slugs[post.Slug] == id {
+		delete(s.slugs, post.Slug)
+	}
 	delete(s.posts, id)
+	delete(s.revisions, id)
+	delete(s.lastUpdated, id)
+	if s.index != nil {
+		s.index.remove(id)
+	}
+	for commentID, comment := range s.comments {
+		if comment.PostID == id {
+			delete(s.comments, commentID)
+		}
+	}
+}
+
+// PublishPost transitions a draft post to published.
+func (s *MemoryStore) PublishPost(ctx context.Context, id int64) (*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.livePost(id)
+	if !ok {
+		return nil, errPostNotFound(id)
+	}
+	if post.Status == model.StatusPublished {
+		return nil, ErrAlreadyPublished
+	}
+
+	now := s.now().UTC()
+	post.Status = model.StatusPublished
+	post.PublishedAt = &now
+	post.UpdatedAt = now
+
+	return post, nil
+}
+
+// BulkPublish publishes all listed drafts under a single lock. Posts that are
+// already published are skipped and unknown IDs are reported as not found.
+func (s *MemoryStore) BulkPublish(ctx context.Context, ids []int64) ([]BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	results := make([]BulkResult, 0, len(ids))
+	for _, id := range ids {
+		post, ok := s.livePost(id)
+		switch {
+		case !ok:
+			results = append(results, BulkResult{ID: id, Result: BulkNotFound})
+		case post.Status == model.StatusPublished:
+			results = append(results, BulkResult{ID: id, Result: BulkSkipped, Reason: ErrAlreadyPublished.Error()})
+		default:
+			publishedAt := now
+			post.Status = model.StatusPublished
+			post.PublishedAt = &publishedAt
+			post.UpdatedAt = now
+			results = append(results, BulkResult{ID: id, Result: BulkPublished})
+		}
+	}
+	return results, nil
+}
+
+// BulkSetStatus moves the listed posts to status under a single lock. Posts
+// already in that status are skipped; soft-deleted posts and disallowed
+// transitions are rejected with the reason.
+func (s *MemoryStore) BulkSetStatus(ctx context.Context, ids []int64, status string) ([]BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	switch status {
+	case model.StatusDraft, model.StatusPublished, model.StatusScheduled:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidTransition, status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	results := make([]BulkResult, 0, len(ids))
+	for _, id := range ids {
+		post, ok := s.posts[id]
+		if !ok {
+			results = append(results, BulkResult{ID: id, Result: BulkNotFound})
+			continue
+		}
+		if post.Status == status && post.DeletedAt == nil {
+			results = append(results, BulkResult{ID: id, Result: BulkSkipped, Reason: alreadyInStatus(status)})
+			continue
+		}
+		if err := checkTransition(post, status, now); err != nil {
+			results = append(results, BulkResult{ID: id, Result: BulkRejected, Reason: err.Error()})
+			continue
+		}
+
+		post.Status = status
+		switch status {
+		case model.StatusPublished:
+			publishedAt := now
+			post.PublishedAt = &publishedAt
+		case model.StatusDraft:
+			post.PublishedAt = nil
+		}
+		post.UpdatedAt = now
+		results = append(results, BulkResult{ID: id, Result: BulkUpdated})
+	}
+	return results, nil
+}
+
+// alreadyInStatus explains why a post already in status was skipped.
+func alreadyInStatus(status string) string {
+	switch status {
+	case model.StatusPublished:
+		return ErrAlreadyPublished.Error()
+	case model.StatusDraft:
+		return ErrAlreadyDraft.Error()
+	}
+	return "post is already " + status
+}
+
+// checkTransition reports why post may not move to status, or nil if it
+// may. Deleted posts keep their status, and a post can only be scheduled
+// from a draft with a future PublishAt.
+func checkTransition(post *model.Post, status string, now time.Time) error {
+	if post.DeletedAt != nil {
+		return fmt.Errorf("%w: post is deleted", ErrInvalidTransition)
+	}
+	if status != model.StatusScheduled {
+		return nil
+	}
+	switch {
+	case post.Status != model.StatusDraft:
+		return fmt.Errorf("%w: only drafts can be scheduled", ErrInvalidTransition)
+	case post.PublishAt == nil || !post.PublishAt.After(now):
+		return fmt.Errorf("%w: publishAt must be in the future", ErrInvalidTransition)
+	}
 	return nil
 }
+
+// PublishDue publishes all scheduled posts that are due under a single lock.
+// Each post's PublishedAt is set to its scheduled time rather than now, so a
+// late run doesn't change when the post appears to have gone live.
+func (s *MemoryStore) PublishDue(ctx context.Context, now time.Time) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id, post := range s.posts {
+		if post.DeletedAt != nil || post.Status != model.StatusScheduled || post.PublishAt == nil || post.PublishAt.After(now) {
+			continue
+		}
+		publishedAt := post.PublishAt.UTC()
+		post.Status = model.StatusPublished
+		post.PublishedAt = &publishedAt
+		post.UpdatedAt = now.UTC()
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// UnpublishPost moves a published post back to draft.
+func (s *MemoryStore) UnpublishPost(ctx context.Context, id int64) (*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.livePost(id)
+	if !ok {
+		return nil, errPostNotFound(id)
+	}
+	if post.Status == model.StatusDraft {
+		return nil, ErrAlreadyDraft
+	}
+
+	post.Status = model.StatusDraft
+	post.PublishedAt = nil
+	post.UpdatedAt = s.now().UTC()
+
+	return post, nil
+}
+
+// TouchPost sets a post's UpdatedAt to now, leaving its content alone.
+func (s *MemoryStore) TouchPost(ctx context.Context, id int64) (*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.livePost(id)
+	if !ok {
+		return nil, errPostNotFound(id)
+	}
+	post.UpdatedAt = s.now().UTC()
+
+	return post, nil
+}
+
+// PinPost pins a post at the given 1-based position.
+func (s *MemoryStore) PinPost(ctx context.Context, id int64, position int) (*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.livePost(id)
+	if !ok {
+		return nil, errPostNotFound(id)
+	}
+
+	s.unpin(post)
+	pinned := 0
+	for _, other := range s.posts {
+		if other.PinOrder != nil {
+			pinned++
+		}
+	}
+	position = max(1, min(position, pinned+1))
+	s.shiftPins(position, 1)
+	post.PinOrder = &position
+
+	return post, nil
+}
+
+// UnpinPost removes a post from the pin order.
+func (s *MemoryStore) UnpinPost(ctx context.Context, id int64) (*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.livePost(id)
+	if !ok {
+		return nil, errPostNotFound(id)
+	}
+	if post.PinOrder == nil {
+		return nil, ErrNotPinned
+	}
+
+	s.unpin(post)
+	return post, nil
+}
+
+// unpin clears a post's pin, if any, and moves the posts pinned after it up
+// one. The caller must hold the write lock.
+func (s *MemoryStore) unpin(post *model.Post) {
+	if post.PinOrder == nil {
+		return
+	}
+	position := *post.PinOrder
+	post.PinOrder = nil
+	s.shiftPins(position+1, -1)
+}
+
+// shiftPins moves every post pinned at or after position by delta. Fresh
+// ints are assigned so that posts already handed to callers are unaffected.
+// The caller must hold the write lock.
+func (s *MemoryStore) shiftPins(position, delta int) {
+	for _, post := range s.posts {
+		if post.PinOrder != nil && *post.PinOrder >= position {
+			order := *post.PinOrder + delta
+			post.PinOrder = &order
+		}
+	}
+}
+
+// Reset empties the store, keeping its options. IDs restart at 1.
+func (s *MemoryStore) Reset(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.posts = make(map[int64]*model.Post)
+	s.nextID = 1
+	s.slugs = make(map[string]int64)
+	s.comments = make(map[int64]*model.Comment)
+	s.nextCommentID = 1
+	s.revisions = make(map[int64][]*model.Revision)
+	s.lastUpdated = make(map[int64]time.Time)
+	if s.index != nil {
+		s.index = newSearchIndex()
+	}
+	if s.dedup != nil {
+		s.dedup = newCommentDedup(s.dedup.window)
+	}
+	return nil
+}
+
+// RepairNextID sets the next post ID to one past the highest stored ID,
+// counting soft-deleted posts. It never lowers it, since IDs of purged posts
+// may still be known to clients and must not be handed out again.
+func (s *MemoryStore) RepairNextID(ctx context.Context) (int64, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.nextID
+	for id := range s.posts {
+		if id >= s.nextID {
+			s.nextID = id + 1
+		}
+	}
+	return previous, s.nextID, nil
+}
+
+// RecomputeDerived refreshes the derived fields of every post, including
+// soft-deleted ones, and gives a slug to any post without one. Posts that
+// change get a new UpdatedAt, so their ETags change too. It returns how many
+// posts changed.
+func (s *MemoryStore) RecomputeDerived(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int64, 0, len(s.posts))
+	for id := range s.posts {
+		ids = append(ids, id)
+	}
+	// Visit posts in ID order so that clashing slugs are resolved the same
+	// way on every run.
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	updated := 0
+	now := s.now().UTC()
+	for _, id := range ids {
+		post := s.posts[id]
+		changed := post.Derive()
+		if post.Slug == "" {
+			post.Slug = s.uniqueSlug(model.Slugify(post.Title))
+			s.slugs[post.Slug] = post.ID
+			changed = true
+		}
+		if changed {
+			post.UpdatedAt = now
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// uniqueSlug returns base, or base with the smallest numeric suffix that no
+// post uses yet. The caller must hold the lock.
+func (s *MemoryStore) uniqueSlug(base string) string {
+	slug := base
+	for n := 2; s.slugs[slug] != 0; n++ {
+		suffix := "-" + strconv.Itoa(n)
+		slug = strings.TrimRight(base[:min(len(base), model.MaxSlugLength-len(suffix))], "-") + suffix
+	}
+	return slug
+}
+
+// slugTaken reports whether slug belongs to a post other than id. The
+// caller must hold the lock.
+func (s *MemoryStore) slugTaken(slug string, id int64) bool {
+	owner := s.slugs[slug]
+	return slug != "" && owner != 0 && owner != id
+}
+
+// SlugExists returns the ID of the post with the given slug, or 0 if there is
+// none.
+func (s *MemoryStore) SlugExists(ctx context.Context, slug string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.slugs[slug], nil
+}
+
+// FindByNormalizedTitle returns a published post whose normalized title
+// matches, or nil if there is none.
+func (s *MemoryStore) FindByNormalizedTitle(ctx context.Context, title string) (*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	normalized := normalizeTitle(title)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	var match *model.Post
+	for _, post := range s.posts {
+		if post.DeletedAt != nil || post.Status != model.StatusPublished || normalizeTitle(post.Title) != normalized {
+			continue
+		}
+		// Report the oldest match so the result is stable.
+		if match == nil || post.ID < match.ID {
+			match = post
+		}
+	}
+	return match, nil
+}
+
+// normalizeTitle lowercases a title and strips everything but letters and
+// digits, so titles differing only in case, punctuation, or spacing compare
+// equal.
+func normalizeTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// CountPosts returns the number of posts in the store, including drafts but
+// not soft-deleted posts.
+func (s *MemoryStore) CountPosts(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, post := range s.posts {
+		if post.DeletedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CountByStatus returns the number of posts in each status, plus the
+// soft-deleted posts under StatusDeleted.
+func (s *MemoryStore) CountByStatus(ctx context.Context) (map[string]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[string]int{
+		model.StatusDraft:     0,
+		model.StatusPublished: 0,
+		model.StatusScheduled: 0,
+		StatusDeleted:         0,
+	}
+	for _, post := range s.posts {
+		if post.DeletedAt != nil {
+			counts[StatusDeleted]++
+		} else {
+			counts[post.Status]++
+		}
+	}
+	return counts, nil
+}
+
+// CountPostsByAuthor returns how many posts the author has, including drafts.
+func (s *MemoryStore) CountPostsByAuthor(ctx context.Context, author string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.countByAuthor(author), nil
+}
+
+// countByAuthor counts the author's posts outside the trash. The caller
+// must hold the lock.
+func (s *MemoryStore) countByAuthor(author string) int {
+	count := 0
+	for _, post := range s.posts {
+		if post.DeletedAt == nil && strings.EqualFold(post.Author, author) {
+			count++
+		}
+	}
+	return count
+}
+
+// AuthorStats totals the author's published posts and their approved,
+// undeleted comments.
+func (s *MemoryStore) AuthorStats(ctx context.Context, author string) (AuthorStats, error) {
+	var stats AuthorStats
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := make(map[int64]bool)
+	for id, post := range s.posts {
+		if post.DeletedAt != nil || post.Status != model.StatusPublished || !strings.EqualFold(post.Author, author) {
+			continue
+		}
+		posts[id] = true
+		stats.Posts++
+		stats.Words += post.WordCount
+	}
+	for _, comment := range s.comments {
+		if posts[comment.PostID] && comment.Status == model.CommentApproved && comment.DeletedAt == nil {
+			stats.Comments++
+		}
+	}
+	return stats, nil
+}
+
+// PublishedBounds finds the oldest and newest published posts in one pass.
+func (s *MemoryStore) PublishedBounds(ctx context.Context) (first, last *time.Time, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, post := range s.posts {
+		if post.DeletedAt != nil || post.Status != model.StatusPublished {
+			continue
+		}
+		if first == nil || post.CreatedAt.Before(*first) {
+			createdAt := post.CreatedAt
+			first = &createdAt
+		}
+		if last == nil || post.CreatedAt.After(*last) {
+			createdAt := post.CreatedAt
+			last = &createdAt
+		}
+	}
+	return first, last, nil
+}
+
+// ListTags counts the tags on published posts. Tags differing only in case
+// are merged under the casing used most often, with ties going to the casing
+// that sorts first. A tag repeated on one post counts once.
+func (s *MemoryStore) ListTags(ctx context.Context) ([]TagCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tagCounts(), nil
+}
+
+// TagConflicts groups the tags of posts not in the trash by their
+// lowercased, trimmed name and returns the groups with more than one
+// spelling. Groups are ordered by the posts using them, descending, and
+// forms within a group by count, then name.
+func (s *MemoryStore) TagConflicts(ctx context.Context) ([]TagConflict, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	forms := make(map[string]map[string]int)
+	for _, post := range s.posts {
+		if post.DeletedAt != nil {
+			continue
+		}
+		seen := make(map[string]bool, len(post.Tags))
+		for _, tag := range post.Tags {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			key := strings.ToLower(strings.TrimSpace(tag))
+			if forms[key] == nil {
+				forms[key] = make(map[string]int)
+			}
+			forms[key][tag]++
+		}
+	}
+
+	conflicts := make([]TagConflict, 0)
+	totals := make(map[string]int)
+	for key, counts := range forms {
+		if len(counts) < 2 {
+			continue
+		}
+		conflict := TagConflict{Tag: key}
+		for name, n := range counts {
+			conflict.Forms = append(conflict.Forms, TagCount{Name: name, Count: n})
+			totals[key] += n
+		}
+		sort.Slice(conflict.Forms, func(i, j int) bool {
+			a, b := conflict.Forms[i], conflict.Forms[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			return a.Name < b.Name
+		})
+		conflicts = append(conflicts, conflict)
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		a, b := conflicts[i].Tag, conflicts[j].Tag
+		if totals[a] != totals[b] {
+			return totals[a] > totals[b]
+		}
+		return a < b
+	})
+	return conflicts, nil
+}
+
+// SuggestTags returns up to limit tags containing q, ignoring case. Tags
+// starting with q come first; within each group, tags are ordered as in
+// ListTags.
+func (s *MemoryStore) SuggestTags(ctx context.Context, q string, limit int) ([]TagCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return suggest(s.tagCounts(), q, limit, true), nil
+}
+
+// SuggestCategories returns up to limit categories of published posts
+// starting with q, ignoring case, most used first.
+func (s *MemoryStore) SuggestCategories(ctx context.Context, q string, limit int) ([]TagCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return suggest(s.categoryCounts(), q, limit, false), nil
+}
+
+// suggest filters counts, already in ranked order, to those whose name
+// starts with q or, if substrings is set, contains it. Prefix matches come
+// ahead of the rest, and at most limit are kept.
+func suggest(counts []TagCount, q string, limit int, substrings bool) []TagCount {
+	q = strings.ToLower(q)
+	var prefix, contains []TagCount
+	for _, c := range counts {
+		name := strings.ToLower(c.Name)
+		switch {
+		case strings.HasPrefix(name, q):
+			prefix = append(prefix, c)
+		case substrings && strings.Contains(name, q):
+			contains = append(contains, c)
+		}
+	}
+	matches := append(prefix, contains...)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// tagCounts aggregates the tags on published posts for ListTags. The
+// caller must hold the lock.
+func (s *MemoryStore) tagCounts() []TagCount {
+	return s.nameCounts(func(post *model.Post) []string { return post.Tags })
+}
+
+// categoryCounts aggregates the categories of published posts the same way
+// tagCounts aggregates tags. Uncategorized posts are not counted. The caller
+// must hold the lock.
+func (s *MemoryStore) categoryCounts() []TagCount {
+	return s.nameCounts(func(post *model.Post) []string {
+		if category := strings.TrimSpace(post.Category); category != "" {
+			return []string{category}
+		}
+		return nil
+	})
+}
+
+// nameCounts counts the published posts carrying each name returned by
+// names, grouping names case-insensitively under their most common casing,
+// ordered by descending count. The caller must hold the lock.
+func (s *MemoryStore) nameCounts(names func(*model.Post) []string) []TagCount {
+	// Count by lowercased name, and by original casing within each.
+	totals := make(map[string]int)
+	casings := make(map[string]map[string]int)
+	for _, post := range s.posts {
+		if post.DeletedAt != nil || post.Status != model.StatusPublished {
+			continue
+		}
+		postNames := names(post)
+		seen := make(map[string]bool, len(postNames))
+		for _, name := range postNames {
+			key := strings.ToLower(name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			totals[key]++
+			if casings[key] == nil {
+				casings[key] = make(map[string]int)
+			}
+			casings[key][name]++
+		}
+	}
+
+	counts := make([]TagCount, 0, len(totals))
+	for key, total := range totals {
+		var label string
+		for casing, n := range casings[key] {
+			if best := casings[key][label]; label == "" || n > best || n == best && casing < label {
+				label = casing
+			}
+		}
+		counts = append(counts, TagCount{Name: label, Count: total})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return strings.ToLower(counts[i].Name) < strings.ToLower(counts[j].Name)
+	})
+	return counts
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecentPosts returns up to n published posts ordered by CreatedAt, newest
+// first. Posts created at the same instant are ordered by descending ID.
+func (s *MemoryStore) RecentPosts(ctx context.Context, n int) ([]*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := make([]*model.Post, 0, len(s.posts))
+	for _, post := range s.posts {
+		if post.DeletedAt == nil && post.Status == model.StatusPublished {
+			posts = append(posts, post)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].CreatedAt.Equal(posts[j].CreatedAt) {
+			return posts[i].CreatedAt.After(posts[j].CreatedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+
+	if len(posts) > n {
+		posts = posts[:n]
+	}
+	return posts, nil
+}
+
+// OnThisDay returns the published posts created on date's month and day in
+// earlier years, newest first.
+func (s *MemoryStore) OnThisDay(ctx context.Context, date time.Time) ([]*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := make([]*model.Post, 0)
+	for _, post := range s.posts {
+		if post.DeletedAt != nil || post.Status != model.StatusPublished {
+			continue
+		}
+		created := post.CreatedAt.In(date.Location())
+		if created.Year() < date.Year() && created.Month() == date.Month() && created.Day() == date.Day() {
+			posts = append(posts, post)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].CreatedAt.Equal(posts[j].CreatedAt) {
+			return posts[i].CreatedAt.After(posts[j].CreatedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+	return posts, nil
+}
+
+// ReviewQueue returns the published posts criteria flags, with their
+// reasons, ordered by how many reasons apply and then by ID.
+func (s *MemoryStore) ReviewQueue(ctx context.Context, criteria ReviewCriteria) ([]ReviewItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]ReviewItem, 0)
+	for _, post := range s.posts {
+		if post.DeletedAt != nil || post.Status != model.StatusPublished {
+			continue
+		}
+		if reasons := criteria.reasons(post); len(reasons) > 0 {
+			items = append(items, ReviewItem{Post: post, Reasons: reasons})
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if len(items[i].Reasons) != len(items[j].Reasons) {
+			return len(items[i].Reasons) > len(items[j].Reasons)
+		}
+		return items[i].Post.ID < items[j].Post.ID
+	})
+	return items, nil
+}
+
+// Neighbors returns the published posts adjacent to a post by CreatedAt,
+// ordering posts created at the same time by ID.
+func (s *MemoryStore) Neighbors(ctx context.Context, id int64) (*model.Post, *model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ref, ok := s.livePost(id)
+	if !ok {
+		return nil, nil, errPostNotFound(id)
+	}
+	before := func(a, b *model.Post) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	}
+
+	var prev, next *model.Post
+	for _, post := range s.posts {
+		if post.ID == id || post.DeletedAt != nil || post.Status != model.StatusPublished {
+			continue
+		}
+		if before(post, ref) {
+			if prev == nil || before(prev, post) {
+				prev = post
+			}
+		} else if next == nil || before(post, next) {
+			next = post
+		}
+	}
+	return prev, next, nil
+}
+
+// MostCommented returns copies of up to n published posts ranked by their
+// number of approved comments.
+func (s *MemoryStore) MostCommented(ctx context.Context, n int) ([]*model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[int64]int)
+	for _, comment := range s.comments {
+		if comment.Status == model.CommentApproved && comment.DeletedAt == nil {
+			counts[comment.PostID]++
+		}
+	}
+
+	posts := make([]*model.Post, 0, len(counts))
+	for id, count := range counts {
+		post, ok := s.livePost(id)
+		if !ok || post.Status != model.StatusPublished {
+			continue
+		}
+		ranked := *post
+		ranked.CommentCount = count
+		posts = append(posts, &ranked)
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].CommentCount != posts[j].CommentCount {
+			return posts[i].CommentCount > posts[j].CommentCount
+		}
+		if !posts[i].CreatedAt.Equal(posts[j].CreatedAt) {
+			return posts[i].CreatedAt.After(posts[j].CreatedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+
+	if len(posts) > n {
+		posts = posts[:n]
+	}
+	return posts, nil
+}
+
+// AddComment adds a comment to an existing post. Under
+// WithCommentDedupWindow, a repeat of a recent comment returns that comment.
+func (s *MemoryStore) AddComment(ctx context.Context, postID int64, comment *model.Comment) (*model.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return nil, errPostNotFound(postID)
+	}
+	if comment.ParentID != nil {
+		parent, ok := s.comments[*comment.ParentID]
+		if !ok || parent.PostID != postID || parent.DeletedAt != nil {
+			return nil, ErrInvalidParent
+		}
+	}
+	now := s.now().UTC()
+	key := commentKey{postID: postID, author: comment.Author, content: comment.Content}
+	if s.dedup != nil {
+		if id, ok := s.dedup.lookup(key, now); ok {
+			if existing, ok := s.comments[id]; ok && existing.DeletedAt == nil {
+				return existing, nil
+			}
+		}
+	}
+	if s.maxComments > 0 && s.commentCount(postID) >= s.maxComments {
+		return nil, ErrTooManyComments
+	}
+
+	comment.ID = s.nextCommentID
+	comment.PostID = postID
+	comment.Status = model.CommentPending
+	comment.CreatedAt = now
+	comment.DeletedAt = nil
+	s.comments[comment.ID] = comment
+	s.nextCommentID++
+	if s.dedup != nil {
+		s.dedup.record(key, comment.ID, now)
+	}
+
+	return comment, nil
+}
+
+// BulkAddComments adds comments to a post under a single lock. Comments with
+// an invalid parent or past the comment limit are rejected with the reason;
+// the others are created with their ID in the result. A comment without a
+// status is pending, and one without a creation time gets the current time.
+func (s *MemoryStore) BulkAddComments(ctx context.Context, postID int64, comments []*model.Comment) ([]BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return nil, errPostNotFound(postID)
+	}
+
+	now := s.now().UTC()
+	count := s.commentCount(postID)
+	results := make([]BulkResult, 0, len(comments))
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			parent, ok := s.comments[*comment.ParentID]
+			if !ok || parent.PostID != postID || parent.DeletedAt != nil {
+				results = append(results, BulkResult{Result: BulkRejected, Reason: ErrInvalidParent.Error()})
+				continue
+			}
+		}
+		if s.maxComments > 0 && count >= s.maxComments {
+			results = append(results, BulkResult{Result: BulkRejected, Reason: ErrTooManyComments.Error()})
+			continue
+		}
+
+		comment.ID = s.nextCommentID
+		comment.PostID = postID
+		if comment.Status == "" {
+			comment.Status = model.CommentPending
+		}
+		if comment.CreatedAt.IsZero() {
+			comment.CreatedAt = now
+		} else {
+			comment.CreatedAt = comment.CreatedAt.UTC()
+		}
+		comment.DeletedAt = nil
+		s.comments[comment.ID] = comment
+		s.nextCommentID++
+		count++
+		results = append(results, BulkResult{ID: comment.ID, Result: BulkCreated})
+	}
+	return results, nil
+}
+
+// commentCount counts a post's comments in any status, leaving out deleted
+// ones. The caller must hold s.mu.
+func (s *MemoryStore) commentCount(postID int64) int {
+	n := 0
+	for _, comment := range s.comments {
+		if comment.PostID == postID && comment.DeletedAt == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// GetComments returns a post's comments matching the filter ordered by
+// CreatedAt, along with the total number of matches before pagination.
+// Deleted comments are listed as tombstones only while a matching comment
+// replies to them.
+func (s *MemoryStore) GetComments(ctx context.Context, postID int64, filter CommentFilter) ([]*model.Comment, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return nil, 0, errPostNotFound(postID)
+	}
+
+	comments := make([]*model.Comment, 0)
+	for _, comment := range s.comments {
+		if comment.PostID == postID && (filter.Status == "" || comment.Status == filter.Status) {
+			comments = append(comments, comment)
+		}
+	}
+	comments = withTombstones(comments)
+	sort.Slice(comments, func(i, j int) bool {
+		a, b := comments[i], comments[j]
+		if filter.NewestFirst {
+			a, b = b, a
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	return filter.page(comments), len(comments), nil
+}
+
+// CommentsForPosts returns up to perPost approved comments for each listed
+// post in a single scan.
+func (s *MemoryStore) CommentsForPosts(ctx context.Context, postIDs []int64, perPost int) (map[int64][]*model.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[int64]bool, len(postIDs))
+	for _, id := range postIDs {
+		if _, ok := s.livePost(id); ok {
+			wanted[id] = true
+		}
+	}
+
+	byPost := make(map[int64][]*model.Comment)
+	for _, comment := range s.comments {
+		if wanted[comment.PostID] && comment.Status == model.CommentApproved {
+			byPost[comment.PostID] = append(byPost[comment.PostID], comment)
+		}
+	}
+	for id, comments := range byPost {
+		comments = withTombstones(comments)
+		byPost[id] = comments
+		sort.Slice(comments, func(i, j int) bool {
+			if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+				return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+			}
+			return comments[i].ID < comments[j].ID
+		})
+		if len(comments) > perPost {
+			byPost[id] = comments[:perPost]
+		}
+	}
+	return byPost, nil
+}
+
+// DeleteComment soft-deletes a comment by setting its DeletedAt. It is
+// hidden from every other method except as a tombstone in listings.
+func (s *MemoryStore) DeleteComment(ctx context.Context, postID, commentID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, err := s.findComment(postID, commentID)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	comment.DeletedAt = &now
+	return nil
+}
+
+// RestoreComment clears a deleted comment's DeletedAt.
+func (s *MemoryStore) RestoreComment(ctx context.Context, postID, commentID int64) (*model.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return nil, errPostNotFound(postID)
+	}
+	comment, ok := s.comments[commentID]
+	if !ok || comment.PostID != postID {
+		return nil, fmt.Errorf("comment with id %d on post %d: %w", commentID, postID, ErrCommentNotFound)
+	}
+	if comment.DeletedAt == nil {
+		return nil, ErrCommentNotDeleted
+	}
+
+	comment.DeletedAt = nil
+	return comment, nil
+}
+
+// withTombstones removes the deleted comments from comments, except those
+// that a comment left in it replies to, directly or through other deleted
+// comments. Those are replaced by tombstone copies without their author and
+// content, so replies keep their place in the thread.
+func withTombstones(comments []*model.Comment) []*model.Comment {
+	byID := make(map[int64]*model.Comment, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+	}
+	replied := make(map[int64]bool)
+	for _, comment := range comments {
+		if comment.DeletedAt != nil {
+			continue
+		}
+		for id := comment.ParentID; id != nil; {
+			parent, ok := byID[*id]
+			if !ok || parent.DeletedAt == nil || replied[parent.ID] {
+				break
+			}
+			replied[parent.ID] = true
+			id = parent.ParentID
+		}
+	}
+
+	kept := comments[:0]
+	for _, comment := range comments {
+		switch {
+		case comment.DeletedAt == nil:
+			kept = append(kept, comment)
+		case replied[comment.ID]:
+			tombstone := *comment
+			tombstone.Author, tombstone.Content = "", model.DeletedCommentContent
+			kept = append(kept, &tombstone)
+		}
+	}
+	return kept
+}
+
+// GetComment looks up a comment by its ID alone.
+func (s *MemoryStore) GetComment(ctx context.Context, commentID int64) (*model.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comment, ok := s.comments[commentID]
+	if ok && comment.DeletedAt == nil {
+		if _, ok := s.livePost(comment.PostID); ok {
+			return comment, nil
+		}
+	}
+	return nil, fmt.Errorf("comment with id %d: %w", commentID, ErrCommentNotFound)
+}
+
+// ApproveComment marks a comment approved so it is shown publicly.
+func (s *MemoryStore) ApproveComment(ctx context.Context, postID, commentID int64) (*model.Comment, error) {
+	return s.setCommentStatus(ctx, postID, commentID, model.CommentApproved)
+}
+
+// RejectComment marks a comment rejected, hiding it without deleting it.
+func (s *MemoryStore) RejectComment(ctx context.Context, postID, commentID int64) (*model.Comment, error) {
+	return s.setCommentStatus(ctx, postID, commentID, model.CommentRejected)
+}
+
+func (s *MemoryStore) setCommentStatus(ctx context.Context, postID, commentID int64, status string) (*model.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, err := s.findComment(postID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	comment.Status = status
+	return comment, nil
+}
+
+// findComment returns the comment with the given ID on the given post. The
+// caller must hold the lock.
+func (s *MemoryStore) findComment(postID, commentID int64) (*model.Comment, error) {
+	if _, ok := s.livePost(postID); !ok {
+		return nil, errPostNotFound(postID)
+	}
+	comment, ok := s.comments[commentID]
+	if !ok || comment.PostID != postID || comment.DeletedAt != nil {
+		return nil, fmt.Errorf("comment with id %d on post %d: %w", commentID, postID, ErrCommentNotFound)
+	}
+	return comment, nil
+}
+
+// GetRevisions returns a post's revisions, oldest first.
+func (s *MemoryStore) GetRevisions(ctx context.Context, postID int64) ([]*model.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return nil, errPostNotFound(postID)
+	}
+	return append([]*model.Revision(nil), s.revisions[postID]...), nil
+}
+
+// GetRevision returns a single revision of a post.
+func (s *MemoryStore) GetRevision(ctx context.Context, postID int64, number int) (*model.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return nil, errPostNotFound(postID)
+	}
+	revisions := s.revisions[postID]
+	if number < 1 || number > len(revisions) {
+		return nil, fmt.Errorf("revision %d of post %d: %w", number, postID, ErrRevisionNotFound)
+	}
+	return revisions[number-1], nil
+}
+
+// errPostNotFound returns an error wrapping ErrPostNotFound for the given ID.
+func errPostNotFound(id int64) error {
+	return fmt.Errorf("post with id %d %w", id, ErrPostNotFound)
+}
@@ -0,0 +1,490 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestMemoryStoreMaxTags(t *testing.T) {
+	store := NewMemoryStore(WithMaxTags(2))
+
+	id, err := store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content", Tags: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("CreatePost() at the limit error = %v", err)
+	}
+
+	tooMany := []string{"a", "b", "c"}
+
+	if _, err := store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content", Tags: tooMany}); !errors.Is(err, ErrTooManyTags) {
+		t.Errorf("CreatePost() error = %v, want %v", err, ErrTooManyTags)
+	}
+	if _, err := store.UpdatePost(context.Background(), id, &model.Post{Title: "Title", Content: "Content", Tags: tooMany}); !errors.Is(err, ErrTooManyTags) {
+		t.Errorf("UpdatePost() error = %v, want %v", err, ErrTooManyTags)
+	}
+	if _, _, err := store.UpsertPost(context.Background(), 10, &model.Post{Title: "Title", Content: "Content", Tags: tooMany}); !errors.Is(err, ErrTooManyTags) {
+		t.Errorf("UpsertPost() error = %v, want %v", err, ErrTooManyTags)
+	}
+
+	if count, _ := store.CountPosts(context.Background()); count != 1 {
+		t.Errorf("store holds %d posts, want 1", count)
+	}
+}
+
+func TestMemoryStoreUnlimitedTags(t *testing.T) {
+	store := NewMemoryStore()
+
+	tags := make([]string, 100)
+	if _, err := store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content", Tags: tags}); err != nil {
+		t.Errorf("CreatePost() error = %v, want nil", err)
+	}
+}
+
+func TestMemoryStoreGetAllPostsFilter(t *testing.T) {
+	store := NewMemoryStore()
+
+	seed := []*model.Post{
+		{Title: "Go generics", Content: "Content", Author: "jane", Category: "Technology", Tags: []string{"Go"}},
+		{Title: "Go channels", Content: "Content", Author: "bob", Category: "technology", Tags: []string{"go", "concurrency"}},
+		{Title: "Go hiking", Content: "Content", Author: "jane", Category: "Travel", Tags: []string{"go"}},
+		{Title: "Rust traits", Content: "Content", Author: "jane", Category: "Technology", Tags: []string{"rust"}},
+		{Title: "Go draft", Content: "Content", Author: "jane", Category: "Technology", Tags: []string{"go"}, Status: model.StatusDraft},
+	}
+	for _, post := range seed {
+		store.CreatePost(context.Background(), post)
+	}
+
+	filter := PostFilter{
+		Term:     "go",
+		Authors:  []string{"JANE", "bob"},
+		Category: "TECHNOLOGY",
+		Tag:      "GO",
+		Sort:     SortOldest,
+		Limit:    1,
+		Offset:   1,
+	}
+	posts, total, err := store.GetAllPosts(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("GetAllPosts() error = %v", err)
+	}
+	if total != 2 {
+		t.Errorf("GetAllPosts() total = %d, want 2", total)
+	}
+	if len(posts) != 1 || posts[0].Title != "Go channels" {
+		t.Errorf("GetAllPosts() = %v, want only %q", posts, "Go channels")
+	}
+
+	t.Run("status", func(t *testing.T) {
+		posts, _, _ := store.GetAllPosts(context.Background(), PostFilter{Status: model.StatusDraft})
+		if len(posts) != 1 || posts[0].Title != "Go draft" {
+			t.Errorf("GetAllPosts() = %v, want only the draft", posts)
+		}
+	})
+}
+
+func TestMemoryStoreStalePosts(t *testing.T) {
+	var clock time.Time
+	store := NewMemoryStore(WithClock(func() time.Time { return clock }))
+	ctx := context.Background()
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	seed := []struct {
+		title   string
+		status  string
+		updated time.Time
+	}{
+		{"Recent", model.StatusPublished, cutoff.AddDate(0, 1, 0)},
+		{"Stale", model.StatusPublished, cutoff.AddDate(0, -1, 0)},
+		{"Stalest", model.StatusPublished, cutoff.AddDate(-1, 0, 0)},
+		{"Stale draft", model.StatusDraft, cutoff.AddDate(-1, 0, 0)},
+	}
+	for _, p := range seed {
+		clock = p.updated
+		store.CreatePost(ctx, &model.Post{Title: p.title, Content: "Content", Status: p.status})
+	}
+
+	posts, total, err := store.GetAllPosts(ctx, PostFilter{UpdatedBefore: cutoff})
+	if err != nil {
+		t.Fatalf("GetAllPosts() error = %v", err)
+	}
+	if total != 2 || len(posts) != 2 || posts[0].Title != "Stalest" || posts[1].Title != "Stale" {
+		t.Errorf("GetAllPosts() = %v, want the stale published posts, oldest first", posts)
+	}
+}
+
+func TestMemoryStoreIDRange(t *testing.T) {
+	// The range is returned in ID order even when the store defaults to
+	// newest first.
+	store := NewMemoryStore(WithDefaultSort(SortNewest))
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		store.CreatePost(ctx, &model.Post{Title: fmt.Sprintf("Post %d", i+1), Content: "Content"})
+	}
+	store.DeletePost(ctx, 5)
+
+	posts, total, err := store.GetAllPosts(ctx, PostFilter{IDFrom: 4, IDTo: 7})
+	if err != nil {
+		t.Fatalf("GetAllPosts() error = %v", err)
+	}
+	var ids []int64
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	if want := []int64{4, 6, 7}; total != len(want) || !reflect.DeepEqual(ids, want) {
+		t.Errorf("GetAllPosts() IDs = %v (total %d), want %v", ids, total, want)
+	}
+
+	if posts, _, _ := store.GetAllPosts(ctx, PostFilter{IDFrom: 9}); len(posts) != 2 {
+		t.Errorf("GetAllPosts() with open upper bound returned %d posts, want 2", len(posts))
+	}
+}
+
+func TestMemoryStoreHomeSort(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := base
+	store := NewMemoryStore(WithClock(func() time.Time { return clock }))
+	for i, post := range []model.Post{
+		{Title: "Old featured", Featured: true},
+		{Title: "Old"},
+		{Title: "Pinned second"},
+		{Title: "New featured", Featured: true},
+		{Title: "Pinned first", Featured: true},
+		{Title: "New"},
+		{Title: "Featured draft", Featured: true, Status: model.StatusDraft},
+	} {
+		post := post
+		post.Content = "Content"
+		clock = base.Add(time.Duration(i) * time.Hour)
+		store.CreatePost(ctx, &post)
+	}
+	store.PinPost(ctx, 3, 1)
+	store.PinPost(ctx, 5, 1)
+
+	posts, total, err := store.GetAllPosts(ctx, PostFilter{Sort: SortHome})
+	if err != nil {
+		t.Fatalf("GetAllPosts() error = %v", err)
+	}
+	var titles []string
+	for _, post := range posts {
+		titles = append(titles, post.Title)
+	}
+	want := []string{"Pinned first", "Pinned second", "New featured", "Old featured", "New", "Old"}
+	if total != len(want) || !reflect.DeepEqual(titles, want) {
+		t.Errorf("GetAllPosts() = %v (total %d), want %v", titles, total, want)
+	}
+
+	if posts, _, _ := store.GetAllPosts(ctx, PostFilter{Sort: SortHome, Status: model.StatusDraft}); len(posts) != 0 {
+		t.Errorf("GetAllPosts() listed %d drafts in the home order, want none", len(posts))
+	}
+}
+
+func TestMemoryStoreRecomputeDerived(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	content := strings.Repeat("word ", 450)
+	staleID, _ := store.CreatePost(ctx, &model.Post{Title: "Old Post", Content: content})
+	store.CreatePost(ctx, &model.Post{Title: "Current", Content: "Already derived."})
+
+	// Simulate a post stored before the derived fields existed.
+	stale := store.posts[staleID]
+	delete(store.slugs, stale.Slug)
+	stale.Slug, stale.WordCount, stale.ReadingTime = "", 0, 0
+	staleUpdated, currentUpdated := stale.UpdatedAt, store.posts[staleID+1].UpdatedAt
+
+	updated, err := store.RecomputeDerived(ctx)
+	if err != nil {
+		t.Fatalf("RecomputeDerived() error = %v", err)
+	}
+	if updated != 1 {
+		t.Errorf("RecomputeDerived() = %d, want 1", updated)
+	}
+	if stale.Slug != "old-post" || stale.WordCount != 450 || stale.ReadingTime != 3 {
+		t.Errorf("RecomputeDerived() left slug %q, %d words, %d minutes; want %q, 450, 3",
+			stale.Slug, stale.WordCount, stale.ReadingTime, "old-post")
+	}
+	if !stale.UpdatedAt.After(staleUpdated) {
+		t.Errorf("RecomputeDerived() left UpdatedAt %v, want it bumped", stale.UpdatedAt)
+	}
+	if got := store.posts[staleID+1].UpdatedAt; !got.Equal(currentUpdated) {
+		t.Errorf("RecomputeDerived() changed UpdatedAt of an unchanged post to %v, want %v", got, currentUpdated)
+	}
+	if id, _ := store.SlugExists(ctx, "old-post"); id != staleID {
+		t.Errorf("SlugExists() = %d, want %d", id, staleID)
+	}
+
+	if updated, _ := store.RecomputeDerived(ctx); updated != 0 {
+		t.Errorf("second RecomputeDerived() = %d, want 0", updated)
+	}
+}
+
+func TestMemoryStoreCancelledContext(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 1000; i++ {
+		store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if posts, _, err := store.GetAllPosts(ctx, PostFilter{}); !errors.Is(err, context.Canceled) || posts != nil {
+		t.Errorf("GetAllPosts() = %d posts, %v, want no posts and %v", len(posts), err, context.Canceled)
+	}
+	if _, err := store.GetPost(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetPost() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("CreatePost() error = %v, want %v", err, context.Canceled)
+	}
+	if count, _ := store.CountPosts(context.Background()); count != 1000 {
+		t.Errorf("CountPosts() = %d after cancelled create, want %d", count, 1000)
+	}
+}
+
+func TestMemoryStoreDefaultOrder(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 20; i++ {
+		store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+	}
+
+	for run := 0; run < 5; run++ {
+		posts, _, _ := store.GetAllPosts(context.Background(), PostFilter{})
+		for i, post := range posts {
+			if post.ID != int64(i+1) {
+				t.Fatalf("run %d: GetAllPosts()[%d].ID = %d, want %d", run, i, post.ID, i+1)
+			}
+		}
+	}
+}
+
+func TestMemoryStoreSearchOrder(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(WithSearchIndex())
+	for i := 0; i < 30; i++ {
+		post := &model.Post{Title: "Notes", Content: "About go"}
+		switch i % 3 {
+		case 1:
+			post.Title = "Go tips"
+		case 2:
+			post.Category = "go"
+		}
+		store.CreatePost(ctx, post)
+	}
+
+	page := func() []int64 {
+		var ids []int64
+		for offset := 0; ; offset += 7 {
+			posts, _, _ := store.GetAllPosts(ctx, PostFilter{Term: "go", Limit: 7, Offset: offset})
+			if len(posts) == 0 {
+				return ids
+			}
+			for _, post := range posts {
+				ids = append(ids, post.ID)
+			}
+		}
+	}
+
+	first := page()
+	if len(first) != 30 {
+		t.Fatalf("paged through %d posts, want %d", len(first), 30)
+	}
+	// Title matches lead, then category matches, then content only, each
+	// by ascending ID.
+	if first[0] != 2 || first[9] != 29 || first[10] != 3 || first[20] != 1 {
+		t.Errorf("search order = %v, want title, then category, then content matches", first)
+	}
+	for run := 0; run < 5; run++ {
+		if again := page(); !reflect.DeepEqual(again, first) {
+			t.Fatalf("run %d: search order = %v, want %v", run, again, first)
+		}
+	}
+}
+
+func TestMemoryStoreComments(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	first, _ := store.CreatePost(ctx, &model.Post{Title: "First", Content: "Content"})
+	second, _ := store.CreatePost(ctx, &model.Post{Title: "Second", Content: "Content"})
+
+	comment, err := store.AddComment(ctx, first, &model.Comment{Content: "Hello"})
+	if err != nil {
+		t.Fatalf("AddComment() error = %v", err)
+	}
+	if err := store.DeleteComment(ctx, second, comment.ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("DeleteComment() on another post error = %v, want %v", err, ErrCommentNotFound)
+	}
+
+	store.DeletePost(ctx, first)
+	if _, _, err := store.GetComments(ctx, first, CommentFilter{}); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("GetComments() after DeletePost error = %v, want %v", err, ErrPostNotFound)
+	}
+	store.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	if len(store.comments) != 0 {
+		t.Errorf("PurgeDeleted() left %d comments behind", len(store.comments))
+	}
+}
+
+func TestMemoryStorePublishDue(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	schedule := func(at time.Time) int64 {
+		id, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content", Status: model.StatusScheduled, PublishAt: &at})
+		return id
+	}
+	pastID := schedule(past)
+	presentID := schedule(now)
+	futureID := schedule(future)
+	draftID, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content", Status: model.StatusDraft, PublishAt: &past})
+
+	ids, err := store.PublishDue(ctx, now)
+	if err != nil {
+		t.Fatalf("PublishDue() error = %v", err)
+	}
+	if len(ids) != 2 || ids[0] != pastID || ids[1] != presentID {
+		t.Errorf("PublishDue() = %v, want [%d %d]", ids, pastID, presentID)
+	}
+
+	if post, _ := store.GetPost(ctx, pastID); post.Status != model.StatusPublished || !post.PublishedAt.Equal(past) {
+		t.Errorf("past post = %s published at %v, want published at %v", post.Status, post.PublishedAt, past)
+	}
+	if post, _ := store.GetPost(ctx, futureID); post.Status != model.StatusScheduled {
+		t.Errorf("future post status = %q, want %q", post.Status, model.StatusScheduled)
+	}
+	if post, _ := store.GetPost(ctx, draftID); post.Status != model.StatusDraft {
+		t.Errorf("draft status = %q, want %q", post.Status, model.StatusDraft)
+	}
+
+	if ids, _ := store.PublishDue(ctx, now); len(ids) != 0 {
+		t.Errorf("second PublishDue() = %v, want none", ids)
+	}
+}
+
+func TestMemoryStoreSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Hello", Content: "Content"})
+
+	if err := store.DeletePost(ctx, id); err != nil {
+		t.Fatalf("DeletePost() error = %v", err)
+	}
+	if _, err := store.GetPost(ctx, id); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("GetPost() error = %v, want ErrPostNotFound", err)
+	}
+	if posts, total, _ := store.GetAllPosts(ctx, PostFilter{}); len(posts) != 0 || total != 0 {
+		t.Errorf("GetAllPosts() = %d posts (total %d), want none", len(posts), total)
+	}
+	if n, _ := store.CountPosts(ctx); n != 0 {
+		t.Errorf("CountPosts() = %d, want 0", n)
+	}
+	if err := store.DeletePost(ctx, id); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("second DeletePost() error = %v, want ErrPostNotFound", err)
+	}
+
+	// The slug is freed for new posts.
+	if owner, _ := store.SlugExists(ctx, "hello"); owner != 0 {
+		t.Errorf("SlugExists() = %d, want 0", owner)
+	}
+}
+
+func TestMemoryStorePurgeDeleted(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	clock := now
+	store := NewMemoryStore(WithClock(func() time.Time { return clock }))
+
+	trash := func(deletedAt time.Time) int64 {
+		clock = deletedAt
+		id, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"})
+		store.AddComment(ctx, id, &model.Comment{Content: "Comment"})
+		store.DeletePost(ctx, id)
+		return id
+	}
+	oldID := trash(now.Add(-40 * 24 * time.Hour))
+	recentID := trash(now.Add(-time.Hour))
+	clock = now
+	liveID, _ := store.CreatePost(ctx, &model.Post{Title: "Live", Content: "Content"})
+
+	purged, err := store.PurgeDeleted(ctx, now.Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeDeleted() error = %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("PurgeDeleted() = %d, want 1", purged)
+	}
+	if _, ok := store.posts[oldID]; ok {
+		t.Error("old deleted post was not purged")
+	}
+	if _, ok := store.posts[recentID]; !ok {
+		t.Error("recently deleted post was purged")
+	}
+	if _, err := store.GetPost(ctx, liveID); err != nil {
+		t.Errorf("GetPost(live) error = %v", err)
+	}
+	for _, comment := range store.comments {
+		if comment.PostID == oldID {
+			t.Error("purged post's comments were kept")
+		}
+	}
+}
+
+func TestMemoryStorePins(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	a, _ := store.CreatePost(ctx, &model.Post{Title: "A", Content: "Content"})
+	b, _ := store.CreatePost(ctx, &model.Post{Title: "B", Content: "Content"})
+	c, _ := store.CreatePost(ctx, &model.Post{Title: "C", Content: "Content"})
+
+	store.PinPost(ctx, a, 1)
+	store.PinPost(ctx, b, 2)
+	store.PinPost(ctx, c, 3)
+
+	// Moving C to the front shifts the others down.
+	store.PinPost(ctx, c, 1)
+	order := func(id int64) int {
+		post, _ := store.GetPost(ctx, id)
+		if post.PinOrder == nil {
+			return 0
+		}
+		return *post.PinOrder
+	}
+	if got := []int{order(c), order(a), order(b)}; got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("pin orders after move = %v, want [1 2 3]", got)
+	}
+
+	// Deleting a pinned post closes the gap.
+	store.DeletePost(ctx, a)
+	if got := []int{order(c), order(b)}; got[0] != 1 || got[1] != 2 {
+		t.Errorf("pin orders after delete = %v, want [1 2]", got)
+	}
+}
+
+func TestMemoryStoreRepairNextID(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	store.CreatePost(ctx, &model.Post{Title: "First", Content: "Content"})
+	// A post restored behind the store's back leaves nextID stale.
+	store.posts[100] = &model.Post{ID: 100, Title: "Restored", Content: "Content", Status: model.StatusPublished}
+
+	previous, next, err := store.RepairNextID(ctx)
+	if err != nil || previous != 2 || next != 101 {
+		t.Fatalf("RepairNextID() = %d, %d, %v; want 2, 101", previous, next, err)
+	}
+	id, err := store.CreatePost(ctx, &model.Post{Title: "Next", Content: "Content"})
+	if err != nil || id != 101 {
+		t.Errorf("CreatePost() after repair = %d, %v; want 101", id, err)
+	}
+
+	if previous, next, _ := store.RepairNextID(ctx); previous != 102 || next != 102 {
+		t.Errorf("RepairNextID() on a consistent store = %d, %d; want 102, 102", previous, next)
+	}
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Option configures a MemoryStore.
+type Option func(*MemoryStore)
+
+// IDGenerator returns the ID for a newly created post. It is called with the
+// store's write lock held, so it must not call back into the store.
+type IDGenerator func() int64
+
+// WithMaxTags limits how many tags a post may have. Zero means unlimited.
+func WithMaxTags(n int) Option {
+	return func(s *MemoryStore) {
+		s.maxTags = n
+	}
+}
+
+// WithMaxTitleLength limits a post's title to n characters, so imports and
+// bulk writes cannot store what the API would reject. Longer titles fail
+// with ErrTitleTooLong. Zero means unlimited.
+func WithMaxTitleLength(n int) Option {
+	return func(s *MemoryStore) {
+		s.maxTitleLength = n
+	}
+}
+
+// WithMaxContentLength limits a post's content to n characters. Longer
+// content fails with ErrContentTooLong. Zero means unlimited.
+func WithMaxContentLength(n int) Option {
+	return func(s *MemoryStore) {
+		s.maxContentLength = n
+	}
+}
+
+// WithMinContentLength requires a post's content to be at least n
+// characters, since very short posts are usually accidents. Shorter content
+// fails with ErrContentTooShort, though updates that keep a post's content
+// as it is are allowed. Zero disables it.
+func WithMinContentLength(n int) Option {
+	return func(s *MemoryStore) {
+		s.minContentLength = n
+	}
+}
+
+// WithMaxPosts caps how many posts the store holds, counting soft-deleted
+// posts until they are purged. Creates beyond it fail with ErrStoreFull.
+// Zero means unlimited.
+func WithMaxPosts(n int) Option {
+	return func(s *MemoryStore) {
+		s.maxPosts = n
+	}
+}
+
+// WithMaxPostsPerAuthor caps how many posts, drafts included, one author
+// may have outside the trash. Creates beyond it fail with
+// ErrAuthorLimitReached. Zero means unlimited.
+func WithMaxPostsPerAuthor(n int) Option {
+	return func(s *MemoryStore) {
+		s.maxPerAuthor = n
+	}
+}
+
+// WithMaxCommentsPerPost caps how many comments a post may have, in any
+// status. Adding more fails with ErrTooManyComments. Zero means unlimited.
+func WithMaxCommentsPerPost(n int) Option {
+	return func(s *MemoryStore) {
+		s.maxComments = n
+	}
+}
+
+// WithCommentDedupWindow makes AddComment return the existing comment
+// instead of adding another when the same author posts the same content on
+// the same post within window of it, which catches double-submitted forms.
+// Zero disables it.
+func WithCommentDedupWindow(window time.Duration) Option {
+	return func(s *MemoryStore) {
+		if window > 0 {
+			s.dedup = newCommentDedup(window)
+		} else {
+			s.dedup = nil
+		}
+	}
+}
+
+// WithMaxResults caps how many posts one GetAllPosts call returns, whatever
+// limit the filter asks for, including none. It is a safety net below the
+// API's own page size, so clamped calls are logged as warnings. Zero means
+// unlimited.
+func WithMaxResults(n int) Option {
+	return func(s *MemoryStore) {
+		s.maxResults = n
+	}
+}
+
+// WithLogger sets the logger for the store's warnings. Without it they go
+// to slog.Default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *MemoryStore) {
+		s.logger = logger
+	}
+}
+
+// WithMinUpdateInterval makes UpdatePost and UpsertPost fail with an
+// UpdateThrottledError when a post was last updated through them less than
+// interval ago, so an auto-saving editor cannot flood its revisions. Zero
+// disables it.
+func WithMinUpdateInterval(interval time.Duration) Option {
+	return func(s *MemoryStore) {
+		s.minUpdateInterval = interval
+	}
+}
+
+// WithIDGenerator replaces the default sequential IDs with IDs from gen.
+// CreatePost fails if gen returns a non-positive ID or one already in use.
+func WithIDGenerator(gen IDGenerator) Option {
+	return func(s *MemoryStore) {
+		s.idGen = gen
+	}
+}
+
+// WithClock sets the function the store reads the current time from when it
+// stamps posts and comments. Without it the store uses time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *MemoryStore) {
+		s.now = now
+	}
+}
+
+// WithDefaultSort sets the order GetAllPosts uses when the filter names none,
+// as one of the Sort constants. Without it posts are ordered by ascending ID.
+func WithDefaultSort(order string) Option {
+	return func(s *MemoryStore) {
+		s.defaultSort = order
+	}
+}
+
+// WithSearchIndex maintains an inverted index of the words in each post's
+// title, content, and category, which GetAllPosts uses for terms made of
+// letters and digits instead of scanning every post. Other terms still scan.
+func WithSearchIndex() Option {
+	return func(s *MemoryStore) {
+		s.index = newSearchIndex()
+	}
+}
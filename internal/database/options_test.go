@@ -0,0 +1,328 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestNewMemoryStoreDefaults(t *testing.T) {
+	store := NewMemoryStore()
+
+	for want := int64(1); want <= 3; want++ {
+		id, err := store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+		if err != nil || id != want {
+			t.Errorf("CreatePost() = %v, %v, want %v, nil", id, err, want)
+		}
+	}
+}
+
+func TestWithIDGenerator(t *testing.T) {
+	next := int64(100)
+	store := NewMemoryStore(WithIDGenerator(func() int64 {
+		next += 10
+		return next
+	}))
+
+	for _, want := range []int64{110, 120} {
+		id, err := store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+		if err != nil || id != want {
+			t.Errorf("CreatePost() = %v, %v, want %v, nil", id, err, want)
+		}
+	}
+
+	t.Run("duplicate ID", func(t *testing.T) {
+		store := NewMemoryStore(WithIDGenerator(func() int64 { return 7 }))
+		store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+
+		if _, err := store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"}); err == nil {
+			t.Error("CreatePost() error = nil, want collision error")
+		}
+	})
+}
+
+func TestOptionsCombine(t *testing.T) {
+	store := NewMemoryStore(WithMaxTags(1), WithIDGenerator(func() int64 { return 42 }))
+
+	if store.maxTags != 1 || store.idGen == nil {
+		t.Fatalf("options not applied: maxTags=%d idGen set=%v", store.maxTags, store.idGen != nil)
+	}
+	if id, _ := store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content", Tags: []string{"a"}}); id != 42 {
+		t.Errorf("CreatePost() id = %v, want %v", id, 42)
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(WithClock(func() time.Time { return clock }))
+
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"})
+	clock = clock.Add(time.Hour)
+	store.DeletePost(ctx, id)
+
+	post := store.posts[id]
+	if !post.CreatedAt.Equal(clock.Add(-time.Hour)) || post.DeletedAt == nil || !post.DeletedAt.Equal(clock) {
+		t.Errorf("post stamped CreatedAt %v, DeletedAt %v; want %v, %v", post.CreatedAt, post.DeletedAt, clock.Add(-time.Hour), clock)
+	}
+}
+
+func TestWithDefaultSort(t *testing.T) {
+	store := NewMemoryStore(WithDefaultSort(SortNewest))
+	for i := 0; i < 3; i++ {
+		store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+	}
+
+	posts, _, _ := store.GetAllPosts(context.Background(), PostFilter{})
+	if len(posts) != 3 {
+		t.Fatalf("GetAllPosts() returned %d posts, want %d", len(posts), 3)
+	}
+	if posts[0].ID != 3 {
+		t.Errorf("GetAllPosts() first ID = %v, want newest post %v", posts[0].ID, 3)
+	}
+
+	posts, _, _ = store.GetAllPosts(context.Background(), PostFilter{Sort: SortOldest})
+	if len(posts) == 0 {
+		t.Fatal("GetAllPosts(oldest) returned no posts")
+	}
+	if posts[0].ID != 1 {
+		t.Errorf("GetAllPosts(oldest) first ID = %v, want %v", posts[0].ID, 1)
+	}
+}
+
+func TestWithMaxPosts(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(WithMaxPosts(2))
+
+	for i := 0; i < 2; i++ {
+		if _, err := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"}); err != nil {
+			t.Fatalf("CreatePost() error = %v", err)
+		}
+	}
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"}); !errors.Is(err, ErrStoreFull) {
+		t.Errorf("CreatePost() error = %v, want %v", err, ErrStoreFull)
+	}
+	if _, _, err := store.UpsertPost(ctx, 10, &model.Post{Title: "Title", Content: "Content"}); !errors.Is(err, ErrStoreFull) {
+		t.Errorf("UpsertPost() error = %v, want %v", err, ErrStoreFull)
+	}
+	if _, _, err := store.UpsertPost(ctx, 1, &model.Post{Title: "Edited", Content: "Content"}); err != nil {
+		t.Errorf("UpsertPost() of an existing post error = %v, want nil", err)
+	}
+
+	// Purging frees capacity; soft-deleting alone does not.
+	store.DeletePost(ctx, 1)
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"}); !errors.Is(err, ErrStoreFull) {
+		t.Errorf("CreatePost() after delete error = %v, want %v", err, ErrStoreFull)
+	}
+	store.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"}); err != nil {
+		t.Errorf("CreatePost() after purge error = %v, want nil", err)
+	}
+}
+
+func TestWithCommentDedupWindow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(WithCommentDedupWindow(time.Minute))
+	postID, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"})
+
+	first, err := store.AddComment(ctx, postID, &model.Comment{Author: "ana", Content: "Nice"})
+	if err != nil {
+		t.Fatalf("AddComment() error = %v", err)
+	}
+	second, err := store.AddComment(ctx, postID, &model.Comment{Author: "ana", Content: "Nice"})
+	if err != nil || second.ID != first.ID {
+		t.Fatalf("AddComment() repeat = %v, %v, want comment %v", second, err, first.ID)
+	}
+	if n := store.commentCount(postID); n != 1 {
+		t.Errorf("stored comments = %d, want %d", n, 1)
+	}
+
+	// A different author or content is a new comment.
+	store.AddComment(ctx, postID, &model.Comment{Author: "bo", Content: "Nice"})
+	store.AddComment(ctx, postID, &model.Comment{Author: "ana", Content: "Nicer"})
+	if n := store.commentCount(postID); n != 3 {
+		t.Errorf("stored comments = %d, want %d", n, 3)
+	}
+
+	t.Run("after the window", func(t *testing.T) {
+		store := NewMemoryStore(WithCommentDedupWindow(time.Minute))
+		postID, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"})
+		store.AddComment(ctx, postID, &model.Comment{Author: "ana", Content: "Nice"})
+		store.dedup.expire(time.Now().Add(time.Minute))
+
+		store.AddComment(ctx, postID, &model.Comment{Author: "ana", Content: "Nice"})
+		if n := store.commentCount(postID); n != 2 {
+			t.Errorf("stored comments = %d, want %d", n, 2)
+		}
+	})
+
+	t.Run("deleted original", func(t *testing.T) {
+		store := NewMemoryStore(WithCommentDedupWindow(time.Minute))
+		postID, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"})
+		first, _ := store.AddComment(ctx, postID, &model.Comment{Author: "ana", Content: "Nice"})
+		store.DeleteComment(ctx, postID, first.ID)
+
+		second, _ := store.AddComment(ctx, postID, &model.Comment{Author: "ana", Content: "Nice"})
+		if second.ID == first.ID {
+			t.Errorf("AddComment() returned deleted comment %v", first.ID)
+		}
+	})
+}
+
+func TestWithMaxLengths(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(WithMaxTitleLength(5), WithMaxContentLength(10))
+
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "Too long", Content: "Content"}); !errors.Is(err, ErrTitleTooLong) {
+		t.Errorf("CreatePost() error = %v, want %v", err, ErrTitleTooLong)
+	}
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Far too much content"}); !errors.Is(err, ErrContentTooLong) {
+		t.Errorf("CreatePost() error = %v, want %v", err, ErrContentTooLong)
+	}
+	// Limits count characters, not bytes.
+	id, err := store.CreatePost(ctx, &model.Post{Title: "Café!", Content: "Ünïcödé ok"})
+	if err != nil {
+		t.Fatalf("CreatePost() error = %v, want nil", err)
+	}
+
+	if _, err := store.UpdatePost(ctx, id, &model.Post{Title: "Title", Content: "Far too much content"}); !errors.Is(err, ErrContentTooLong) {
+		t.Errorf("UpdatePost() error = %v, want %v", err, ErrContentTooLong)
+	}
+	if _, _, err := store.UpsertPost(ctx, 10, &model.Post{Title: "Too long", Content: "Content"}); !errors.Is(err, ErrTitleTooLong) {
+		t.Errorf("UpsertPost() error = %v, want %v", err, ErrTitleTooLong)
+	}
+	if post, _ := store.GetPost(ctx, id); post.Content != "Ünïcödé ok" {
+		t.Errorf("rejected update changed content to %q", post.Content)
+	}
+}
+
+func TestWithMinUpdateInterval(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(WithMinUpdateInterval(time.Minute))
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"})
+
+	if _, err := store.UpdatePost(ctx, id, &model.Post{Title: "Title", Content: "One"}); err != nil {
+		t.Fatalf("UpdatePost() error = %v", err)
+	}
+	_, err := store.UpdatePost(ctx, id, &model.Post{Title: "Title", Content: "Two"})
+	var throttled *UpdateThrottledError
+	if !errors.As(err, &throttled) || !errors.Is(err, ErrUpdateThrottled) {
+		t.Fatalf("UpdatePost() error = %v, want UpdateThrottledError", err)
+	}
+	if throttled.RetryAfter <= 0 || throttled.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %v, want within a minute", throttled.RetryAfter)
+	}
+
+	store.lastUpdated[id] = time.Now().Add(-time.Minute)
+	if _, err := store.UpdatePost(ctx, id, &model.Post{Title: "Title", Content: "Two"}); err != nil {
+		t.Errorf("UpdatePost() after the interval error = %v", err)
+	}
+}
+
+func TestWithMaxResults(t *testing.T) {
+	ctx := context.Background()
+	var logs bytes.Buffer
+	store := NewMemoryStore(WithMaxResults(3), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	for i := 0; i < 5; i++ {
+		store.CreatePost(ctx, &model.Post{Title: fmt.Sprintf("Post %d", i), Content: "Content"})
+	}
+
+	tests := []struct {
+		limit, offset int
+		want          int
+		logged        bool
+	}{
+		{1000000, 0, 3, true},
+		{0, 0, 3, true},
+		{2, 0, 2, false},
+		{0, 3, 2, false},
+	}
+	for _, tt := range tests {
+		logs.Reset()
+		posts, total, err := store.GetAllPosts(ctx, PostFilter{Limit: tt.limit, Offset: tt.offset})
+		if err != nil || len(posts) != tt.want || total != 5 {
+			t.Errorf("GetAllPosts(limit %d, offset %d) = %d posts, total %d, %v; want %d, 5", tt.limit, tt.offset, len(posts), total, err, tt.want)
+		}
+		if logged := strings.Contains(logs.String(), "post listing clamped"); logged != tt.logged {
+			t.Errorf("GetAllPosts(limit %d, offset %d) logged a clamp = %v, want %v: %s", tt.limit, tt.offset, logged, tt.logged, logs.String())
+		}
+	}
+}
+
+func TestWithMinContentLength(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(WithMinContentLength(10))
+
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Too short"}); !errors.Is(err, ErrContentTooShort) {
+		t.Errorf("CreatePost() error = %v, want %v", err, ErrContentTooShort)
+	}
+	// The minimum counts characters, not bytes.
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Ünïcödé"}); !errors.Is(err, ErrContentTooShort) {
+		t.Errorf("CreatePost() error = %v, want %v", err, ErrContentTooShort)
+	}
+	id, err := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Long enough"})
+	if err != nil {
+		t.Fatalf("CreatePost() error = %v, want nil", err)
+	}
+
+	if _, err := store.UpdatePost(ctx, id, &model.Post{Title: "Title", Content: "Short"}); !errors.Is(err, ErrContentTooShort) {
+		t.Errorf("UpdatePost() error = %v, want %v", err, ErrContentTooShort)
+	}
+	if post, _ := store.GetPost(ctx, id); post.Content != "Long enough" {
+		t.Errorf("UpdatePost() changed content to %q", post.Content)
+	}
+
+	// Raising the minimum leaves existing posts editable as long as their
+	// content stays as it is.
+	store.minContentLength = 20
+	if _, err := store.UpdatePost(ctx, id, &model.Post{Title: "Title", Content: "Long enough", Tags: []string{"go"}}); err != nil {
+		t.Errorf("UpdatePost() with unchanged content error = %v, want nil", err)
+	}
+	if _, err := store.UpdatePost(ctx, id, &model.Post{Title: "Title", Content: "Long enough!"}); !errors.Is(err, ErrContentTooShort) {
+		t.Errorf("UpdatePost() error = %v, want %v", err, ErrContentTooShort)
+	}
+}
+
+func TestWithMaxPostsPerAuthor(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(WithMaxPostsPerAuthor(3))
+
+	// Concurrent creates cannot overshoot the limit between count and insert.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store.CreatePost(ctx, &model.Post{Title: fmt.Sprintf("Post %d", i), Content: "Content", Author: "jane"})
+		}(i)
+	}
+	wg.Wait()
+	if count, _ := store.CountPostsByAuthor(ctx, "jane"); count != 3 {
+		t.Errorf("CountPostsByAuthor() = %d, want %d", count, 3)
+	}
+
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "More", Content: "Content", Author: "JANE"}); !errors.Is(err, ErrAuthorLimitReached) {
+		t.Errorf("CreatePost() error = %v, want %v", err, ErrAuthorLimitReached)
+	}
+	if _, _, err := store.UpsertPost(ctx, 99, &model.Post{Title: "Upserted", Content: "Content", Author: "jane"}); !errors.Is(err, ErrAuthorLimitReached) {
+		t.Errorf("UpsertPost() error = %v, want %v", err, ErrAuthorLimitReached)
+	}
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "Anonymous", Content: "Content"}); err != nil {
+		t.Errorf("CreatePost() without an author error = %v, want nil", err)
+	}
+
+	// Trashed posts free their slot.
+	posts, _, _ := store.GetAllPosts(ctx, PostFilter{Authors: []string{"jane"}})
+	store.DeletePost(ctx, posts[0].ID)
+	if _, err := store.CreatePost(ctx, &model.Post{Title: "Replacement", Content: "Content", Author: "jane"}); err != nil {
+		t.Errorf("CreatePost() after a delete error = %v, want nil", err)
+	}
+}
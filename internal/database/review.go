@@ -0,0 +1,52 @@
+package database
+
+import (
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// Reasons a post is flagged in the review queue.
+const (
+	ReviewNoCategory   = "no_category"
+	ReviewNoTags       = "no_tags"
+	ReviewShortContent = "short_content"
+	ReviewStale        = "stale"
+)
+
+// ReviewCriteria sets the thresholds of ReviewQueue. Zero-valued thresholds
+// disable their rule; posts without a category or tags are always flagged.
+type ReviewCriteria struct {
+	// DefaultCategory counts as no category, since posts created without
+	// one are given it.
+	DefaultCategory string
+	// MinWords flags posts whose WordCount is below it.
+	MinWords int
+	// StaleBefore flags posts last updated before it.
+	StaleBefore time.Time
+}
+
+// ReviewItem is a post flagged for review and the reasons it was flagged.
+type ReviewItem struct {
+	Post    *model.Post `json:"post"`
+	Reasons []string    `json:"reasons"`
+}
+
+// reasons returns why a post needs review under c, in the order of the
+// Review constants, or nil if it doesn't.
+func (c ReviewCriteria) reasons(post *model.Post) []string {
+	var reasons []string
+	if post.Category == "" || post.Category == c.DefaultCategory {
+		reasons = append(reasons, ReviewNoCategory)
+	}
+	if len(post.Tags) == 0 {
+		reasons = append(reasons, ReviewNoTags)
+	}
+	if post.WordCount < c.MinWords {
+		reasons = append(reasons, ReviewShortContent)
+	}
+	if post.UpdatedAt.Before(c.StaleBefore) {
+		reasons = append(reasons, ReviewStale)
+	}
+	return reasons
+}
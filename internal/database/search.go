@@ -0,0 +1,100 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// SearchQuery is a parsed PostFilter.Term. Terms follow this grammar, where
+// items are separated by whitespace:
+//
+//	"some phrase"   matches the quoted text as written
+//	-word           excludes posts containing word
+//	-"some phrase"  excludes posts containing the phrase
+//	anything else   matches as written, together with adjacent plain words
+//
+// A run of plain words between the other items is kept as one phrase, so a
+// term without quotes or exclusions matches as a single substring, as it
+// always has. An unterminated quote runs to the end of the term. A post
+// matches when its title, content, or category contains every included
+// phrase and none of the excluded ones, ignoring case.
+type SearchQuery struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseSearch parses a search term. The phrases are lowercased.
+func ParseSearch(term string) SearchQuery {
+	var q SearchQuery
+	s := strings.ToLower(term)
+	// plainStart and plainEnd bound the current run of plain words in s.
+	plainStart, plainEnd := -1, -1
+	flush := func() {
+		if plainStart >= 0 {
+			q.Include = append(q.Include, s[plainStart:plainEnd])
+			plainStart = -1
+		}
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		exclude := false
+		if r == '-' && i+1 < len(s) {
+			if next, _ := utf8.DecodeRuneInString(s[i+1:]); !unicode.IsSpace(next) {
+				exclude = true
+				i++
+			}
+		}
+
+		if s[i] == '"' {
+			flush()
+			start := i + 1
+			phrase := s[start:]
+			i = len(s)
+			if end := strings.IndexByte(phrase, '"'); end >= 0 {
+				phrase, i = phrase[:end], start+end+1
+			}
+			if phrase = strings.TrimSpace(phrase); phrase != "" {
+				if exclude {
+					q.Exclude = append(q.Exclude, phrase)
+				} else {
+					q.Include = append(q.Include, phrase)
+				}
+			}
+			continue
+		}
+
+		end := strings.IndexFunc(s[i:], unicode.IsSpace)
+		if end < 0 {
+			end = len(s) - i
+		}
+		if exclude {
+			flush()
+			q.Exclude = append(q.Exclude, s[i:i+end])
+		} else {
+			if plainStart < 0 {
+				plainStart = i
+			}
+			plainEnd = i + end
+		}
+		i += end
+	}
+	flush()
+	return q
+}
+
+// postContains reports whether the post's title, content, or category
+// contains phrase, which must be lowercase.
+func postContains(post *model.Post, phrase string) bool {
+	return strings.Contains(strings.ToLower(post.Title), phrase) ||
+		strings.Contains(strings.ToLower(post.Content), phrase) ||
+		strings.Contains(strings.ToLower(post.Category), phrase)
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		term string
+		want SearchQuery
+	}{
+		{"Go", SearchQuery{Include: []string{"go"}}},
+		{"go  tips", SearchQuery{Include: []string{"go  tips"}}},
+		{"e-mail", SearchQuery{Include: []string{"e-mail"}}},
+		{`"exact phrase"`, SearchQuery{Include: []string{"exact phrase"}}},
+		{`"exact phrase" -draft`, SearchQuery{Include: []string{"exact phrase"}, Exclude: []string{"draft"}}},
+		{`go tips -java more words`, SearchQuery{Include: []string{"go tips", "more words"}, Exclude: []string{"java"}}},
+		{`-"old news" go`, SearchQuery{Include: []string{"go"}, Exclude: []string{"old news"}}},
+		{`"unterminated phrase`, SearchQuery{Include: []string{"unterminated phrase"}}},
+		{`a - b`, SearchQuery{Include: []string{"a - b"}}},
+		{`"" -""`, SearchQuery{}},
+	}
+	for _, tt := range tests {
+		if got := ParseSearch(tt.term); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSearch(%q) = %+v, want %+v", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestSearchOperators(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for _, post := range []model.Post{
+		{Title: "Go tips", Content: "Use the race detector."},
+		{Title: "Tips for Go", Content: "A draft of the race guide."},
+		{Title: "Rust", Content: "The borrow checker."},
+	} {
+		post := post
+		store.CreatePost(ctx, &post)
+	}
+
+	tests := []struct {
+		term string
+		want []int64
+	}{
+		{"go tips", []int64{1}},
+		{`"race detector"`, []int64{1}},
+		{`"race" -draft`, []int64{1}},
+		{`tips -"race guide"`, []int64{1}},
+		{"-go", []int64{3}},
+		{`"tips" "go"`, []int64{1, 2}},
+	}
+	for _, tt := range tests {
+		if got := searchIDs(t, store, tt.term); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search for %q = %v, want %v", tt.term, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gemini/go-blog-api/internal/middleware"
+)
+
+// Reindex handles POST /admin/reindex, recomputing the derived fields of
+// every post after the set of derived fields changes. Admin only.
+func (h *PostHandler) Reindex(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	updated, err := h.Store.RecomputeDerived(r.Context())
+	if err != nil {
+		h.serverError(w, r, "Failed to reindex posts", err)
+		return
+	}
+	h.writeJSON(w, r, http.StatusOK, map[string]int{"updated": updated})
+}
+
+// RepairIDs handles POST /admin/repair-ids, moving the next post ID past
+// every stored post after imports or restores left it stale. Admin only.
+func (h *PostHandler) RepairIDs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	previous, next, err := h.Store.RepairNextID(r.Context())
+	if err != nil {
+		h.serverError(w, r, "Failed to repair post IDs", err)
+		return
+	}
+	if next != previous {
+		h.Logger.Warn("next post id repaired", "previous", previous, "next", next)
+	}
+	h.writeJSON(w, r, http.StatusOK, repairResult{PreviousNextID: previous, NextID: next, Adjusted: next != previous})
+}
+
+// repairResult is the response of POST /admin/repair-ids.
+type repairResult struct {
+	PreviousNextID int64 `json:"previousNextId" id:""`
+	NextID         int64 `json:"nextId" id:""`
+	Adjusted       bool  `json:"adjusted"`
+}
+
+// Reset handles POST /admin/reset, deleting every post and comment and
+// restarting IDs at 1. Admin only, and only available with AllowReset.
+func (h *PostHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	if !h.AllowReset {
+		writeNotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	if err := h.Store.Reset(r.Context()); err != nil {
+		h.serverError(w, r, "Failed to reset store", err)
+		return
+	}
+	h.Logger.Warn("store reset", "clientIp", middleware.ClientIP(r))
+	w.WriteHeader(http.StatusNoContent)
+}
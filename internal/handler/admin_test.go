@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestReindex(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+
+	do := func(method string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/reindex", nil)
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.Reindex(rr, req)
+		return rr
+	}
+
+	t.Run("requires admin", func(t *testing.T) {
+		if status := do(http.MethodPost, false).Code; status != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("method", func(t *testing.T) {
+		if status := do(http.MethodGet, true).Code; status != http.StatusMethodNotAllowed {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusMethodNotAllowed)
+		}
+	})
+
+	t.Run("reindex", func(t *testing.T) {
+		rr := do(http.MethodPost, true)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var resp map[string]int
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		if updated, ok := resp["updated"]; !ok || updated != 0 {
+			t.Errorf("handler returned wrong body: got %v want updated 0 for an up-to-date store", resp)
+		}
+	})
+}
+
+func TestRepairIDs(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+
+	do := func(method string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/repair-ids", nil)
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.RepairIDs(rr, req)
+		return rr
+	}
+
+	if status := do(http.MethodPost, false).Code; status != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnauthorized)
+	}
+	if status := do(http.MethodGet, true).Code; status != http.StatusMethodNotAllowed {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusMethodNotAllowed)
+	}
+
+	rr := do(http.MethodPost, true)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var resp struct {
+		PreviousNextID int64 `json:"previousNextId"`
+		NextID         int64 `json:"nextId"`
+		Adjusted       bool  `json:"adjusted"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.PreviousNextID != 2 || resp.NextID != 2 || resp.Adjusted {
+		t.Errorf("handler returned wrong body: got %+v want next ID 2 unadjusted", resp)
+	}
+}
+
+func TestReset(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	for _, title := range []string{"First", "Second", "Third"} {
+		id, _ := store.CreatePost(ctx, &model.Post{Title: title, Content: "Content"})
+		store.AddComment(ctx, id, &model.Comment{Content: "Comment"})
+	}
+
+	do := func(admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/reset", nil)
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.Reset(rr, req)
+		return rr
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		if status := do(true).Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+
+	handler.AllowReset = true
+
+	t.Run("requires admin", func(t *testing.T) {
+		if status := do(false).Code; status != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		if status := do(true).Code; status != http.StatusNoContent {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+		}
+		if count, _ := store.CountPosts(ctx); count != 0 {
+			t.Errorf("store has %d posts after reset, want 0", count)
+		}
+
+		id, _ := store.CreatePost(ctx, &model.Post{Title: "First", Content: "Content"})
+		if id != 1 {
+			t.Errorf("first post after reset got ID %d, want 1", id)
+		}
+		comment, _ := store.AddComment(ctx, id, &model.Comment{Content: "Comment"})
+		if comment.ID != 1 {
+			t.Errorf("first comment after reset got ID %d, want 1", comment.ID)
+		}
+		if post, _ := store.GetPost(ctx, id); post.Slug != "first" {
+			t.Errorf("post after reset got slug %q, want %q", post.Slug, "first")
+		}
+	})
+}
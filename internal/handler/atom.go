@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// atomNamespace is the XML namespace of Atom 1.0 (RFC 4287).
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atomFeedEntries is the number of recent posts in the Atom feed.
+const atomFeedEntries = 20
+
+// atomAnonymous names the author of posts that have none, since Atom
+// requires an author on every entry of a feed without one.
+const atomAnonymous = "Anonymous"
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Author    atomAuthor  `xml:"author"`
+	Link      atomLink    `xml:"link"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// AtomFeed handles GET /feed.atom, writing the most recent published posts
+// as an Atom 1.0 feed. Entries are identified by their permalinks. HEAD gets
+// the same headers without the body, so feed readers can check the feed
+// cheaply.
+func (h *PostHandler) AtomFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	posts, err := h.Store.RecentPosts(r.Context(), atomFeedEntries)
+	if err != nil {
+		h.serverError(w, r, "Failed to get posts", err)
+		return
+	}
+
+	body, err := xml.MarshalIndent(h.atomFeed(h.siteURL(r), posts), "", "  ")
+	if err != nil {
+		h.serverError(w, r, "Failed to get posts", err)
+		return
+	}
+
+	body = append(append([]byte(xml.Header), body...), '\n')
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// atomFeed builds the feed for posts, newest first, linking them under
+// base. The feed is as recent as its most recently updated post.
+func (h *PostHandler) atomFeed(base string, posts []*model.Post) atomFeed {
+	self := base + "/feed.atom"
+	feed := atomFeed{
+		XMLNS: atomNamespace,
+		ID:    self,
+		Title: "Blog",
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: self},
+			{Rel: "alternate", Href: base},
+		},
+	}
+
+	var updated time.Time
+	for _, post := range posts {
+		if post.UpdatedAt.After(updated) {
+			updated = post.UpdatedAt
+		}
+		feed.Entries = append(feed.Entries, h.atomEntry(base, post))
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+	return feed
+}
+
+// atomEntry converts a post to an Atom entry.
+func (h *PostHandler) atomEntry(base string, post *model.Post) atomEntry {
+	link := h.permalink(base, post)
+	published := post.CreatedAt
+	if post.PublishedAt != nil {
+		published = *post.PublishedAt
+	}
+	author := post.AuthorName
+	if author == "" {
+		author = post.Author
+	}
+	if author == "" {
+		author = atomAnonymous
+	}
+
+	return atomEntry{
+		ID:        link,
+		Title:     post.Title,
+		Updated:   post.UpdatedAt.UTC().Format(time.RFC3339),
+		Published: published.UTC().Format(time.RFC3339),
+		Author:    atomAuthor{Name: author},
+		Link:      atomLink{Rel: "alternate", Type: "text/html", Href: link},
+		Content:   atomContent{Type: "html", Text: post.Content},
+	}
+}
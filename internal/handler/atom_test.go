@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestAtomFeed(t *testing.T) {
+	type feed struct {
+		XMLName xml.Name
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Updated   string `xml:"updated"`
+			Published string `xml:"published"`
+			Author    string `xml:"author>name"`
+			Content   string `xml:"content"`
+		} `xml:"entry"`
+	}
+	get := func(handler *PostHandler) (*httptest.ResponseRecorder, feed) {
+		req := httptest.NewRequest(http.MethodGet, "/feed.atom", nil)
+		rr := httptest.NewRecorder()
+		handler.AtomFeed(rr, req)
+
+		var doc feed
+		if err := xml.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("feed is not valid XML: %v", err)
+		}
+		return rr, doc
+	}
+
+	t.Run("entries", func(t *testing.T) {
+		store := database.NewMemoryStore()
+		handler := NewPostHandler(store)
+		handler.BaseURL = "https://blog.example.com"
+		ctx := context.Background()
+		store.CreatePost(ctx, &model.Post{Title: "Go tips", Content: "Use <b>gofmt</b>.", Author: "ana", Slug: "go-tips"})
+		store.CreatePost(ctx, &model.Post{Title: "Lisbon", Content: "Trams.", Slug: "lisbon"})
+		store.CreatePost(ctx, &model.Post{Title: "Unfinished", Content: "Draft.", Status: model.StatusDraft})
+
+		rr, doc := get(handler)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+			t.Errorf("handler returned wrong Content-Type: got %v want application/atom+xml", ct)
+		}
+		if doc.XMLName.Space != atomNamespace || doc.XMLName.Local != "feed" {
+			t.Errorf("feed has wrong root element: got %v want {%s feed}", doc.XMLName, atomNamespace)
+		}
+		if len(doc.Entries) != 2 {
+			t.Fatalf("feed has wrong number of entries: got %v want %v", len(doc.Entries), 2)
+		}
+
+		ids := map[string]string{}
+		for _, entry := range doc.Entries {
+			ids[entry.Title] = entry.ID
+			if entry.Updated == "" || entry.Published == "" {
+				t.Errorf("entry %q is missing its dates", entry.Title)
+			}
+		}
+		if want := "https://blog.example.com/posts/go-tips"; ids["Go tips"] != want {
+			t.Errorf("entry has wrong id: got %v want %v", ids["Go tips"], want)
+		}
+		for _, entry := range doc.Entries {
+			if entry.Title == "Go tips" && (entry.Author != "ana" || entry.Content != "Use <b>gofmt</b>.") {
+				t.Errorf("entry has wrong author or content: got %q, %q", entry.Author, entry.Content)
+			}
+			if entry.Title == "Lisbon" && entry.Author != atomAnonymous {
+				t.Errorf("entry has wrong author: got %q want %q", entry.Author, atomAnonymous)
+			}
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		store := database.NewMemoryStore()
+		handler := NewPostHandler(store)
+		ctx := context.Background()
+		for i := 0; i < atomFeedEntries+5; i++ {
+			store.CreatePost(ctx, &model.Post{Title: "Post", Content: "Content"})
+		}
+
+		if _, doc := get(handler); len(doc.Entries) != atomFeedEntries {
+			t.Errorf("feed has wrong number of entries: got %v want %v", len(doc.Entries), atomFeedEntries)
+		}
+	})
+
+	t.Run("HEAD", func(t *testing.T) {
+		handler := NewPostHandler(database.NewMemoryStore())
+		rr, _ := get(handler)
+
+		req := httptest.NewRequest(http.MethodHead, "/feed.atom", nil)
+		head := httptest.NewRecorder()
+		handler.AtomFeed(head, req)
+		if status := head.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if head.Body.Len() != 0 {
+			t.Errorf("handler wrote a body for HEAD: %q", head.Body.String())
+		}
+		if got, want := head.Header().Get("Content-Length"), strconv.Itoa(rr.Body.Len()); got != want {
+			t.Errorf("handler returned wrong Content-Length: got %v want %v", got, want)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/feed.atom", nil)
+		rr := httptest.NewRecorder()
+		NewPostHandler(database.NewMemoryStore()).AtomFeed(rr, req)
+		if status := rr.Code; status != http.StatusMethodNotAllowed {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusMethodNotAllowed)
+		}
+		if allow := rr.Header().Get("Allow"); allow != "GET, HEAD" {
+			t.Errorf("handler returned wrong Allow header: got %q want %q", allow, "GET, HEAD")
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// isAdmin reports whether the request carries the configured admin token as
+// a bearer token. Admin access is disabled when no token is configured.
+func (h *PostHandler) isAdmin(r *http.Request) bool {
+	if h.AdminToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.AdminToken)) == 1
+}
+
+// requireAdmin responds 401 and reports false unless the request is from an
+// admin.
+func (h *PostHandler) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.isAdmin(r) {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+	return false
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// authorPosts is the body of a GET /authors/{author}/posts response. Posts
+// holds the page of posts, or just their IDs for Prefer: return=minimal.
+type authorPosts struct {
+	Author string               `json:"author"`
+	Stats  database.AuthorStats `json:"stats"`
+	Posts  interface{}          `json:"posts"`
+}
+
+// ServeAuthorPosts handles GET /authors/{author}/posts, listing a page of
+// the author's published posts, newest first, along with totals over all
+// of them. An unknown author gives no posts and zero stats. The page and the
+// stats are read separately, so a post published or deleted in between can
+// be counted in one but not the other. Like other listings, an empty page is
+// 204 No Content under EmptyListNoContent.
+func (h *PostHandler) ServeAuthorPosts(w http.ResponseWriter, r *http.Request) {
+	author, ok, err := collectionName(r, "/authors/")
+	switch {
+	case err != nil:
+		writeError(w, r, http.StatusBadRequest, CodeInvalidName, err.Error())
+		return
+	case !ok:
+		writeNotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	limit, offset, err := parsePagination(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+	filter := database.PostFilter{Authors: []string{author}, Limit: limit, Offset: offset, Sort: database.SortNewest}
+
+	posts, total, err := h.Store.GetAllPosts(r.Context(), filter)
+	if err != nil {
+		h.serverError(w, r, "Failed to get posts", err)
+		return
+	}
+	stats, err := h.Store.AuthorStats(r.Context(), author)
+	if err != nil {
+		h.serverError(w, r, "Failed to get author stats", err)
+		return
+	}
+	if posts == nil {
+		posts = []*model.Post{}
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Add("Vary", "Prefer")
+	if len(posts) == 0 && h.EmptyListNoContent {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	resp := authorPosts{Author: author, Stats: stats, Posts: posts}
+	if preferMinimal(r) {
+		if resp.Posts, err = project(posts, []string{"id"}); err != nil {
+			h.serverError(w, r, "Failed to encode posts", err)
+			return
+		}
+		applyMinimal(w)
+	}
+	h.writeJSON(w, r, http.StatusOK, resp)
+}
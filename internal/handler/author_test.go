@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestServeAuthorPosts(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	first, _ := store.CreatePost(ctx, &model.Post{Title: "First", Content: "One two three", Author: "jane", AllowComments: true})
+	store.CreatePost(ctx, &model.Post{Title: "Second", Content: "Four five", Author: "Jane"})
+	store.CreatePost(ctx, &model.Post{Title: "Draft", Content: "Not counted at all", Author: "jane", Status: model.StatusDraft})
+	store.CreatePost(ctx, &model.Post{Title: "Other", Content: "Someone else", Author: "bob"})
+	for i, content := range []string{"Nice", "Great", "Spam"} {
+		comment, _ := store.AddComment(ctx, first, &model.Comment{Author: "ana", Content: content})
+		if i < 2 {
+			store.ApproveComment(ctx, first, comment.ID)
+		}
+	}
+
+	type page struct {
+		Author string               `json:"author"`
+		Stats  database.AuthorStats `json:"stats"`
+		Posts  []model.Post         `json:"posts"`
+	}
+	get := func(path string, header ...string) (*httptest.ResponseRecorder, page) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeAuthorPosts(rr, req)
+
+		var resp page
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr, resp
+	}
+
+	t.Run("stats", func(t *testing.T) {
+		rr, resp := get("/authors/JANE/posts?limit=1")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		want := database.AuthorStats{Posts: 2, Words: 5, Comments: 2}
+		if resp.Stats != want {
+			t.Errorf("handler returned wrong stats: got %+v want %+v", resp.Stats, want)
+		}
+		if len(resp.Posts) != 1 || resp.Posts[0].Title != "Second" {
+			t.Errorf("handler returned wrong posts: got %v want [Second]", resp.Posts)
+		}
+		if total := rr.Header().Get("X-Total-Count"); total != "2" {
+			t.Errorf("handler returned wrong X-Total-Count: got %v want %v", total, "2")
+		}
+	})
+
+	t.Run("unknown author", func(t *testing.T) {
+		rr, resp := get("/authors/nobody/posts")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if resp.Posts == nil || len(resp.Posts) != 0 || resp.Stats != (database.AuthorStats{}) {
+			t.Errorf("handler returned wrong result: got %+v want no posts and zero stats", resp)
+		}
+	})
+
+	t.Run("minimal", func(t *testing.T) {
+		rr, resp := get("/authors/jane/posts", "Prefer", "return=minimal")
+		if applied := rr.Header().Get("Preference-Applied"); applied != "return=minimal" {
+			t.Errorf("handler returned wrong Preference-Applied: got %q want %q", applied, "return=minimal")
+		}
+		if len(resp.Posts) != 2 || resp.Posts[0].ID != 2 || resp.Posts[0].Title != "" || resp.Stats.Posts != 2 {
+			t.Errorf("handler returned wrong minimal page: got %+v want IDs only and the stats", resp)
+		}
+	})
+
+	t.Run("empty list no content", func(t *testing.T) {
+		handler.EmptyListNoContent = true
+		defer func() { handler.EmptyListNoContent = false }()
+		if rr, _ := get("/authors/nobody/posts"); rr.Code != http.StatusNoContent {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNoContent)
+		}
+		if rr, _ := get("/authors/jane/posts"); rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("other paths", func(t *testing.T) {
+		if rr, _ := get("/authors/jane"); rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/authors/jane/posts", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeAuthorPosts(rr, req)
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusMethodNotAllowed)
+		}
+	})
+}
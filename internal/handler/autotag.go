@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultAutoTagCount is the default value of PostHandler.AutoTagCount.
+const DefaultAutoTagCount = 5
+
+// minAutoTagLength is the shortest word extractTags considers.
+const minAutoTagLength = 3
+
+// defaultStopwords are the common English words extractTags skips unless
+// PostHandler.AutoTagStopwords replaces them.
+var defaultStopwords = []string{
+	"about", "after", "again", "all", "also", "and", "any", "are", "because",
+	"been", "before", "being", "but", "can", "could", "did", "does", "doing",
+	"down", "each", "for", "from", "had", "has", "have", "her", "here", "him",
+	"his", "how", "into", "its", "just", "more", "most", "not", "now", "off",
+	"once", "one", "only", "other", "our", "out", "over", "own", "same",
+	"she", "should", "some", "such", "than", "that", "the", "their", "them",
+	"then", "there", "these", "they", "this", "those", "through", "too",
+	"under", "until", "use", "very", "was", "way", "were", "what", "when",
+	"where", "which", "while", "who", "why", "will", "with", "would", "you",
+	"your",
+}
+
+// extractTags picks up to n candidate tags from a post's content: the words
+// used most often, at least twice, ignoring HTML, case, numbers, stopwords,
+// and words shorter than minAutoTagLength. Words used equally often keep
+// the order they first appear in.
+func extractTags(content string, stopwords map[string]bool, n int) []string {
+	text := html.UnescapeString(plainText.Sanitize(content))
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	counts := make(map[string]int)
+	var order []string
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < minAutoTagLength || utf8.RuneCountInString(word) > maxTagLength ||
+			stopwords[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		if counts[word] == 0 {
+			order = append(order, word)
+		}
+		counts[word]++
+	}
+
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+	var tags []string
+	for _, word := range order {
+		if len(tags) == n || counts[word] < 2 {
+			break
+		}
+		tags = append(tags, word)
+	}
+	return normalizeTags(nil, tags)
+}
+
+// stopwordSet returns the stopwords extractTags skips, lowercased.
+func (h *PostHandler) stopwordSet() map[string]bool {
+	words := h.AutoTagStopwords
+	if words == nil {
+		words = defaultStopwords
+	}
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[strings.ToLower(strings.TrimSpace(word))] = true
+	}
+	return set
+}
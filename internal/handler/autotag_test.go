@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestAutoTag(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+	handler.AutoTagCount = 3
+	content := "<p>Kubernetes makes <b>deploying</b> containers easy. With Kubernetes, " +
+		"containers scale; the Kubernetes scheduler places containers. " +
+		"Golang powers Kubernetes, and golang is fast. In 2024 and 2024 again, the the the.</p>"
+
+	create := func(query, body string) model.Post {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/posts"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", status, http.StatusCreated, rr.Body.String())
+		}
+		var post model.Post
+		json.Unmarshal(rr.Body.Bytes(), &post)
+		return post
+	}
+	body := func(title, tags string) string {
+		b, _ := json.Marshal(map[string]interface{}{"title": title, "content": content})
+		if tags != "" {
+			return strings.TrimSuffix(string(b), "}") + `,"tags":` + tags + "}"
+		}
+		return string(b)
+	}
+
+	if got, want := create("?autoTag=true", body("Tagged", "")).Tags, []string{"kubernetes", "containers", "golang"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("handler extracted wrong tags: got %v want %v", got, want)
+	}
+	if got := create("", body("Untagged", "")).Tags; len(got) != 0 {
+		t.Errorf("handler tagged a post without autoTag: %v", got)
+	}
+	if got, want := create("?autoTag=true", body("Own tags", `["k8s"]`)).Tags, []string{"k8s"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("handler replaced the sent tags: got %v want %v", got, want)
+	}
+
+	// Custom stopwords replace the built-in list and match any case.
+	handler.AutoTagStopwords = []string{"Kubernetes", "THE", "and"}
+	if got, want := create("?autoTag=true", body("Custom stopwords", "")).Tags, []string{"containers", "golang"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("handler extracted wrong tags with custom stopwords: got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,374 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+	"github.com/gemini/go-blog-api/internal/webhook"
+)
+
+// AddComment handles POST /posts/{id}/comments
+//
+// Posts with AllowComments unset, or at the store's comment limit, reject
+// new comments with 403.
+func (h *PostHandler) AddComment(w http.ResponseWriter, r *http.Request, postID int64) {
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	post, err := h.Store.GetPost(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+			return
+		}
+		h.serverError(w, r, "Failed to add comment", err)
+		return
+	}
+	if !post.AllowComments {
+		writeError(w, r, http.StatusForbidden, CodeCommentsDisabled, "Comments are disabled for this post")
+		return
+	}
+
+	var comment model.Comment
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request payload")
+		return
+	}
+
+	comment.Replies = nil
+	if h.Sanitizer != nil {
+		comment.Content = h.Sanitizer.Sanitize(comment.Content)
+	}
+	if errs := h.validateComment(&comment); len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	created, err := h.Store.AddComment(r.Context(), postID, &comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrPostNotFound):
+			h.notFound(w, r, err)
+		case errors.Is(err, database.ErrInvalidParent):
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParent, err.Error())
+		case errors.Is(err, database.ErrTooManyComments):
+			writeError(w, r, http.StatusForbidden, CodeCommentLimitReached, "This post has reached the maximum number of comments")
+		default:
+			h.serverError(w, r, "Failed to add comment", err)
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusCreated, created)
+}
+
+// maxBulkComments caps how many comments a single bulk request may add.
+const maxBulkComments = 500
+
+// BulkAddComments handles POST /posts/{id}/comments/bulk, importing an array
+// of comments, such as a post's discussion migrated from another platform.
+// Each comment is validated like one sent to AddComment; the valid ones are
+// stored together, keeping any status and createdAt they carry, and the
+// response reports the outcome for every comment in order, rejecting those
+// with an unknown status. Admin only, and allowed even on posts that no
+// longer take comments.
+func (h *PostHandler) BulkAddComments(w http.ResponseWriter, r *http.Request, postID int64) {
+	if !h.requireAdmin(w, r) || !h.requireJSON(w, r) {
+		return
+	}
+
+	var comments []*model.Comment
+	if err := json.NewDecoder(r.Body).Decode(&comments); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request payload")
+		return
+	}
+	var errs model.ValidationErrors
+	switch {
+	case len(comments) == 0:
+		errs.Add("comments", "required")
+	case len(comments) > maxBulkComments:
+		errs.Add("comments", "too many")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	// Invalid comments are answered here; the rest go to the store, whose
+	// results fill the remaining slots in order.
+	results := make([]database.BulkResult, len(comments))
+	var valid []*model.Comment
+	var slots []int
+	for i, comment := range comments {
+		if comment == nil {
+			results[i] = database.BulkResult{Result: database.BulkRejected, Reason: "comment must be an object"}
+			continue
+		}
+		comment.Replies = nil
+		if h.Sanitizer != nil {
+			comment.Content = h.Sanitizer.Sanitize(comment.Content)
+		}
+		if errs := h.validateComment(comment); len(errs) > 0 {
+			results[i] = database.BulkResult{Result: database.BulkRejected, Reason: errs.Error()}
+			continue
+		}
+		switch comment.Status {
+		case "", model.CommentPending, model.CommentApproved, model.CommentRejected:
+		default:
+			results[i] = database.BulkResult{Result: database.BulkRejected, Reason: fmt.Sprintf(
+				"status must be one of %q, %q, or %q", model.CommentPending, model.CommentApproved, model.CommentRejected)}
+			continue
+		}
+		valid = append(valid, comment)
+		slots = append(slots, i)
+	}
+
+	if len(valid) > 0 {
+		stored, err := h.Store.BulkAddComments(r.Context(), postID, valid)
+		if err != nil {
+			if errors.Is(err, database.ErrPostNotFound) {
+				h.notFound(w, r, err)
+			} else {
+				h.serverError(w, r, "Failed to add comments", err)
+			}
+			return
+		}
+		for j, result := range stored {
+			results[slots[j]] = result
+		}
+	} else if _, err := h.Store.GetPost(r.Context(), postID); err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to add comments", err)
+		}
+		return
+	}
+
+	h.writeBulkResults(w, r, results, commentID)
+}
+
+// ListComments handles GET /posts/{id}/comments
+//
+// Only approved comments are listed by default. Admins can pass a status of
+// pending, rejected, or all to moderate the rest. Comments are listed oldest
+// first unless order=newest, and each page holds CommentPageSize comments
+// unless limit says otherwise. With tree=true, replies on the requested page
+// are nested under their parents.
+func (h *PostHandler) ListComments(w http.ResponseWriter, r *http.Request, postID int64) {
+	query := r.URL.Query()
+	filter := database.CommentFilter{Status: query.Get("status")}
+	switch filter.Status {
+	case "", model.CommentApproved:
+		filter.Status = model.CommentApproved
+	case "all", model.CommentPending, model.CommentRejected:
+		if !h.requireAdmin(w, r) {
+			return
+		}
+		if filter.Status == "all" {
+			filter.Status = ""
+		}
+	default:
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, "Invalid comment status")
+		return
+	}
+
+	switch query.Get("order") {
+	case "", database.SortOldest:
+	case database.SortNewest:
+		filter.NewestFirst = true
+	default:
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, `order must be "oldest" or "newest"`)
+		return
+	}
+
+	limit, offset, err := parsePagination(query)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+	if query.Get("limit") == "" {
+		limit = h.CommentPageSize
+	}
+	filter.Limit, filter.Offset = limit, offset
+
+	comments, total, err := h.Store.GetComments(r.Context(), postID, filter)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to get comments", err)
+		}
+		return
+	}
+
+	if query.Get("tree") == "true" {
+		comments = commentTree(comments)
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	h.writeJSON(w, r, http.StatusOK, comments)
+}
+
+// DeleteComment handles DELETE /posts/{id}/comments/{commentID}
+//
+// There are no user accounts to prove a caller wrote the comment, so
+// deletion is limited to admins.
+func (h *PostHandler) DeleteComment(w http.ResponseWriter, r *http.Request, postID, commentID int64) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	err := h.Store.DeleteComment(r.Context(), postID, commentID)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) || errors.Is(err, database.ErrCommentNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to delete comment", err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// commentTree nests copies of comments under their parents, keeping each
+// level in the original order. Replies whose parent is not among comments,
+// such as replies to a comment awaiting moderation, are placed at the top
+// level.
+func commentTree(comments []*model.Comment) []*model.Comment {
+	nodes := make(map[int64]*model.Comment, len(comments))
+	for _, comment := range comments {
+		node := *comment
+		node.Replies = nil
+		nodes[comment.ID] = &node
+	}
+
+	roots := make([]*model.Comment, 0)
+	for _, comment := range comments {
+		node := nodes[comment.ID]
+		if comment.ParentID != nil {
+			if parent, ok := nodes[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// ApproveComment handles POST /posts/{id}/comments/{commentID}/approve.
+// Admin only.
+func (h *PostHandler) ApproveComment(w http.ResponseWriter, r *http.Request, postID, commentID int64) {
+	h.moderateComment(w, r, postID, commentID, h.Store.ApproveComment)
+}
+
+// RejectComment handles POST /posts/{id}/comments/{commentID}/reject.
+// Admin only.
+func (h *PostHandler) RejectComment(w http.ResponseWriter, r *http.Request, postID, commentID int64) {
+	h.moderateComment(w, r, postID, commentID, h.Store.RejectComment)
+}
+
+// RestoreComment handles POST /posts/{id}/comments/{commentID}/restore,
+// undoing a delete. Admin only.
+func (h *PostHandler) RestoreComment(w http.ResponseWriter, r *http.Request, postID, commentID int64) {
+	h.moderateComment(w, r, postID, commentID, h.Store.RestoreComment)
+}
+
+// moderateComment applies a moderation decision to a comment.
+func (h *PostHandler) moderateComment(w http.ResponseWriter, r *http.Request, postID, commentID int64, moderate func(ctx context.Context, postID, commentID int64) (*model.Comment, error)) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	comment, err := moderate(r.Context(), postID, commentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrPostNotFound) || errors.Is(err, database.ErrCommentNotFound):
+			h.notFound(w, r, err)
+		case errors.Is(err, database.ErrCommentNotDeleted):
+			writeError(w, r, http.StatusConflict, CodeInvalidState, err.Error())
+		default:
+			h.serverError(w, r, "Failed to moderate comment", err)
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, comment)
+}
+
+// PromoteComment handles POST /comments/{id}/promote, turning a comment
+// into a draft post by the same author with the comment as its content and
+// a link back to the post it was left on. The title is "Re: " and that
+// post's title, for an editor to rework. Admin only.
+func (h *PostHandler) PromoteComment(w http.ResponseWriter, r *http.Request, commentID int64) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	comment, err := h.Store.GetComment(r.Context(), commentID)
+	if err != nil {
+		if errors.Is(err, database.ErrCommentNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to promote comment", err)
+		}
+		return
+	}
+	original, err := h.Store.GetPost(r.Context(), comment.PostID)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to promote comment", err)
+		}
+		return
+	}
+
+	title := []rune("Re: " + original.Title)
+	post := &model.Post{
+		Title: string(title[:min(len(title), MaxTitleLength)]),
+		Content: fmt.Sprintf(`%s<p>Originally a comment on <a href="/posts/%s">%s</a>.</p>`,
+			comment.Content, h.formatID(original.ID), html.EscapeString(original.Title)),
+		Category:      h.DefaultCategory,
+		Author:        comment.Author,
+		Status:        model.StatusDraft,
+		AllowComments: true,
+	}
+	h.sanitizePost(post)
+	if errs := h.validate(post); len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	id, err := h.Store.CreatePost(r.Context(), post)
+	if err != nil {
+		switch limitErrs := storeLimitErrors(err); {
+		case limitErrs != nil:
+			writeValidationErrors(w, r, limitErrs)
+		case errors.Is(err, database.ErrStoreFull):
+			writeError(w, r, http.StatusInsufficientStorage, CodeStoreFull, err.Error())
+		default:
+			h.serverError(w, r, "Failed to promote comment", err)
+		}
+		return
+	}
+	createdPost, err := h.Store.GetPost(r.Context(), id)
+	if err != nil {
+		h.serverError(w, r, "Failed to retrieve created post", err)
+		return
+	}
+
+	h.Webhook.Notify(webhook.PostCreated, createdPost.ID, createdPost)
+
+	h.writeJSON(w, r, http.StatusCreated, createdPost)
+}
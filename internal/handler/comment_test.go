@@ -0,0 +1,677 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestComments(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content", AllowComments: true})
+
+	do := func(method, path, body string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("add and list", func(t *testing.T) {
+		rr := do(http.MethodPost, "/posts/1/comments", `{"author":"jane","content":"Nice <script>x</script>post"}`, false)
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+		var comment model.Comment
+		json.Unmarshal(rr.Body.Bytes(), &comment)
+		if comment.PostID != 1 || comment.Content != "Nice post" {
+			t.Errorf("handler returned unexpected comment: %+v", comment)
+		}
+
+		if comment.Status != model.CommentPending {
+			t.Errorf("new comment status = %q, want %q", comment.Status, model.CommentPending)
+		}
+
+		rr = do(http.MethodGet, "/posts/1/comments?status=all", "", true)
+		var comments []model.Comment
+		json.Unmarshal(rr.Body.Bytes(), &comments)
+		if len(comments) != 1 {
+			t.Errorf("handler returned wrong number of comments: got %v want %v", len(comments), 1)
+		}
+	})
+
+	t.Run("moderation", func(t *testing.T) {
+		list := func(query string, admin bool) []model.Comment {
+			rr := do(http.MethodGet, "/posts/1/comments"+query, "", admin)
+			var comments []model.Comment
+			json.Unmarshal(rr.Body.Bytes(), &comments)
+			return comments
+		}
+
+		rr := do(http.MethodPost, "/posts/1/comments", `{"content":"Pending","status":"approved"}`, false)
+		var comment model.Comment
+		json.Unmarshal(rr.Body.Bytes(), &comment)
+		path := "/posts/1/comments/" + strconv.FormatInt(comment.ID, 10)
+
+		for _, c := range list("", false) {
+			if c.ID == comment.ID {
+				t.Fatalf("pending comment %d listed publicly", comment.ID)
+			}
+		}
+		if status := do(http.MethodGet, "/posts/1/comments?status=all", "", false).Code; status != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code for status=all without token: got %v want %v", status, http.StatusUnauthorized)
+		}
+		if status := do(http.MethodPost, path+"/approve", "", false).Code; status != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code for approve without token: got %v want %v", status, http.StatusUnauthorized)
+		}
+
+		if status := do(http.MethodPost, path+"/approve", "", true).Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if comments := list("", false); len(comments) != 1 || comments[0].ID != comment.ID {
+			t.Errorf("approved comment not listed publicly: %+v", comments)
+		}
+
+		do(http.MethodPost, path+"/reject", "", true)
+		if comments := list("", false); len(comments) != 0 {
+			t.Errorf("rejected comment listed publicly: %+v", comments)
+		}
+		if comments := list("?status=rejected", true); len(comments) != 1 {
+			t.Errorf("handler returned wrong number of rejected comments: got %v want %v", len(comments), 1)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		handler.MaxCommentLength = 20
+		defer func() { handler.MaxCommentLength = DefaultMaxCommentLength }()
+
+		tests := []struct {
+			name  string
+			body  string
+			field string
+		}{
+			{"empty content", `{"content":"  "}`, "content"},
+			{"overlong content", `{"content":"` + strings.Repeat("a", 21) + `"}`, "content"},
+			{"overlong author", `{"author":"` + strings.Repeat("a", 101) + `","content":"Hi"}`, "author"},
+		}
+		for _, tt := range tests {
+			rr := do(http.MethodPost, "/posts/1/comments", tt.body, false)
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.name, status, http.StatusUnprocessableEntity)
+				continue
+			}
+			var resp struct {
+				Errors model.ValidationErrors `json:"errors"`
+			}
+			json.Unmarshal(rr.Body.Bytes(), &resp)
+			if len(resp.Errors) != 1 || resp.Errors[0].Field != tt.field {
+				t.Errorf("%s: handler returned wrong errors: got %+v want one for %q", tt.name, resp.Errors, tt.field)
+			}
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		rr := do(http.MethodPost, "/posts/1/comments", `{"content":"Delete me"}`, false)
+		var comment model.Comment
+		json.Unmarshal(rr.Body.Bytes(), &comment)
+		path := "/posts/1/comments/" + strconv.FormatInt(comment.ID, 10)
+
+		if status := do(http.MethodDelete, path, "", false).Code; status != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code without token: got %v want %v", status, http.StatusUnauthorized)
+		}
+		if status := do(http.MethodDelete, path, "", true).Code; status != http.StatusNoContent {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+		}
+		if status := do(http.MethodDelete, path, "", true).Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code for deleted comment: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			method string
+			path   string
+		}{
+			{"list on missing post", http.MethodGet, "/posts/99/comments"},
+			{"add to missing post", http.MethodPost, "/posts/99/comments"},
+			{"delete on missing post", http.MethodDelete, "/posts/99/comments/1"},
+			{"delete missing comment", http.MethodDelete, "/posts/1/comments/99"},
+			{"approve missing comment", http.MethodPost, "/posts/1/comments/99/approve"},
+		}
+		for _, tt := range tests {
+			rr := do(tt.method, tt.path, `{"content":"Hello"}`, true)
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.name, status, http.StatusNotFound)
+			}
+		}
+	})
+}
+
+func TestCommentThreads(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	first, _ := store.CreatePost(ctx, &model.Post{Title: "First", Content: "Content", AllowComments: true})
+	second, _ := store.CreatePost(ctx, &model.Post{Title: "Second", Content: "Content", AllowComments: true})
+
+	add := func(postID int64, parentID *int64) *model.Comment {
+		comment, err := store.AddComment(ctx, postID, &model.Comment{Content: "Comment", ParentID: parentID})
+		if err != nil {
+			t.Fatalf("AddComment() error = %v", err)
+		}
+		store.ApproveComment(ctx, postID, comment.ID)
+		return comment
+	}
+	root := add(first, nil)
+	reply := add(first, &root.ID)
+	add(first, &reply.ID)
+	add(first, nil)
+
+	t.Run("tree", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts/1/comments?tree=true", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var comments []model.Comment
+		json.Unmarshal(rr.Body.Bytes(), &comments)
+		if len(comments) != 2 {
+			t.Fatalf("handler returned wrong number of top-level comments: got %v want %v", len(comments), 2)
+		}
+		replies := comments[0].Replies
+		if len(replies) != 1 || replies[0].ID != reply.ID || len(replies[0].Replies) != 1 {
+			t.Errorf("handler returned wrong thread: %+v", comments[0])
+		}
+	})
+
+	t.Run("flat", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts/1/comments", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var comments []model.Comment
+		json.Unmarshal(rr.Body.Bytes(), &comments)
+		if len(comments) != 4 {
+			t.Errorf("handler returned wrong number of comments: got %v want %v", len(comments), 4)
+		}
+	})
+
+	t.Run("parent on another post", func(t *testing.T) {
+		body := `{"content":"Reply","parentId":` + strconv.FormatInt(root.ID, 10) + `}`
+		req := httptest.NewRequest(http.MethodPost, "/posts/"+strconv.FormatInt(second, 10)+"/comments", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestCommentSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	postID, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content", AllowComments: true})
+
+	add := func(parentID *int64) *model.Comment {
+		comment, _ := store.AddComment(ctx, postID, &model.Comment{Author: "ana", Content: "Comment", ParentID: parentID})
+		store.ApproveComment(ctx, postID, comment.ID)
+		return comment
+	}
+	parent := add(nil)
+	reply := add(&parent.ID)
+	lonely := add(nil)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	list := func() []model.Comment {
+		var comments []model.Comment
+		json.Unmarshal(do(http.MethodGet, "/posts/1/comments?tree=true").Body.Bytes(), &comments)
+		return comments
+	}
+
+	for _, id := range []int64{parent.ID, lonely.ID} {
+		if rr := do(http.MethodDelete, fmt.Sprintf("/posts/1/comments/%d", id)); rr.Code != http.StatusNoContent {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNoContent)
+		}
+	}
+
+	comments := list()
+	if len(comments) != 1 {
+		t.Fatalf("handler returned %d top-level comments, want only the tombstone", len(comments))
+	}
+	tombstone := comments[0]
+	if tombstone.ID != parent.ID || tombstone.Content != model.DeletedCommentContent || tombstone.Author != "" || tombstone.DeletedAt == nil {
+		t.Errorf("handler returned wrong tombstone: %+v", tombstone)
+	}
+	if len(tombstone.Replies) != 1 || tombstone.Replies[0].ID != reply.ID || tombstone.Replies[0].Content != "Comment" {
+		t.Errorf("handler returned wrong replies under the tombstone: %+v", tombstone.Replies)
+	}
+
+	t.Run("tombstone removed with its last reply", func(t *testing.T) {
+		do(http.MethodDelete, fmt.Sprintf("/posts/1/comments/%d", reply.ID))
+		if comments := list(); len(comments) != 0 {
+			t.Errorf("handler returned %d comments, want none", len(comments))
+		}
+		do(http.MethodPost, fmt.Sprintf("/posts/1/comments/%d/restore", reply.ID))
+	})
+
+	t.Run("deleted twice", func(t *testing.T) {
+		if rr := do(http.MethodDelete, fmt.Sprintf("/posts/1/comments/%d", parent.ID)); rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		rr := do(http.MethodPost, fmt.Sprintf("/posts/1/comments/%d/restore", parent.ID))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if comments := list(); len(comments) != 1 || comments[0].Content != "Comment" || comments[0].DeletedAt != nil {
+			t.Errorf("handler returned wrong comments after restore: %+v", comments)
+		}
+		if rr := do(http.MethodPost, fmt.Sprintf("/posts/1/comments/%d/restore", parent.ID)); rr.Code != http.StatusConflict {
+			t.Errorf("restoring a live comment: got %v want %v", rr.Code, http.StatusConflict)
+		}
+	})
+	t.Run("deletedAt ignored on create", func(t *testing.T) {
+		body := `{"author":"bo","content":"Born deleted?","deletedAt":"2020-01-01T00:00:00Z"}`
+		req := httptest.NewRequest(http.MethodPost, "/posts/1/comments", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		var created model.Comment
+		json.Unmarshal(rr.Body.Bytes(), &created)
+		if created.DeletedAt != nil {
+			t.Errorf("handler created a deleted comment: %+v", created)
+		}
+		if stored, err := store.GetComment(ctx, created.ID); err != nil || stored.DeletedAt != nil {
+			t.Errorf("GetComment() = %+v, %v; want a live comment", stored, err)
+		}
+	})
+}
+
+func TestCommentPagination(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	postID, _ := store.CreatePost(ctx, &model.Post{Title: "Popular", Content: "Content"})
+	for i := 0; i < 30; i++ {
+		comment, _ := store.AddComment(ctx, postID, &model.Comment{Content: "Comment"})
+		store.ApproveComment(ctx, postID, comment.ID)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/1/comments?limit=10&offset=10", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if total := rr.Header().Get("X-Total-Count"); total != "30" {
+		t.Errorf("handler returned wrong X-Total-Count: got %v want %v", total, "30")
+	}
+
+	var comments []model.Comment
+	json.Unmarshal(rr.Body.Bytes(), &comments)
+	if len(comments) != 10 {
+		t.Fatalf("handler returned wrong number of comments: got %v want %v", len(comments), 10)
+	}
+	if comments[0].ID != 11 || comments[9].ID != 20 {
+		t.Errorf("handler returned wrong page: got IDs %v to %v want 11 to 20", comments[0].ID, comments[9].ID)
+	}
+}
+
+func TestCommentOrder(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := base
+	store := database.NewMemoryStore(database.WithClock(func() time.Time { return clock }))
+	handler := NewPostHandler(store)
+	postID, _ := store.CreatePost(ctx, &model.Post{Title: "Popular", Content: "Content"})
+	for _, hours := range []int{2, 1, 3, 3} {
+		clock = base.Add(time.Duration(hours) * time.Hour)
+		comment, _ := store.AddComment(ctx, postID, &model.Comment{Content: "Comment"})
+		store.ApproveComment(ctx, postID, comment.ID)
+	}
+
+	list := func(query string) (int, []int64) {
+		req := httptest.NewRequest(http.MethodGet, "/posts/1/comments"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		var comments []model.Comment
+		json.Unmarshal(rr.Body.Bytes(), &comments)
+		var ids []int64
+		for _, c := range comments {
+			ids = append(ids, c.ID)
+		}
+		return rr.Code, ids
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "[2 1 3 4]"},
+		{"?order=oldest", "[2 1 3 4]"},
+		{"?order=newest", "[4 3 1 2]"},
+		{"?order=newest&limit=2&offset=1", "[3 1]"},
+	}
+	for _, tt := range tests {
+		status, ids := list(tt.query)
+		if status != http.StatusOK {
+			t.Fatalf("%s: handler returned wrong status code: got %v want %v", tt.query, status, http.StatusOK)
+		}
+		if fmt.Sprint(ids) != tt.want {
+			t.Errorf("%s: handler returned wrong order: got %v want %v", tt.query, ids, tt.want)
+		}
+	}
+
+	t.Run("invalid order", func(t *testing.T) {
+		if status, _ := list("?order=random"); status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("page size", func(t *testing.T) {
+		handler.CommentPageSize = 3
+		defer func() { handler.CommentPageSize = 0 }()
+
+		if _, ids := list("?order=newest"); fmt.Sprint(ids) != "[4 3 1]" {
+			t.Errorf("handler returned wrong page: got %v want %v", ids, "[4 3 1]")
+		}
+		if _, ids := list("?limit=4"); len(ids) != 4 {
+			t.Errorf("handler returned %d comments with an explicit limit, want %d", len(ids), 4)
+		}
+	})
+}
+
+func TestCommentsDisabled(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var open, closed model.Post
+	json.Unmarshal(do(http.MethodPost, "/posts", `{"title":"Open","content":"Content"}`).Body.Bytes(), &open)
+	json.Unmarshal(do(http.MethodPost, "/posts", `{"title":"Closed","content":"Content","allowComments":false}`).Body.Bytes(), &closed)
+	if !open.AllowComments || closed.AllowComments {
+		t.Fatalf("handler returned wrong allowComments: got %v and %v want true and false", open.AllowComments, closed.AllowComments)
+	}
+
+	if status := do(http.MethodPost, "/posts/"+strconv.FormatInt(open.ID, 10)+"/comments", `{"content":"Hello"}`).Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	if status := do(http.MethodPost, "/posts/"+strconv.FormatInt(closed.ID, 10)+"/comments", `{"content":"Hello"}`).Code; status != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusForbidden)
+	}
+
+	// Re-enabling through an update lets comments in again.
+	do(http.MethodPut, "/posts/"+strconv.FormatInt(closed.ID, 10), `{"title":"Closed","content":"Content","allowComments":true}`)
+	if status := do(http.MethodPost, "/posts/"+strconv.FormatInt(closed.ID, 10)+"/comments", `{"content":"Hello"}`).Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code after update: got %v want %v", status, http.StatusCreated)
+	}
+}
+
+func TestCommentLimit(t *testing.T) {
+	store := database.NewMemoryStore(database.WithMaxCommentsPerPost(3))
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	full, _ := store.CreatePost(ctx, &model.Post{Title: "Full", Content: "Content", AllowComments: true})
+	other, _ := store.CreatePost(ctx, &model.Post{Title: "Other", Content: "Content", AllowComments: true})
+
+	addComment := func(postID int64) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/posts/"+strconv.FormatInt(postID, 10)+"/comments", strings.NewReader(`{"content":"Hello"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 3; i++ {
+		if status := addComment(full).Code; status != http.StatusCreated {
+			t.Fatalf("comment %d: handler returned wrong status code: got %v want %v", i+1, status, http.StatusCreated)
+		}
+	}
+	// Pending and rejected comments count towards the limit too.
+	store.RejectComment(ctx, full, 1)
+
+	rr := addComment(full)
+	if status := rr.Code; status != http.StatusForbidden {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusForbidden)
+	}
+	if !strings.Contains(rr.Body.String(), "maximum number of comments") {
+		t.Errorf("handler returned unclear message: %q", rr.Body.String())
+	}
+
+	if status := addComment(other).Code; status != http.StatusCreated {
+		t.Errorf("limit applied to another post: got %v want %v", status, http.StatusCreated)
+	}
+
+	// Deleting a comment makes room again.
+	store.DeleteComment(ctx, full, 2)
+	if status := addComment(full).Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code after a delete: got %v want %v", status, http.StatusCreated)
+	}
+}
+
+func TestBulkAddComments(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	ctx := context.Background()
+	postID, _ := store.CreatePost(ctx, &model.Post{Title: "Imported", Content: "Content"})
+	existing, _ := store.AddComment(ctx, postID, &model.Comment{Author: "ana", Content: "First"})
+
+	do := func(path, body string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	body := fmt.Sprintf(`[
+		{"author":"bo","content":"Old reply","status":"approved","createdAt":"2019-05-01T10:00:00Z","parentId":%d},
+		{"author":"cy","content":""},
+		{"author":"di","content":"No timestamp"},
+		{"author":"ed","content":"Orphan","parentId":99}
+	]`, existing.ID)
+	rr := do("/posts/1/comments/bulk", body, true)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", status, http.StatusOK, rr.Body.String())
+	}
+	var resp struct {
+		Results []database.BulkResult `json:"results"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	want := []string{database.BulkCreated, database.BulkRejected, database.BulkCreated, database.BulkRejected}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("handler returned wrong number of results: got %+v want %v", resp.Results, len(want))
+	}
+	for i, result := range resp.Results {
+		if result.Result != want[i] {
+			t.Errorf("result %d: got %+v want %q", i, result, want[i])
+		}
+	}
+	if !strings.Contains(resp.Results[1].Reason, "content") || resp.Results[3].Reason != database.ErrInvalidParent.Error() {
+		t.Errorf("handler returned unclear reasons: %+v", resp.Results)
+	}
+
+	comments, _, _ := store.GetComments(ctx, postID, database.CommentFilter{})
+	if len(comments) != 3 {
+		t.Fatalf("store has wrong number of comments: got %v want %v", len(comments), 3)
+	}
+	imported := comments[0]
+	if imported.ID != resp.Results[0].ID || imported.Status != model.CommentApproved ||
+		!imported.CreatedAt.Equal(time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("imported comment lost its status or timestamp: %+v", imported)
+	}
+	if c := comments[2]; c.Status != model.CommentPending || time.Since(c.CreatedAt) > time.Minute {
+		t.Errorf("comment without status or timestamp got wrong defaults: %+v", c)
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			path  string
+			body  string
+			admin bool
+			want  int
+		}{
+			{"not admin", "/posts/1/comments/bulk", `[{"content":"Hi"}]`, false, http.StatusUnauthorized},
+			{"malformed", "/posts/1/comments/bulk", `{"content":"Hi"}`, true, http.StatusBadRequest},
+			{"empty", "/posts/1/comments/bulk", `[]`, true, http.StatusUnprocessableEntity},
+			{"unknown post", "/posts/99/comments/bulk", `[{"content":"Hi"}]`, true, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			if status := do(tt.path, tt.body, tt.admin).Code; status != tt.want {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.name, status, tt.want)
+			}
+		}
+		if comments, _, _ := store.GetComments(ctx, postID, database.CommentFilter{}); len(comments) != 3 {
+			t.Errorf("rejected requests stored comments: got %v want %v", len(comments), 3)
+		}
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		rr := do("/posts/1/comments/bulk", `[{"content":"Before"},{"content":"Spam","status":"spam"},{"content":"After"}]`, true)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", status, http.StatusOK, rr.Body.String())
+		}
+		var resp struct {
+			Results []database.BulkResult `json:"results"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		want := []string{database.BulkCreated, database.BulkRejected, database.BulkCreated}
+		if len(resp.Results) != len(want) {
+			t.Fatalf("handler returned wrong number of results: got %+v want %v", resp.Results, len(want))
+		}
+		for i, result := range resp.Results {
+			if result.Result != want[i] {
+				t.Errorf("result %d: got %+v want %q", i, result, want[i])
+			}
+		}
+		if !strings.Contains(resp.Results[1].Reason, "status must be one of") {
+			t.Errorf("handler returned unclear reason: %q", resp.Results[1].Reason)
+		}
+	})
+}
+
+func TestPromoteComment(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	ctx := context.Background()
+	postID, _ := store.CreatePost(ctx, &model.Post{Title: "Go & You", Content: "Content", AllowComments: true})
+	comment, _ := store.AddComment(ctx, postID, &model.Comment{Author: "ana", Content: "<p>A whole essay.</p>"})
+
+	promote := func(path string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeComments(rr, req)
+		return rr
+	}
+
+	rr := promote(fmt.Sprintf("/comments/%d/promote", comment.ID), true)
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", status, http.StatusCreated, rr.Body.String())
+	}
+	var created model.Post
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	if created.Status != model.StatusDraft || created.Author != "ana" || created.Title != "Re: Go & You" {
+		t.Errorf("handler returned wrong post: %+v", created)
+	}
+	want := `<p>A whole essay.</p><p>Originally a comment on <a href="/posts/1">Go &amp; You</a>.</p>`
+	if created.Content != want {
+		t.Errorf("handler returned wrong content: got %q want %q", created.Content, want)
+	}
+	if stored, err := store.GetPost(ctx, created.ID); err != nil || stored.Status != model.StatusDraft {
+		t.Errorf("draft was not stored: %+v, %v", stored, err)
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		store.AddComment(ctx, postID, &model.Comment{Author: "bo", Content: "Gone"})
+		store.DeleteComment(ctx, postID, 2)
+		tests := []struct {
+			path  string
+			admin bool
+			want  int
+		}{
+			{"/comments/99/promote", true, http.StatusNotFound},
+			{"/comments/2/promote", true, http.StatusNotFound},
+			{"/comments/abc/promote", true, http.StatusBadRequest},
+			{"/comments/1/demote", true, http.StatusNotFound},
+			{"/comments/1/promote", false, http.StatusUnauthorized},
+		}
+		for _, tt := range tests {
+			if status := promote(tt.path, tt.admin).Code; status != tt.want {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.path, status, tt.want)
+			}
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/comments/1/promote", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeComments(rr, req)
+		if status := rr.Code; status != http.StatusMethodNotAllowed {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusMethodNotAllowed)
+		}
+		if allow := rr.Header().Get("Allow"); allow != http.MethodPost {
+			t.Errorf("handler returned wrong Allow header: got %q want %q", allow, http.MethodPost)
+		}
+	})
+
+	t.Run("author limit", func(t *testing.T) {
+		limited := NewPostHandler(database.NewMemoryStore(database.WithMaxPostsPerAuthor(1)))
+		limited.AdminToken = "secret"
+		id, _ := limited.Store.CreatePost(ctx, &model.Post{Title: "By ana", Content: "Content", Author: "ana", AllowComments: true})
+		c, _ := limited.Store.AddComment(ctx, id, &model.Comment{Author: "ana", Content: "Another essay"})
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/comments/%d/promote", c.ID), nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rr := httptest.NewRecorder()
+		limited.ServeComments(rr, req)
+		if status := rr.Code; status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+		}
+	})
+}
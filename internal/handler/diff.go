@@ -0,0 +1,94 @@
+package handler
+
+// Line diff operations.
+const (
+	diffEqual  = "equal"
+	diffInsert = "insert"
+	diffDelete = "delete"
+)
+
+// maxDiffCells bounds the work diffLines does on the lines that differ. Past
+// it, the changed region is reported as wholly deleted and reinserted.
+const maxDiffCells = 4_000_000
+
+// lineDiff is one line of a content diff.
+type lineDiff struct {
+	Op   string `json:"op"`
+	Line string `json:"line"`
+}
+
+// diffLines returns a line-based diff turning a into b, built from a longest
+// common subsequence after trimming the lines the two share at either end.
+func diffLines(a, b []string) []lineDiff {
+	diff := []lineDiff{}
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		diff = append(diff, lineDiff{Op: diffEqual, Line: a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if len(x)*len(y) > maxDiffCells {
+		for _, line := range x {
+			diff = append(diff, lineDiff{Op: diffDelete, Line: line})
+		}
+		for _, line := range y {
+			diff = append(diff, lineDiff{Op: diffInsert, Line: line})
+		}
+	} else {
+		diff = append(diff, lcsDiff(x, y)...)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		diff = append(diff, lineDiff{Op: diffEqual, Line: line})
+	}
+	return diff
+}
+
+// lcsDiff diffs a and b using a dynamic-programming longest common
+// subsequence table, listing deletions before insertions at each change.
+func lcsDiff(a, b []string) []lineDiff {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []lineDiff
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, lineDiff{Op: diffEqual, Line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, lineDiff{Op: diffDelete, Line: a[i]})
+			i++
+		default:
+			diff = append(diff, lineDiff{Op: diffInsert, Line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, lineDiff{Op: diffDelete, Line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, lineDiff{Op: diffInsert, Line: b[j]})
+	}
+	return diff
+}
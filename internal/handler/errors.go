@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/database"
+)
+
+// ErrorCode is the machine-readable reason for a failed request, sent in
+// the code field of every error response so clients can branch on it
+// instead of parsing the message.
+type ErrorCode string
+
+// Error codes, grouped by status.
+const (
+	// 400 Bad Request.
+	CodeInvalidBody   ErrorCode = "INVALID_BODY"
+	CodeInvalidQuery  ErrorCode = "INVALID_QUERY"
+	CodeInvalidID     ErrorCode = "INVALID_ID"
+	CodeInvalidName   ErrorCode = "INVALID_NAME"
+	CodeInvalidParent ErrorCode = "INVALID_PARENT"
+
+	// 401 Unauthorized and 403 Forbidden.
+	CodeUnauthorized        ErrorCode = "UNAUTHORIZED"
+	CodeCommentsDisabled    ErrorCode = "COMMENTS_DISABLED"
+	CodeCommentLimitReached ErrorCode = "COMMENT_LIMIT_REACHED"
+
+	// 404 Not Found.
+	CodeNotFound         ErrorCode = "NOT_FOUND"
+	CodePostNotFound     ErrorCode = "POST_NOT_FOUND"
+	CodeCommentNotFound  ErrorCode = "COMMENT_NOT_FOUND"
+	CodeRevisionNotFound ErrorCode = "REVISION_NOT_FOUND"
+
+	// 405, 409, 412, and 415.
+	CodeMethodNotAllowed     ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeSlugConflict         ErrorCode = "SLUG_CONFLICT"
+	CodeDuplicatePost        ErrorCode = "DUPLICATE_POST"
+	CodeInvalidState         ErrorCode = "INVALID_STATE"
+	CodePreconditionFailed   ErrorCode = "PRECONDITION_FAILED"
+	CodeUnsupportedMediaType ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+
+	// 422 Unprocessable Entity, with per-field errors.
+	CodeValidationFailed ErrorCode = "VALIDATION_FAILED"
+
+	// 429 Too Many Requests.
+	CodeUpdateThrottled ErrorCode = "UPDATE_THROTTLED"
+
+	// 5xx.
+	CodeInternal  ErrorCode = "INTERNAL_ERROR"
+	CodeStoreFull ErrorCode = "STORE_FULL"
+)
+
+// errorResponse is the body of every error response. Some errors add
+// fields of their own, such as the per-field errors of a validation
+// failure.
+type errorResponse struct {
+	Code  ErrorCode `json:"code"`
+	Error string    `json:"error"`
+}
+
+// writeError responds with status and an errorResponse.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code ErrorCode, msg string) {
+	writeJSON(w, r, status, errorResponse{Code: code, Error: msg})
+}
+
+// writeNotFound responds 404 for a path that names no endpoint.
+func writeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, CodeNotFound, "Not found")
+}
+
+// NotFound is the fallback handler for paths that name no endpoint,
+// responding 404 with the JSON error envelope.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeNotFound(w, r)
+}
+
+// retryAfter returns how long to wait before retrying an update the store
+// throttled, or zero for any other error.
+func retryAfter(err error) time.Duration {
+	var throttled *database.UpdateThrottledError
+	if errors.As(err, &throttled) {
+		return throttled.RetryAfter
+	}
+	return 0
+}
+
+// writeUpdateThrottled responds 429 with a Retry-After header of wait,
+// rounded up to whole seconds.
+func writeUpdateThrottled(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeError(w, r, http.StatusTooManyRequests, CodeUpdateThrottled, "Post was updated too recently")
+}
+
+// writeMethodNotAllowed responds 405.
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+}
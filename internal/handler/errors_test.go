@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+)
+
+func TestErrorCodes(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   ErrorCode
+	}{
+		{"unknown post", http.MethodGet, "/posts/99", "", http.StatusNotFound, CodePostNotFound},
+		{"invalid post", http.MethodPost, "/posts", `{"title":"","content":""}`, http.StatusUnprocessableEntity, CodeValidationFailed},
+		{"malformed body", http.MethodPost, "/posts", `{`, http.StatusBadRequest, CodeInvalidBody},
+		{"invalid id", http.MethodGet, "/posts/abc", "", http.StatusBadRequest, CodeInvalidID},
+		{"method not allowed", http.MethodPatch, "/posts/1", "", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.status)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("wrong content type: got %q want %q", ct, "application/json")
+			}
+			var resp errorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if resp.Code != tt.code {
+				t.Errorf("wrong error code: got %q want %q", resp.Code, tt.code)
+			}
+			if resp.Error == "" {
+				t.Error("expected an error message")
+			}
+		})
+	}
+}
+
+func TestFallbackErrorsAreJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		serve  http.HandlerFunc
+		status int
+		code   ErrorCode
+	}{
+		{"unknown path", NotFound, http.StatusNotFound, CodeNotFound},
+		{"encode failure", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, r, http.StatusOK, make(chan int))
+		}, http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.serve(rr, httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+			if rr.Code != tt.status {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.status)
+			}
+			var resp errorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if resp.Code != tt.code {
+				t.Errorf("wrong error code: got %q want %q", resp.Code, tt.code)
+			}
+		})
+	}
+}
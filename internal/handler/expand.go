@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// Limits on comments embedded by ?expand=comments.
+const (
+	defaultEmbeddedComments = 3
+	maxEmbeddedComments     = 10
+)
+
+// parseExpand reads the expand and commentLimit query parameters of
+// GET /posts and returns how many comments to embed per post, or zero when
+// comments are not expanded.
+func parseExpand(query url.Values) (int, error) {
+	switch query.Get("expand") {
+	case "":
+		return 0, nil
+	case "comments":
+	default:
+		return 0, errors.New(`expand must be "comments"`)
+	}
+
+	v := query.Get("commentLimit")
+	if v == "" {
+		return defaultEmbeddedComments, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxEmbeddedComments {
+		return 0, fmt.Errorf("commentLimit must be between 1 and %d", maxEmbeddedComments)
+	}
+	return n, nil
+}
+
+// withComments returns copies of posts with up to perPost approved comments
+// embedded, loaded for the whole page in one store call.
+func (h *PostHandler) withComments(ctx context.Context, posts []*model.Post, perPost int) ([]*model.Post, error) {
+	ids := make([]int64, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	byPost, err := h.Store.CommentsForPosts(ctx, ids, perPost)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*model.Post, len(posts))
+	for i, post := range posts {
+		result := *post
+		result.Comments = byPost[post.ID]
+		results[i] = &result
+	}
+	return results, nil
+}
+
+// withThread returns a copy of post with all of its approved comments
+// embedded, replies nested under their parents.
+func (h *PostHandler) withThread(ctx context.Context, post *model.Post) (*model.Post, error) {
+	comments, _, err := h.Store.GetComments(ctx, post.ID, database.CommentFilter{Status: model.CommentApproved})
+	if err != nil {
+		return nil, err
+	}
+	result := *post
+	result.Comments = commentTree(comments)
+	return &result, nil
+}
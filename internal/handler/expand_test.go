@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestExpandComments(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	busy, _ := store.CreatePost(ctx, &model.Post{Title: "Busy", Content: "Content"})
+	quiet, _ := store.CreatePost(ctx, &model.Post{Title: "Quiet", Content: "Content"})
+	for i := 1; i <= 5; i++ {
+		comment, _ := store.AddComment(ctx, busy, &model.Comment{Content: fmt.Sprintf("Comment %d", i)})
+		store.ApproveComment(ctx, busy, comment.ID)
+	}
+	store.AddComment(ctx, quiet, &model.Comment{Content: "Pending"})
+
+	get := func(query string) ([]model.Post, int) {
+		req := httptest.NewRequest(http.MethodGet, "/posts"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		var posts []model.Post
+		json.Unmarshal(rr.Body.Bytes(), &posts)
+		return posts, rr.Code
+	}
+
+	t.Run("default", func(t *testing.T) {
+		posts, status := get("?expand=comments")
+		if status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if len(posts) != 2 {
+			t.Fatalf("handler returned wrong number of posts: got %v want %v", len(posts), 2)
+		}
+		if got := posts[0].Comments; len(got) != defaultEmbeddedComments || got[0].Content != "Comment 1" {
+			t.Errorf("busy post embedded %d comments starting %+v, want %d from Comment 1", len(got), got, defaultEmbeddedComments)
+		}
+		if got := posts[1].Comments; len(got) != 0 {
+			t.Errorf("quiet post embedded %d comments, want none approved", len(got))
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		posts, _ := get("?expand=comments&commentLimit=5")
+		if len(posts) == 0 || len(posts[0].Comments) != 5 {
+			t.Errorf("handler embedded wrong number of comments: got %+v want 5", posts)
+		}
+	})
+
+	t.Run("not expanded", func(t *testing.T) {
+		posts, _ := get("")
+		if len(posts) == 0 || posts[0].Comments != nil {
+			t.Errorf("handler embedded comments without expand: %+v", posts)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, query := range []string{"?expand=tags", "?expand=comments&commentLimit=0", "?expand=comments&commentLimit=11"} {
+			if _, status := get(query); status != http.StatusBadRequest {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", query, status, http.StatusBadRequest)
+			}
+		}
+	})
+}
+
+func TestGetPostExpandComments(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Threaded", Content: "Content"})
+	parent, _ := store.AddComment(ctx, id, &model.Comment{Content: "Parent"})
+	store.ApproveComment(ctx, id, parent.ID)
+	reply, _ := store.AddComment(ctx, id, &model.Comment{Content: "Reply", ParentID: &parent.ID})
+	store.ApproveComment(ctx, id, reply.ID)
+	store.AddComment(ctx, id, &model.Comment{Content: "Pending"})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get(fmt.Sprintf("/posts/%d?expand=comments", id))
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var post model.Post
+	json.Unmarshal(rr.Body.Bytes(), &post)
+	if len(post.Comments) != 1 || post.Comments[0].Content != "Parent" ||
+		len(post.Comments[0].Replies) != 1 || post.Comments[0].Replies[0].Content != "Reply" {
+		t.Errorf("handler embedded wrong comments: %s", rr.Body.String())
+	}
+
+	if status := get("/posts/999?expand=comments").Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+	if status := get(fmt.Sprintf("/posts/%d?expand=tags", id)).Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+	if body := get(fmt.Sprintf("/posts/%d", id)).Body.String(); strings.Contains(body, `"comments"`) {
+		t.Errorf("unexpanded post embedded comments: %s", body)
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import "net/http"
+
+// Export handles GET /export, streaming the published posts as
+// newline-delimited JSON for migration or backup. It accepts the same
+// filters as GET /posts, so a subset such as one category can be exported.
+func (h *PostHandler) Export(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	filter, err := h.postFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	posts, _, err := h.Store.GetAllPosts(r.Context(), filter)
+	if err != nil {
+		h.serverError(w, r, "Failed to export posts", err)
+		return
+	}
+
+	setDownloadHeaders(w, "application/x-ndjson", "posts.ndjson")
+	h.streamNDJSON(w, r, posts)
+}
+
+// setDownloadHeaders marks a response as a file download of contentType.
+// It sets nosniff itself rather than relying on middleware, so a browser
+// never renders an export as HTML even when the handler is mounted alone.
+func setDownloadHeaders(w http.ResponseWriter, contentType, filename string) {
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	h.Set("X-Content-Type-Options", "nosniff")
+}
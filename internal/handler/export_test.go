@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestExport(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	for _, category := range []string{"tech", "travel", "Tech", "food", "tech"} {
+		store.CreatePost(ctx, &model.Post{Title: "Post", Content: "Content", Category: category})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/export?category=tech", nil)
+	rr := httptest.NewRecorder()
+	handler.Export(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":           "application/x-ndjson",
+		"Content-Disposition":    `attachment; filename="posts.ndjson"`,
+		"X-Content-Type-Options": "nosniff",
+	} {
+		if got := rr.Header().Get(header); got != want {
+			t.Errorf("handler returned wrong %s: got %v want %v", header, got, want)
+		}
+	}
+
+	records := 0
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var post model.Post
+		if err := json.Unmarshal(scanner.Bytes(), &post); err != nil {
+			t.Fatalf("line %d is not a post: %v", records+1, err)
+		}
+		records++
+	}
+	if records != 3 {
+		t.Errorf("export has wrong number of records: got %v want %v", records, 3)
+	}
+}
+
+func TestExportWXR(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	store.CreatePost(ctx, &model.Post{Title: "Go tips", Content: "Use <b>gofmt</b> ]]> always.", Category: "Tech", Tags: []string{"go", "tools"}, Author: "ana"})
+	store.CreatePost(ctx, &model.Post{Title: "Lisbon", Content: "Trams.", Category: "Travel", Tags: []string{"go"}})
+	store.CreatePost(ctx, &model.Post{Title: "Unfinished", Content: "Draft.", Status: model.StatusDraft})
+
+	req := httptest.NewRequest(http.MethodGet, "/export/wxr", nil)
+	rr := httptest.NewRecorder()
+	handler.ExportWXR(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/rss+xml") {
+		t.Errorf("handler returned wrong Content-Type: got %v want application/rss+xml", ct)
+	}
+
+	body := rr.Body.String()
+	for _, want := range []string{
+		`xmlns:wp="http://wordpress.org/export/1.2/"`,
+		`xmlns:content="http://purl.org/rss/1.0/modules/content/"`,
+		`xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"`,
+		`xmlns:dc="http://purl.org/dc/elements/1.1/"`,
+		`<content:encoded><![CDATA[Use <b>gofmt</b> ]]]]><![CDATA[> always.]]></content:encoded>`,
+		`<category domain="post_tag" nicename="go"><![CDATA[go]]></category>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("export does not contain %s", want)
+		}
+	}
+
+	var doc struct {
+		Channel struct {
+			Items []struct {
+				Title   string `xml:"title"`
+				Content string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			} `xml:"item"`
+			Tags []struct {
+				Slug string `xml:"http://wordpress.org/export/1.2/ tag_slug"`
+			} `xml:"http://wordpress.org/export/1.2/ tag"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("export is not valid XML: %v", err)
+	}
+	if got := len(doc.Channel.Items); got != 2 {
+		t.Fatalf("wrong number of items: got %v want %v", got, 2)
+	}
+	for _, item := range doc.Channel.Items {
+		if want := "Use <b>gofmt</b> ]]> always."; item.Title == "Go tips" && item.Content != want {
+			t.Errorf("content was not preserved: got %q want %q", item.Content, want)
+		}
+	}
+	if got := len(doc.Channel.Tags); got != 2 {
+		t.Errorf("wrong number of tags: got %v want %v", got, 2)
+	}
+}
+
+const wxrFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>My WordPress blog</title>
+	<wp:wxr_version>1.2</wp:wxr_version>
+	<item>
+		<title>Hello world</title>
+		<pubDate>Mon, 04 Mar 2019 10:30:00 +0000</pubDate>
+		<dc:creator><![CDATA[ana]]></dc:creator>
+		<content:encoded><![CDATA[<p>Welcome to WordPress.</p>]]></content:encoded>
+		<wp:post_date_gmt>2019-03-04 10:30:00</wp:post_date_gmt>
+		<wp:post_name>hello-world</wp:post_name>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+		<category domain="category" nicename="news"><![CDATA[News]]></category>
+		<category domain="post_tag" nicename="intro"><![CDATA[intro]]></category>
+		<category domain="post_tag" nicename="meta"><![CDATA[meta]]></category>
+	</item>
+	<item>
+		<title>About</title>
+		<content:encoded><![CDATA[About me.]]></content:encoded>
+		<wp:status>publish</wp:status>
+		<wp:post_type>page</wp:post_type>
+	</item>
+	<item>
+		<title>header.png</title>
+		<wp:post_type>attachment</wp:post_type>
+	</item>
+	<item>
+		<title>Half written</title>
+		<content:encoded><![CDATA[Notes.]]></content:encoded>
+		<wp:post_date_gmt>0000-00-00 00:00:00</wp:post_date_gmt>
+		<wp:status>draft</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title></title>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+</channel>
+</rss>`
+
+func TestImportWXR(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	ctx := context.Background()
+
+	importFile := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/import/wxr", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/xml")
+		req.Header.Set("Authorization", "Bearer secret")
+		rr := httptest.NewRecorder()
+		handler.ImportWXR(rr, req)
+		return rr
+	}
+
+	rr := importFile(wxrFixture)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var resp struct {
+		Created int `json:"created"`
+		Results []struct {
+			Item   int    `json:"item"`
+			Result string `json:"result"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if resp.Created != 2 {
+		t.Errorf("wrong number of posts created: got %v want %v", resp.Created, 2)
+	}
+	wantResults := []string{database.BulkCreated, database.BulkSkipped, database.BulkSkipped, database.BulkCreated, database.BulkRejected}
+	if len(resp.Results) != len(wantResults) {
+		t.Fatalf("wrong number of results: got %v want %v", len(resp.Results), len(wantResults))
+	}
+	for i, want := range wantResults {
+		if got := resp.Results[i].Result; got != want {
+			t.Errorf("item %d: wrong result: got %q want %q", i+1, got, want)
+		}
+	}
+
+	post, err := store.GetPost(ctx, 1)
+	if err != nil {
+		t.Fatalf("imported post not found: %v", err)
+	}
+	published := time.Date(2019, 3, 4, 10, 30, 0, 0, time.UTC)
+	switch {
+	case post.Title != "Hello world", post.Slug != "hello-world", post.Author != "ana":
+		t.Errorf("wrong post fields: %+v", post)
+	case post.Category != "News", !reflect.DeepEqual(post.Tags, []string{"intro", "meta"}):
+		t.Errorf("wrong taxonomy: category %q, tags %v", post.Category, post.Tags)
+	case post.Status != model.StatusPublished, post.PublishedAt == nil || !post.PublishedAt.Equal(published):
+		t.Errorf("wrong publication: status %q, publishedAt %v", post.Status, post.PublishedAt)
+	}
+	if draft, err := store.GetPost(ctx, 2); err != nil || draft.Status != model.StatusDraft {
+		t.Errorf("draft item was not imported as a draft: %+v, %v", draft, err)
+	}
+
+	t.Run("imported again", func(t *testing.T) {
+		rr := importFile(wxrFixture)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if !strings.Contains(rr.Body.String(), `"created":1`) {
+			t.Errorf("expected only the slugless draft to be created again: %s", rr.Body.String())
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, body := range []string{"<rss><channel><item>", "not xml", `<feed xmlns="http://www.w3.org/2005/Atom"></feed>`} {
+			if rr := importFile(body); rr.Code != http.StatusBadRequest {
+				t.Errorf("%q: handler returned wrong status code: got %v want %v", body, rr.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
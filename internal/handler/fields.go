@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// postFields is the set of JSON field names of a post that ?fields may
+// select.
+var postFields = jsonFieldNames(reflect.TypeOf(model.Post{}))
+
+// jsonFieldNames returns the JSON names of a struct type's exported fields.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// parseFields reads the fields query parameter, a comma-separated list of
+// post fields to return. It returns nil when the parameter is absent, so
+// that responses carry every field.
+func parseFields(query url.Values) ([]string, error) {
+	if !query.Has("fields") {
+		return nil, nil
+	}
+	var fields []string
+	for _, name := range strings.Split(query.Get("fields"), ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !postFields[name] {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		fields = append(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("fields must list at least one field")
+	}
+	return fields, nil
+}
+
+// project encodes a post or a list of posts keeping only the listed fields,
+// in name order. Fields a post omits when empty stay omitted. With no fields
+// it returns v unchanged.
+func project(v interface{}, fields []string) (interface{}, error) {
+	if fields == nil {
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := func(post map[string]json.RawMessage) map[string]json.RawMessage {
+		projected := make(map[string]json.RawMessage, len(fields))
+		for _, name := range fields {
+			if value, ok := post[name]; ok {
+				projected[name] = value
+			}
+		}
+		return projected
+	}
+
+	if _, ok := v.([]*model.Post); ok {
+		var posts []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &posts); err != nil {
+			return nil, err
+		}
+		for i, post := range posts {
+			posts[i] = keep(post)
+		}
+		return posts, nil
+	}
+	var post map[string]json.RawMessage
+	if err := json.Unmarshal(data, &post); err != nil {
+		return nil, err
+	}
+	return keep(post), nil
+}
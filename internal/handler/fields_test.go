@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestFieldProjection(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	store.CreatePost(ctx, &model.Post{Title: "First", Content: "Content", Tags: []string{"go"}})
+	store.CreatePost(ctx, &model.Post{Title: "Second", Content: "Content"})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	keys := func(object map[string]json.RawMessage) []string {
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	want := []string{"createdAt", "id", "title"}
+
+	t.Run("list", func(t *testing.T) {
+		rr := get("/posts?fields=id,title,createdAt")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var posts []map[string]json.RawMessage
+		if err := json.Unmarshal(rr.Body.Bytes(), &posts); err != nil {
+			t.Fatalf("could not decode response: %v", err)
+		}
+		if len(posts) != 2 {
+			t.Fatalf("handler returned wrong number of posts: got %v want %v", len(posts), 2)
+		}
+		for _, post := range posts {
+			if got := keys(post); !reflect.DeepEqual(got, want) {
+				t.Errorf("handler returned wrong fields: got %v want %v", got, want)
+			}
+		}
+		if string(posts[1]["title"]) != `"Second"` {
+			t.Errorf("handler returned wrong title: got %s want %q", posts[1]["title"], "Second")
+		}
+	})
+
+	t.Run("single", func(t *testing.T) {
+		rr := get("/posts/1?fields=title,id,createdAt")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var post map[string]json.RawMessage
+		json.Unmarshal(rr.Body.Bytes(), &post)
+		if got := keys(post); !reflect.DeepEqual(got, want) {
+			t.Errorf("handler returned wrong fields: got %v want %v", got, want)
+		}
+	})
+
+	t.Run("ETag", func(t *testing.T) {
+		full := get("/posts/1").Header().Get("ETag")
+		projected := get("/posts/1?fields=title,id").Header().Get("ETag")
+		if projected == full || !strings.HasPrefix(projected, "W/") {
+			t.Errorf("handler returned ETag %s for a projection of %s, want a distinct weak one", projected, full)
+		}
+		if other := get("/posts/1?fields=id,title,title").Header().Get("ETag"); other != projected {
+			t.Errorf("handler returned ETag %s for the same fields, want %s", other, projected)
+		}
+		if other := get("/posts/1?fields=id").Header().Get("ETag"); other == projected {
+			t.Errorf("handler returned the same ETag %s for different fields", other)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/posts/1?fields=id", nil)
+		req.Header.Set("If-None-Match", full)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code for the full post's ETag: got %v want %v", status, http.StatusOK)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, path := range []string{
+			"/posts?fields=id,password",
+			"/posts?fields=,",
+			"/posts?fields=id&format=ndjson",
+			"/posts/1?fields=secret",
+		} {
+			if status := get(path).Code; status != http.StatusBadRequest {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", path, status, http.StatusBadRequest)
+			}
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gemini/go-blog-api/internal/database"
+)
+
+// maxPageSize caps the limit query parameter.
+const maxPageSize = 100
+
+// maxSinceDays caps the sinceDays query parameter.
+const maxSinceDays = 366
+
+// DefaultMaxQueryFilters is the default value of PostHandler.MaxQueryFilters.
+const DefaultMaxQueryFilters = 8
+
+// filterParams are the GET /posts query parameters that narrow the results
+// and count towards MaxQueryFilters. Sorting and pagination don't.
+var filterParams = []string{
+	"term", "includeComments", "author", "category", "tag", "uncategorized",
+	"hasImage", "linksTo", "from", "to", "sinceDays", "staleBefore", "idFrom", "idTo",
+}
+
+// postFilter builds a PostFilter from the query parameters of GET /posts.
+func (h *PostHandler) postFilter(query url.Values) (database.PostFilter, error) {
+	var filter database.PostFilter
+
+	if h.MaxQueryFilters > 0 {
+		n := 0
+		for _, param := range filterParams {
+			if query.Get(param) != "" {
+				n++
+			}
+		}
+		if n > h.MaxQueryFilters {
+			return filter, fmt.Errorf("at most %d filters may be combined, got %d", h.MaxQueryFilters, n)
+		}
+	}
+
+	filter.Term = strings.TrimSpace(query.Get("term"))
+	if filter.Term != "" && utf8.RuneCountInString(filter.Term) < h.MinSearchTermLength {
+		return filter, fmt.Errorf("search term must be at least %d characters", h.MinSearchTermLength)
+	}
+	filter.IncludeComments = query.Get("includeComments") == "true"
+
+	if query.Has("author") {
+		for _, author := range strings.Split(query.Get("author"), ",") {
+			if author = strings.TrimSpace(author); author != "" {
+				filter.Authors = append(filter.Authors, author)
+			}
+		}
+		if len(filter.Authors) == 0 {
+			return filter, errors.New("author must list at least one name")
+		}
+	}
+
+	filter.Category = strings.TrimSpace(query.Get("category"))
+	filter.Tag = strings.TrimSpace(query.Get("tag"))
+	filter.Uncategorized = query.Get("uncategorized") == "true"
+	filter.HasImage = query.Get("hasImage") == "true"
+	filter.LinksTo = strings.TrimSpace(query.Get("linksTo"))
+
+	var err error
+	if filter.CreatedAfter, err = parseDate(query.Get("from"), false); err != nil {
+		return filter, errors.New("from must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+	}
+	if filter.CreatedBefore, err = parseDate(query.Get("to"), true); err != nil {
+		return filter, errors.New("to must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+	}
+	// sinceDays is shorthand for a from date N days ago; the later of the
+	// two applies when both are given.
+	if v := query.Get("sinceDays"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 || days > maxSinceDays {
+			return filter, fmt.Errorf("sinceDays must be an integer between 1 and %d", maxSinceDays)
+		}
+		if cutoff := time.Now().AddDate(0, 0, -days); cutoff.After(filter.CreatedAfter) {
+			filter.CreatedAfter = cutoff
+		}
+	}
+	if filter.UpdatedBefore, err = parseDate(query.Get("staleBefore"), false); err != nil {
+		return filter, errors.New("staleBefore must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+	}
+
+	if filter.IDFrom, err = h.parseIDBound(query.Get("idFrom")); err != nil {
+		return filter, errors.New("idFrom must be a post ID")
+	}
+	if filter.IDTo, err = h.parseIDBound(query.Get("idTo")); err != nil {
+		return filter, errors.New("idTo must be a post ID")
+	}
+	if filter.IDFrom > 0 && filter.IDTo > 0 && filter.IDFrom > filter.IDTo {
+		return filter, errors.New("idFrom must not be greater than idTo")
+	}
+
+	switch sort := query.Get("sort"); sort {
+	case "", database.SortNewest, database.SortOldest, database.SortUpdated, database.SortHome, database.SortRelevance:
+		filter.Sort = sort
+	default:
+		return filter, fmt.Errorf("sort must be one of %q, %q, %q, %q, or %q", database.SortNewest, database.SortOldest, database.SortUpdated, database.SortHome, database.SortRelevance)
+	}
+
+	if filter.Limit, filter.Offset, err = parsePagination(query); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseDate parses a date or RFC 3339 timestamp. A bare date used as an upper
+// bound is moved to the following midnight so that the whole day is included.
+func parseDate(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// parseIDBound parses an optional ID range bound with parseID. An empty
+// string yields zero, meaning unbounded.
+func (h *PostHandler) parseIDBound(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return h.parseID(s)
+}
+
+// parseTopLimit reads the limit query parameter of a ranked listing,
+// defaulting to def and capping it at max.
+func parseTopLimit(query url.Values, def, max int) (int, error) {
+	v := query.Get("limit")
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	return min(n, max), nil
+}
+
+// parsePagination reads the limit and offset query parameters. A zero limit
+// means no limit was requested.
+func parsePagination(query url.Values) (limit, offset int, err error) {
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a non-negative integer")
+		}
+	}
+	return limit, offset, nil
+}
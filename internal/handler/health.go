@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/database"
+)
+
+// Health statuses reported by the store check.
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+)
+
+// DefaultStoreLatencyThreshold is the default value of
+// HealthHandler.StoreLatencyThreshold.
+const DefaultStoreLatencyThreshold = 500 * time.Millisecond
+
+// HealthHandler serves the health check endpoint.
+type HealthHandler struct {
+	Store     database.Store
+	StartedAt time.Time
+	// StoreLatencyThreshold is the store check latency above which the
+	// service reports itself degraded.
+	StoreLatencyThreshold time.Duration
+}
+
+// NewHealthHandler creates a new HealthHandler reporting uptime since startedAt.
+func NewHealthHandler(s database.Store, startedAt time.Time) *HealthHandler {
+	return &HealthHandler{Store: s, StartedAt: startedAt, StoreLatencyThreshold: DefaultStoreLatencyThreshold}
+}
+
+// ServeHTTP handles GET /health. The default response is a minimal status;
+// passing ?verbose=true adds store diagnostics, and ?checks=store times a
+// store round trip.
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{"status": healthOK}
+	status := http.StatusOK
+
+	if r.URL.Query().Get("checks") == "store" {
+		start := time.Now()
+		_, err := h.Store.CountPosts(r.Context())
+		latency := time.Since(start)
+
+		data["storeLatencyMs"] = float64(latency.Microseconds()) / 1000
+		switch {
+		case err != nil:
+			data["status"] = healthDown
+			status = http.StatusServiceUnavailable
+		case latency > h.StoreLatencyThreshold:
+			data["status"] = healthDegraded
+		}
+	}
+
+	if r.URL.Query().Get("verbose") == "true" {
+		count, err := h.Store.CountPosts(r.Context())
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to count posts")
+			return
+		}
+		data["postCount"] = count
+		data["uptime"] = time.Since(h.StartedAt).Round(time.Second).String()
+		data["store"] = strings.TrimPrefix(fmt.Sprintf("%T", h.Store), "*")
+	}
+
+	writeJSON(w, r, status, data)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestHealthHandler(t *testing.T) {
+	store := database.NewMemoryStore()
+	store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+	handler := NewHealthHandler(store, time.Now().Add(-time.Minute))
+
+	get := func(target string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var data map[string]interface{}
+		json.Unmarshal(rr.Body.Bytes(), &data)
+		return data
+	}
+
+	t.Run("default", func(t *testing.T) {
+		data := get("/health")
+		if len(data) != 1 || data["status"] != "ok" {
+			t.Errorf("handler returned unexpected body: got %v want only status ok", data)
+		}
+	})
+
+	t.Run("verbose", func(t *testing.T) {
+		data := get("/health?verbose=true")
+		if data["postCount"] != float64(1) {
+			t.Errorf("handler returned wrong postCount: got %v want %v", data["postCount"], 1)
+		}
+		if data["uptime"] != "1m0s" {
+			t.Errorf("handler returned wrong uptime: got %v want %v", data["uptime"], "1m0s")
+		}
+		if data["store"] != "database.MemoryStore" {
+			t.Errorf("handler returned wrong store: got %v want %v", data["store"], "database.MemoryStore")
+		}
+	})
+}
+
+// slowStore delays CountPosts to simulate a slow backend.
+type slowStore struct {
+	database.Store
+	delay time.Duration
+	err   error
+}
+
+func (s *slowStore) CountPosts(ctx context.Context) (int, error) {
+	time.Sleep(s.delay)
+	return 0, s.err
+}
+
+func TestHealthStoreCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      *slowStore
+		wantStatus string
+		wantCode   int
+	}{
+		{"fast", &slowStore{}, "ok", http.StatusOK},
+		{"slow", &slowStore{delay: 20 * time.Millisecond}, "degraded", http.StatusOK},
+		{"failing", &slowStore{err: errors.New("connection refused")}, "down", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHealthHandler(tt.store, time.Now())
+			handler.StoreLatencyThreshold = 10 * time.Millisecond
+
+			req := httptest.NewRequest(http.MethodGet, "/health?checks=store", nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.wantCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.wantCode)
+			}
+			var data map[string]interface{}
+			json.Unmarshal(rr.Body.Bytes(), &data)
+			if data["status"] != tt.wantStatus {
+				t.Errorf("handler returned wrong status: got %v want %v", data["status"], tt.wantStatus)
+			}
+			if _, ok := data["storeLatencyMs"].(float64); !ok {
+				t.Errorf("handler returned no storeLatencyMs: %v", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// languageRange is a single entry of an Accept-Language header.
+type languageRange struct {
+	tag     string
+	quality float64
+}
+
+// parseAcceptLanguage parses an Accept-Language header into language ranges
+// ordered by descending quality. Ranges with a quality of zero are dropped.
+func parseAcceptLanguage(header string) []languageRange {
+	var ranges []languageRange
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag, params, _ := strings.Cut(part, ";")
+		lr := languageRange{tag: strings.ToLower(strings.TrimSpace(tag)), quality: 1}
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(key) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+			lr.quality = q
+		}
+
+		if lr.tag != "" && lr.quality > 0 {
+			ranges = append(ranges, lr)
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+	return ranges
+}
+
+// localizePost returns the post using the translation that best matches the
+// Accept-Language header, along with the language that was selected. When no
+// translation matches, the post is returned unchanged with an empty language.
+func localizePost(post *model.Post, acceptLanguage string) (*model.Post, string) {
+	if len(post.Translations) == 0 || acceptLanguage == "" {
+		return post, ""
+	}
+
+	// Index translations by lowercase tag so matching is case-insensitive.
+	available := make(map[string]string, len(post.Translations))
+	for lang := range post.Translations {
+		available[strings.ToLower(lang)] = lang
+	}
+
+	for _, lr := range parseAcceptLanguage(acceptLanguage) {
+		if lr.tag == "*" {
+			// Any language is acceptable, so the default fields will do.
+			return post, ""
+		}
+
+		lang, ok := available[lr.tag]
+		if !ok {
+			// Fall back from a regional tag like "fr-ca" to its primary "fr".
+			primary, _, _ := strings.Cut(lr.tag, "-")
+			lang, ok = available[primary]
+		}
+		if !ok {
+			continue
+		}
+
+		translation := post.Translations[lang]
+		localized := *post
+		if translation.Title != "" {
+			localized.Title = translation.Title
+		}
+		if translation.Content != "" {
+			localized.Content = translation.Content
+		}
+		return &localized, lang
+	}
+
+	return post, ""
+}
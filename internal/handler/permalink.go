@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// siteURL returns the base of the public links to posts: BaseURL when it is
+// set, otherwise the scheme and host the request was made to.
+func (h *PostHandler) siteURL(r *http.Request) string {
+	if h.BaseURL != "" {
+		return strings.TrimSuffix(h.BaseURL, "/")
+	}
+	return baseURL(r)
+}
+
+// permalink returns a post's canonical URL under base, as siteURL returns
+// it: its slug when it has one, and otherwise its ID as it appears in URLs.
+// A slug that would be read as an ID, such as "2024", falls back to the ID
+// too. Feeds and exports link posts through it.
+func (h *PostHandler) permalink(base string, post *model.Post) string {
+	if _, err := h.parseID(post.Slug); post.Slug != "" && err != nil {
+		return base + "/posts/" + post.Slug
+	}
+	return base + "/posts/" + h.formatID(post.ID)
+}
+
+// Permalink handles GET /posts/{id}/permalink, returning the post's
+// canonical URL for sharing.
+func (h *PostHandler) Permalink(w http.ResponseWriter, r *http.Request, id int64) {
+	post, err := h.Store.GetPost(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to get post", err)
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, map[string]string{"url": h.permalink(h.siteURL(r), post)})
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestPermalink(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	store.CreatePost(context.Background(), &model.Post{Title: "My First Post", Content: "Content"})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	url := func(rr *httptest.ResponseRecorder) string {
+		var resp map[string]string
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return resp["url"]
+	}
+
+	t.Run("slug", func(t *testing.T) {
+		rr := get("/posts/1/permalink")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		got := url(rr)
+		if want := "http://example.com/posts/my-first-post"; got != want {
+			t.Errorf("handler returned wrong url: got %q want %q", got, want)
+		}
+
+		// The permalink resolves to the post.
+		rr = get(strings.TrimPrefix(got, "http://example.com"))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("permalink returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var post model.Post
+		json.Unmarshal(rr.Body.Bytes(), &post)
+		if post.ID != 1 {
+			t.Errorf("permalink returned wrong post: got ID %v want %v", post.ID, 1)
+		}
+
+		handler.BaseURL = "https://blog.example.com/"
+		defer func() { handler.BaseURL = "" }()
+		if got, want := url(get("/posts/1/permalink")), "https://blog.example.com/posts/my-first-post"; got != want {
+			t.Errorf("handler returned wrong url with BaseURL: got %q want %q", got, want)
+		}
+	})
+
+	t.Run("id fallback", func(t *testing.T) {
+		if got, want := handler.permalink("https://blog.example.com", &model.Post{ID: 7}), "https://blog.example.com/posts/7"; got != want {
+			t.Errorf("permalink() = %q, want %q", got, want)
+		}
+		// A slug that reads as an ID would resolve to another post.
+		if got, want := handler.permalink("https://blog.example.com", &model.Post{ID: 7, Slug: "2024"}), "https://blog.example.com/posts/7"; got != want {
+			t.Errorf("permalink() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("slug routing", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			method string
+			path   string
+			want   int
+		}{
+			{"unknown slug", http.MethodGet, "/posts/no-such-post", http.StatusBadRequest},
+			{"not a slug", http.MethodGet, "/posts/Not_A_Slug", http.StatusBadRequest},
+			{"only GET", http.MethodDelete, "/posts/my-first-post", http.StatusBadRequest},
+			{"only the post itself", http.MethodGet, "/posts/my-first-post/permalink", http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if status := rr.Code; status != tt.want {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.name, status, tt.want)
+			}
+		}
+	})
+
+	t.Run("missing post", func(t *testing.T) {
+		if status := get("/posts/99/permalink").Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+}
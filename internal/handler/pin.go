@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// pinRequest is the body of POST /posts/{id}/pin.
+type pinRequest struct {
+	Position *int `json:"position"`
+}
+
+// PinPost handles POST /posts/{id}/pin, pinning the post at the requested
+// 1-based position.
+func (h *PostHandler) PinPost(w http.ResponseWriter, r *http.Request, id int64) {
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	var req pinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	var errs model.ValidationErrors
+	switch {
+	case req.Position == nil:
+		errs.Add("position", "required")
+	case *req.Position < 1:
+		errs.Add("position", "must be at least 1")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	post, err := h.Store.PinPost(r.Context(), id, *req.Position)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to pin post", err)
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, post)
+}
+
+// UnpinPost handles POST /posts/{id}/unpin
+func (h *PostHandler) UnpinPost(w http.ResponseWriter, r *http.Request, id int64) {
+	post, err := h.Store.UnpinPost(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotPinned):
+			writeError(w, r, http.StatusConflict, CodeInvalidState, err.Error())
+		case errors.Is(err, database.ErrPostNotFound):
+			h.notFound(w, r, err)
+		default:
+			h.serverError(w, r, "Failed to unpin post", err)
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, post)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestPinPosts(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	var ids []int64
+	for i := 1; i <= 5; i++ {
+		id, _ := store.CreatePost(context.Background(), &model.Post{Title: fmt.Sprintf("Post %d", i), Content: "Content"})
+		ids = append(ids, id)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	pin := func(id int64, position int) *httptest.ResponseRecorder {
+		return do(http.MethodPost, fmt.Sprintf("/posts/%d/pin", id), fmt.Sprintf(`{"position":%d}`, position))
+	}
+	listIDs := func() []int64 {
+		var posts []model.Post
+		json.Unmarshal(do(http.MethodGet, "/posts", "").Body.Bytes(), &posts)
+		got := make([]int64, len(posts))
+		for i, post := range posts {
+			got[i] = post.ID
+		}
+		return got
+	}
+
+	// Pin 4 first, then 2 ahead of it, then 5 at the end.
+	for _, p := range []struct {
+		id       int64
+		position int
+	}{{ids[3], 1}, {ids[1], 1}, {ids[4], 10}} {
+		if rr := pin(p.id, p.position); rr.Code != http.StatusOK {
+			t.Fatalf("pin %d: handler returned wrong status code: got %v want %v", p.id, rr.Code, http.StatusOK)
+		}
+	}
+
+	want := fmt.Sprint([]int64{ids[1], ids[3], ids[4], ids[0], ids[2]})
+	if got := fmt.Sprint(listIDs()); got != want {
+		t.Errorf("handler returned wrong order: got %v want %v", got, want)
+	}
+
+	t.Run("unpin closes the gap", func(t *testing.T) {
+		rr := do(http.MethodPost, fmt.Sprintf("/posts/%d/unpin", ids[1]), "")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		want := fmt.Sprint([]int64{ids[3], ids[4], ids[0], ids[1], ids[2]})
+		if got := fmt.Sprint(listIDs()); got != want {
+			t.Errorf("handler returned wrong order: got %v want %v", got, want)
+		}
+		post, _ := store.GetPost(context.Background(), ids[4])
+		if post.PinOrder == nil || *post.PinOrder != 2 {
+			t.Errorf("pin order after unpin = %v, want 2", post.PinOrder)
+		}
+
+		if status := do(http.MethodPost, fmt.Sprintf("/posts/%d/unpin", ids[1]), "").Code; status != http.StatusConflict {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"position":0}`} {
+			rr := do(http.MethodPost, fmt.Sprintf("/posts/%d/pin", ids[0]), body)
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", body, status, http.StatusUnprocessableEntity)
+			}
+		}
+		if status := pin(999, 1).Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+}
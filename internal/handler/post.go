@@ -1,169 +1,1191 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
-	// "errors"
-	"fmt"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/middleware"
 	"github.com/gemini/go-blog-api/internal/model"
+	"github.com/gemini/go-blog-api/internal/sanitize"
+	"github.com/gemini/go-blog-api/internal/webhook"
 )
 
 // PostHandler handles HTTP requests for blog posts.
 type PostHandler struct {
 	Store database.Store
+	// Sanitizer strips disallowed HTML from post content before it is stored.
+	Sanitizer *sanitize.Policy
+	// AllowedCategories restricts post categories when non-empty.
+	AllowedCategories []string
+	// DefaultCategory is given to new posts whose category is blank. Posts
+	// keep an empty category when it is empty.
+	DefaultCategory string
+	// MinSearchTermLength is the shortest search term, in characters, that
+	// GetAllPosts accepts. An empty term is always allowed.
+	MinSearchTermLength int
+	// MaxQueryFilters caps how many filter parameters one GET /posts
+	// request may combine. Zero means unlimited.
+	MaxQueryFilters int
+	// AdminToken is the bearer token that grants admin access. Admin-only
+	// endpoints are unavailable when it is empty.
+	AdminToken string
+	// AllowReset enables POST /admin/reset, which wipes the store. It is
+	// meant for tests and demos; the endpoint is 404 while it is unset.
+	AllowReset bool
+	// RequireJSONContentType rejects write requests whose body is not
+	// declared as application/json with 415 Unsupported Media Type.
+	RequireJSONContentType bool
+	// StrictText rejects posts whose text fields hold invalid UTF-8, NUL
+	// bytes, or control characters other than tab, newline, and carriage
+	// return with 422 Unprocessable Entity.
+	StrictText bool
+	// MinContentLength is the shortest post content, in characters, that
+	// GET /posts/schema advertises. The store enforces it, so set it to the
+	// store's database.WithMinContentLength.
+	MinContentLength int
+	// MaxCommentLength is the longest comment, in characters, that
+	// AddComment accepts. Zero means unlimited.
+	MaxCommentLength int
+	// CommentPageSize is how many comments GET /posts/{id}/comments
+	// returns when the request sets no limit. Zero returns them all.
+	CommentPageSize int
+	// ReviewMinWords and ReviewStaleAfter are the thresholds of GET
+	// /posts/review-queue: posts shorter than ReviewMinWords words or not
+	// updated for ReviewStaleAfter are flagged. Zero disables either rule.
+	ReviewMinWords   int
+	ReviewStaleAfter time.Duration
+	// AutoTagCount is the most tags POST /posts?autoTag=true extracts
+	// from the content of a post created without tags, and
+	// AutoTagStopwords the words it never picks. Nil stopwords use a
+	// built-in list of common English words.
+	AutoTagCount     int
+	AutoTagStopwords []string
+	// BaseURL, such as "https://blog.example.com", is the base of the
+	// permalinks in responses and feeds. When empty, links use the scheme
+	// and host of the request.
+	BaseURL string
+	// Logger records store failures behind 500 responses.
+	Logger *slog.Logger
+	// IDCodec, when set, replaces integer post IDs in URLs with its opaque
+	// tokens. Set the same codec with model.SetIDCodec so that responses
+	// use the tokens too.
+	IDCodec model.IDCodec
+	// EmptyListNoContent answers post listings that match nothing with 204
+	// No Content instead of 200 and an empty array.
+	EmptyListNoContent bool
+	// IDsAsStrings writes integer IDs in responses as strings unless the
+	// request sets ?idsAsStrings=false.
+	IDsAsStrings bool
+	// Webhook is told about created, updated, deleted, and published posts.
+	// Nil disables webhooks.
+	Webhook *webhook.Notifier
+	// Draining is closed when the server begins shutting down. Streaming
+	// responses stop at the next item once it is, so shutdown does not wait
+	// for them. Nil never closes.
+	Draining <-chan struct{}
 }
 
+// DefaultMinSearchTermLength is the default value of MinSearchTermLength.
+const DefaultMinSearchTermLength = 2
+
 // NewPostHandler creates a new PostHandler.
 func NewPostHandler(s database.Store) *PostHandler {
-	return &PostHandler{Store: s}
+	return &PostHandler{
+		Store:                  s,
+		Sanitizer:              sanitize.DefaultPolicy(),
+		MinSearchTermLength:    DefaultMinSearchTermLength,
+		MaxQueryFilters:        DefaultMaxQueryFilters,
+		RequireJSONContentType: true,
+		MaxCommentLength:       DefaultMaxCommentLength,
+		ReviewMinWords:         DefaultReviewMinWords,
+		ReviewStaleAfter:       DefaultReviewStaleAfter,
+		AutoTagCount:           DefaultAutoTagCount,
+		Logger:                 slog.Default(),
+	}
 }
 
-// ServeHTTP routes the request to the appropriate handler method.
-func (h *PostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/posts/")
-
-	// Route to specific handlers based on method and path
-	if idStr == "" { // Path is /posts
-		switch r.Method {
-		case http.MethodGet:
-			h.GetAllPosts(w, r)
-		case http.MethodPost:
-			h.CreatePost(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// postRequest is the body of POST /posts and PUT /posts/{id}. It holds only
+// the fields clients may set, so server-managed fields such as the ID,
+// timestamps, and pins are never taken from a request.
+type postRequest struct {
+	Title         string                       `json:"title"`
+	Slug          string                       `json:"slug"`
+	Content       string                       `json:"content"`
+	Category      string                       `json:"category"`
+	Tags          []string                     `json:"tags"`
+	Author        string                       `json:"author"`
+	AuthorName    string                       `json:"authorName"`
+	Status        string                       `json:"status"`
+	Translations  map[string]model.Translation `json:"translations"`
+	ImageURL      string                       `json:"imageUrl"`
+	AllowComments *bool                        `json:"allowComments"`
+	Featured      bool                         `json:"featured"`
+	PublishAt     *time.Time                   `json:"publishAt"`
+}
+
+// post maps the request onto a new model.Post. AllowComments defaults to
+// true when the request omits it.
+func (req *postRequest) post() *model.Post {
+	return &model.Post{
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Content:       req.Content,
+		Category:      req.Category,
+		Tags:          req.Tags,
+		Author:        req.Author,
+		AuthorName:    req.AuthorName,
+		Status:        req.Status,
+		Translations:  req.Translations,
+		ImageURL:      req.ImageURL,
+		AllowComments: req.AllowComments == nil || *req.AllowComments,
+		Featured:      req.Featured,
+		PublishAt:     req.PublishAt,
+	}
+}
+
+// decodePost reads a postRequest from the request body and returns the
+// post it describes, with its content sanitized.
+func (h *PostHandler) decodePost(r *http.Request) (*model.Post, error) {
+	var body io.Reader = r.Body
+	if h.StrictText {
+		// encoding/json silently replaces invalid UTF-8 with U+FFFD, so
+		// each invalid sequence becomes an escaped NUL first, which the
+		// text checks in validate then reject in whichever field it is.
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
 		}
-	} else { // Path is /posts/{id}
-		id, err := strconv.ParseInt(idStr, 10, 64)
+		body = bytes.NewReader(bytes.ToValidUTF8(b, []byte(`\u0000`)))
+	}
+
+	var req postRequest
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		return nil, err
+	}
+	post := req.post()
+	h.sanitizePost(post)
+	return post, nil
+}
+
+// sanitizePost removes disallowed HTML from the post's content and any
+// translated content.
+func (h *PostHandler) sanitizePost(post *model.Post) {
+	if h.Sanitizer == nil {
+		return
+	}
+	post.Content = h.Sanitizer.Sanitize(post.Content)
+	for lang, translation := range post.Translations {
+		translation.Content = h.Sanitizer.Sanitize(translation.Content)
+		post.Translations[lang] = translation
+	}
+}
+
+// requireJSON responds 415 and reports false if the handler requires JSON
+// request bodies and the request's Content-Type is not application/json.
+// Parameters such as charset are allowed.
+func (h *PostHandler) requireJSON(w http.ResponseWriter, r *http.Request) bool {
+	if !h.RequireJSONContentType {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		writeError(w, r, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, "Content-Type must be application/json")
+		return false
+	}
+	return true
+}
+
+// serverError logs err and responds 500 with msg, keeping store details out
+// of the response.
+func (h *PostHandler) serverError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	h.Logger.Error(msg,
+		"error", err,
+		"method", r.Method,
+		"path", r.URL.Path,
+		"requestId", middleware.RequestIDFromContext(r.Context()),
+	)
+	writeError(w, r, http.StatusInternalServerError, CodeInternal, msg)
+}
+
+// parseID reads a post ID from a URL: a token when IDCodec is set,
+// otherwise a positive integer.
+func (h *PostHandler) parseID(s string) (int64, error) {
+	if h.IDCodec != nil {
+		return h.IDCodec.Decode(s)
+	}
+	// IDs start at 1, so zero and negative IDs can never match a post.
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err == nil && id <= 0 {
+		err = errors.New("post IDs are positive")
+	}
+	return id, err
+}
+
+// formatID returns a post ID as it appears in URLs, the inverse of parseID.
+func (h *PostHandler) formatID(id int64) string {
+	if h.IDCodec != nil {
+		return h.IDCodec.Encode(id)
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// jsonID returns a post ID as it appears in response bodies: the token from
+// formatID when IDCodec is set, otherwise the integer itself, matching the
+// id field of posts.
+func (h *PostHandler) jsonID(id int64) interface{} {
+	if h.IDCodec != nil {
+		return h.formatID(id)
+	}
+	return id
+}
+
+// postIDParam is a post ID in a request body, as it appears in URLs. It may
+// be written as a JSON number or a string, so integer IDs work either way
+// and tokens work when IDCodec is set.
+type postIDParam string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *postIDParam) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*p = postIDParam(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return errors.New("post id must be a number or a string")
+	}
+	*p = postIDParam(n)
+	return nil
+}
+
+// parseIDParams parses post IDs from a request body with parseID.
+func (h *PostHandler) parseIDParams(params []postIDParam) ([]int64, error) {
+	ids := make([]int64, 0, len(params))
+	for _, param := range params {
+		id, err := h.parseID(string(param))
 		if err != nil {
-			http.Error(w, "Invalid post ID", http.StatusBadRequest)
-			return
+			return nil, err
 		}
-		switch r.Method {
-		case http.MethodGet:
-			h.GetPost(w, r, id)
-		case http.MethodPut:
-			h.UpdatePost(w, r, id)
-		case http.MethodDelete:
-			h.DeletePost(w, r, id)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// notFound responds 404 with err's message. The message is generic while
+// IDCodec is set, since store errors name the integer ID behind a token.
+func (h *PostHandler) notFound(w http.ResponseWriter, r *http.Request, err error) {
+	code := CodePostNotFound
+	switch {
+	case errors.Is(err, database.ErrCommentNotFound):
+		code = CodeCommentNotFound
+	case errors.Is(err, database.ErrRevisionNotFound):
+		code = CodeRevisionNotFound
+	}
+	msg := err.Error()
+	if h.IDCodec != nil {
+		msg = "Not found"
+	}
+	writeError(w, r, http.StatusNotFound, code, msg)
+}
+
+// ServeHTTP routes the request to the handler for its path and method, as
+// listed in postRoutes.
+func (h *PostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.serveRoutes(w, r, "/posts", postRoutes)
+}
+
+// ServeComments routes requests under /comments, as listed in
+// commentRoutes.
+func (h *PostHandler) ServeComments(w http.ResponseWriter, r *http.Request) {
+	h.serveRoutes(w, r, "/comments", commentRoutes)
+}
+
+// serveRoutes routes a request under prefix to the handler for its path and
+// method in table.
+func (h *PostHandler) serveRoutes(w http.ResponseWriter, r *http.Request, prefix string, table []route) {
+	// Normalize the path so /posts and /posts/ are equivalent and a trailing
+	// slash after an ID or action is ignored.
+	rest := strings.TrimPrefix(r.URL.Path, prefix)
+	if rest != "" && rest[0] != '/' {
+		writeNotFound(w, r)
+		return
+	}
+	rest = strings.TrimSuffix(strings.TrimPrefix(rest, "/"), "/")
+
+	segments := strings.Split(rest, "/")
+	routes := matchRoutes(table, segments)
+	if len(routes) == 0 {
+		writeNotFound(w, r)
+		return
+	}
+	params, ok := h.parseRouteParams(w, r, routes[0].pattern, segments)
+	if !ok {
+		return
+	}
+	for _, rt := range routes {
+		if rt.method == r.Method {
+			rt.handle(h, w, r, params)
+			return
 		}
 	}
+	w.Header().Set("Allow", allowedMethods(routes))
+	writeMethodNotAllowed(w, r)
 }
 
 // CreatePost handles POST /posts
 func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
-	var post model.Post
-	if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	post, err := h.decodePost(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
 		return
 	}
 
-	// Basic validation
-	if post.Title == "" || post.Content == "" {
-		http.Error(w, `{"error": "title and content are required"}`, http.StatusBadRequest)
+	if strings.TrimSpace(post.Category) == "" {
+		post.Category = h.DefaultCategory
+	}
+	if len(post.Tags) == 0 && r.URL.Query().Get("autoTag") == "true" {
+		post.Tags = extractTags(post.Content, h.stopwordSet(), min(h.AutoTagCount, MaxTags))
+	}
+	if errs := h.validate(post); len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	// Imports keyed on slug get the existing post back instead of a copy.
+	if r.URL.Query().Get("createIfAbsent") == "true" {
+		if post.Slug == "" {
+			writeValidationErrors(w, r, model.ValidationErrors{{Field: "slug", Message: "required"}})
+			return
+		}
+		owner, err := h.Store.SlugExists(r.Context(), post.Slug)
+		if err != nil {
+			h.serverError(w, r, "Failed to check slug", err)
+			return
+		}
+		if owner != 0 {
+			existing, err := h.Store.GetPost(r.Context(), owner)
+			if err != nil {
+				h.serverError(w, r, "Failed to create post", err)
+				return
+			}
+			h.writeJSON(w, r, http.StatusOK, existing)
+			return
+		}
+	}
+
+	// Guard against accidental reposts unless the client opts out.
+	if r.URL.Query().Get("allowDuplicate") != "true" {
+		existing, err := h.Store.FindByNormalizedTitle(r.Context(), post.Title)
+		if err != nil {
+			h.serverError(w, r, "Failed to create post", err)
+			return
+		}
+		if existing != nil {
+			h.writeConflict(w, r, CodeDuplicatePost, "a post with a similar title already exists", existing.ID)
+			return
+		}
+	}
+
+	if post.Slug != "" && !h.slugAvailable(w, r, post.Slug, 0) {
 		return
 	}
 
-	id, err := h.Store.CreatePost(&post)
+	id, err := h.Store.CreatePost(r.Context(), post)
 	if err != nil {
-		http.Error(w, "Failed to create post", http.StatusInternalServerError)
+		switch limitErrs := storeLimitErrors(err); {
+		case limitErrs != nil:
+			writeValidationErrors(w, r, limitErrs)
+		case errors.Is(err, database.ErrSlugTaken):
+			writeError(w, r, http.StatusConflict, CodeSlugConflict, err.Error())
+		case errors.Is(err, database.ErrStoreFull):
+			writeError(w, r, http.StatusInsufficientStorage, CodeStoreFull, err.Error())
+		default:
+			h.serverError(w, r, "Failed to create post", err)
+		}
 		return
 	}
 
 	// Retrieve the created post to get all fields (like CreatedAt, etc.)
-	createdPost, err := h.Store.GetPost(id)
+	createdPost, err := h.Store.GetPost(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Failed to retrieve created post", http.StatusInternalServerError)
+		h.serverError(w, r, "Failed to retrieve created post", err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(createdPost)
+	h.Webhook.Notify(webhook.PostCreated, createdPost.ID, createdPost)
+
+	h.writeJSON(w, r, http.StatusCreated, createdPost)
+}
+
+// slugAvailable reports whether slug is free for the post with the given ID,
+// which is zero for a new post. Otherwise it responds 409 with the ID of the
+// post using it.
+func (h *PostHandler) slugAvailable(w http.ResponseWriter, r *http.Request, slug string, id int64) bool {
+	owner, err := h.Store.SlugExists(r.Context(), slug)
+	if err != nil {
+		h.serverError(w, r, "Failed to check slug", err)
+		return false
+	}
+	if owner != 0 && owner != id {
+		h.writeConflict(w, r, CodeSlugConflict, "slug is already in use", owner)
+		return false
+	}
+	return true
+}
+
+// writeConflict responds 409 with the ID of the post that conflicts with the
+// request.
+func (h *PostHandler) writeConflict(w http.ResponseWriter, r *http.Request, code ErrorCode, message string, conflictingID int64) {
+	h.writeJSON(w, r, http.StatusConflict, conflictResponse{errorResponse{Code: code, Error: message}, h.jsonID(conflictingID)})
+}
+
+// conflictResponse is the error envelope of a 409 naming the post a request
+// conflicts with.
+type conflictResponse struct {
+	errorResponse
+	ConflictingID interface{} `json:"conflictingId" id:""`
 }
 
 // GetAllPosts handles GET /posts
 func (h *PostHandler) GetAllPosts(w http.ResponseWriter, r *http.Request) {
-	term := r.URL.Query().Get("term")
+	filter, err := h.postFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "ndjson" {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, `format must be "json" or "ndjson"`)
+		return
+	}
+	commentLimit, err := parseExpand(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+	fields, err := parseFields(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+	if fields != nil && format == "ndjson" {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, `fields can't be combined with format "ndjson"`)
+		return
+	}
 
-	posts, err := h.Store.GetAllPosts(term)
+	posts, total, err := h.Store.GetAllPosts(r.Context(), filter)
 	if err != nil {
-		http.Error(w, "Failed to get posts", http.StatusInternalServerError)
+		h.serverError(w, r, "Failed to get posts", err)
+		return
+	}
+	if query := database.ParseSearch(filter.Term); len(query.Include) > 0 {
+		posts = withMatchedFields(posts, query.Include[0])
+		if r.URL.Query().Get("snippet") == "true" {
+			posts = withSnippets(posts, query.Include[0])
+		}
+	}
+	if commentLimit > 0 {
+		if posts, err = h.withComments(r.Context(), posts, commentLimit); err != nil {
+			h.serverError(w, r, "Failed to get comments", err)
+			return
+		}
+	}
+
+	// Let polling clients skip the body when nothing has changed.
+	etag := listETag(r.URL.Query(), posts, total)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if format == "ndjson" && !(len(posts) == 0 && h.EmptyListNoContent) {
+		h.streamNDJSON(w, r, posts)
+		return
+	}
+	if fields != nil && len(posts) > 0 {
+		projected, err := project(posts, fields)
+		if err != nil {
+			h.serverError(w, r, "Failed to encode posts", err)
+			return
+		}
+		h.writeJSON(w, r, http.StatusOK, projected)
+		return
+	}
+
+	h.writePosts(w, r, posts)
+}
+
+// writePosts responds with a list of posts: 200 with the JSON array, or
+// 204 No Content when it is empty and EmptyListNoContent is set. A request
+// preferring return=minimal gets each post as just its id.
+func (h *PostHandler) writePosts(w http.ResponseWriter, r *http.Request, posts []*model.Post) {
+	w.Header().Add("Vary", "Prefer")
+	if len(posts) == 0 && h.EmptyListNoContent {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if !preferMinimal(r) {
+		h.writeJSON(w, r, http.StatusOK, posts)
+		return
+	}
+
+	minimal, err := project(posts, []string{"id"})
+	if err != nil {
+		h.serverError(w, r, "Failed to encode posts", err)
+		return
+	}
+	applyMinimal(w)
+	h.writeJSON(w, r, http.StatusOK, minimal)
+}
+
+// stringIDKeys is the set of JSON keys holding integer IDs in responses,
+// which idsAsStrings quotes.
+var stringIDKeys = model.IDKeys(model.Post{}, model.Comment{}, model.Revision{},
+	bulkResult{}, bulkIDs{}, conflictResponse{}, wxrImportResult{}, repairResult{})
+
+// writeJSON responds with v encoded as JSON, like the function writeJSON,
+// writing integer IDs as strings when stringIDs says to.
+func (h *PostHandler) writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	body, err := encodeJSON(v, h.stringIDs(r))
+	respondJSON(w, r, status, body, err)
+}
+
+// stringIDs reports whether integer IDs in the response to r are written
+// as strings: as ?idsAsStrings says when it is a valid boolean, otherwise
+// as IDsAsStrings does.
+func (h *PostHandler) stringIDs(r *http.Request) bool {
+	if b, err := strconv.ParseBool(r.URL.Query().Get("idsAsStrings")); err == nil {
+		return b
+	}
+	return h.IDsAsStrings
+}
+
+// writeJSON responds with v encoded as JSON. The body is compact and has no
+// trailing newline, which strict clients reject, unless the request asks for
+// ?pretty=true; indented output ends in a newline for terminals.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	body, err := encodeJSON(v, false)
+	respondJSON(w, r, status, body, err)
+}
+
+// encodeFailedBody is the error response sent when a response body cannot
+// be encoded.
+var encodeFailedBody = []byte(`{"code":"` + string(CodeInternal) + `","error":"Failed to encode response"}`)
+
+// respondJSON finishes writeJSON: it writes body, indented for
+// ?pretty=true, or a 500 if encoding it failed with err.
+func respondJSON(w http.ResponseWriter, r *http.Request, status int, body []byte, err error) {
+	if err == nil && r.URL.Query().Get("pretty") == "true" {
+		var buf bytes.Buffer
+		err = json.Indent(&buf, body, "", "  ")
+		body = append(buf.Bytes(), '\n')
+	}
+	if err != nil {
+		// Pre-encoded, since the error envelope goes through writeJSON too.
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(encodeFailedBody)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// encodeJSON encodes v compactly, quoting its integer IDs if stringIDs is
+// set.
+func encodeJSON(v interface{}, stringIDs bool) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err == nil && stringIDs {
+		body, err = model.QuoteIDs(body, stringIDKeys)
+	}
+	return body, err
+}
+
+// streamNDJSON writes posts as newline-delimited JSON, flushing after each
+// line so consumers can process them as they arrive. It ends the stream
+// early if the client goes away or the server starts draining.
+func (h *PostHandler) streamNDJSON(w http.ResponseWriter, r *http.Request, posts []*model.Post) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(posts)
+
+	rc := http.NewResponseController(w)
+	for _, post := range posts {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.Draining:
+			return
+		default:
+		}
+		line, err := encodeJSON(post, h.stringIDs(r))
+		if err != nil {
+			return
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return
+		}
+	}
+}
+
+// ListDrafts handles GET /posts/drafts
+//
+// Drafts are private to their author, so the author parameter is required
+// and the listing is always scoped to that single author.
+func (h *PostHandler) ListDrafts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	author := strings.TrimSpace(query.Get("author"))
+	if author == "" || strings.Contains(author, ",") {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, "author must name a single author")
+		return
+	}
+
+	limit, offset, err := parsePagination(query)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	posts, total, err := h.Store.GetAllPosts(r.Context(), database.PostFilter{
+		Status:  model.StatusDraft,
+		Authors: []string{author},
+		Sort:    database.SortUpdated,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		h.serverError(w, r, "Failed to get drafts", err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	h.writePosts(w, r, posts)
+}
+
+// ListScheduled handles GET /posts/scheduled, the publishing queue: scheduled
+// posts whose PublishAt is still ahead, the next to go live first. Admin
+// only.
+func (h *PostHandler) ListScheduled(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	limit, offset, err := parsePagination(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	posts, total, err := h.Store.GetAllPosts(r.Context(), database.PostFilter{
+		Status:       model.StatusScheduled,
+		PublishAfter: time.Now(),
+		Sort:         database.SortPublishAt,
+		Limit:        limit,
+		Offset:       offset,
+	})
+	if err != nil {
+		h.serverError(w, r, "Failed to get scheduled posts", err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	h.writePosts(w, r, posts)
 }
 
-// GetPost handles GET /posts/{id}
+// PostBounds handles GET /posts/bounds, reporting when the first and most
+// recent published posts were created. Both are null when nothing is
+// published.
+func (h *PostHandler) PostBounds(w http.ResponseWriter, r *http.Request) {
+	first, last, err := h.Store.PublishedBounds(r.Context())
+	if err != nil {
+		h.serverError(w, r, "Failed to get post bounds", err)
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, map[string]*string{
+		"first": model.FormatOptionalTime(first),
+		"last":  model.FormatOptionalTime(last),
+	})
+}
+
+// StatusSummary handles GET /posts/status-summary, reporting how many posts
+// are in each status.
+func (h *PostHandler) StatusSummary(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.Store.CountByStatus(r.Context())
+	if err != nil {
+		h.serverError(w, r, "Failed to count posts", err)
+		return
+	}
+	h.writeJSON(w, r, http.StatusOK, counts)
+}
+
+// Limits for GET /posts/recent and GET /posts/most-commented.
+const (
+	defaultRecentLimit        = 5
+	maxRecentLimit            = 50
+	defaultMostCommentedLimit = 10
+)
+
+// RecentPosts handles GET /posts/recent
+func (h *PostHandler) RecentPosts(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseTopLimit(r.URL.Query(), defaultRecentLimit, maxRecentLimit)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	posts, err := h.Store.RecentPosts(r.Context(), limit)
+	if err != nil {
+		h.serverError(w, r, "Failed to get posts", err)
+		return
+	}
+
+	h.writePosts(w, r, posts)
+}
+
+// OnThisDay handles GET /posts/on-this-day, returning the published posts
+// created on today's date, in UTC, in earlier years.
+func (h *PostHandler) OnThisDay(w http.ResponseWriter, r *http.Request) {
+	posts, err := h.Store.OnThisDay(r.Context(), time.Now().UTC())
+	if err != nil {
+		h.serverError(w, r, "Failed to get posts", err)
+		return
+	}
+
+	h.writePosts(w, r, posts)
+}
+
+// neighbors is the body of a GET /posts/{id}/neighbors response.
+type neighbors struct {
+	Previous *model.Post `json:"previous"`
+	Next     *model.Post `json:"next"`
+}
+
+// Neighbors handles GET /posts/{id}/neighbors, returning the published posts
+// created just before and after the post for previous and next links.
+func (h *PostHandler) Neighbors(w http.ResponseWriter, r *http.Request, id int64) {
+	prev, next, err := h.Store.Neighbors(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to get neighbors", err)
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, neighbors{Previous: prev, Next: next})
+}
+
+// MostCommented handles GET /posts/most-commented, ranking published posts
+// with approved comments by how many they have.
+func (h *PostHandler) MostCommented(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseTopLimit(r.URL.Query(), defaultMostCommentedLimit, maxRecentLimit)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	posts, err := h.Store.MostCommented(r.Context(), limit)
+	if err != nil {
+		h.serverError(w, r, "Failed to get posts", err)
+		return
+	}
+
+	h.writePosts(w, r, posts)
+}
+
+// GetPost handles GET /posts/{id}. With ?expand=comments, the post's
+// approved comments are embedded as a thread.
 func (h *PostHandler) GetPost(w http.ResponseWriter, r *http.Request, id int64) {
-	post, err := h.Store.GetPost(id)
+	expand := r.URL.Query().Get("expand")
+	if expand != "" && expand != "comments" {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, `expand must be "comments"`)
+		return
+	}
+	fields, err := parseFields(r.URL.Query())
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Post with id %d not found", id), http.StatusNotFound)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(post)
+	post, err := h.Store.GetPost(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to get post", err)
+		}
+		return
+	}
+
+	// Serve the translation that best matches the client's preferred languages.
+	post, lang := localizePost(post, r.Header.Get("Accept-Language"))
+	if lang != "" {
+		w.Header().Set("Content-Language", lang)
+	}
+	w.Header().Add("Vary", "Accept-Language")
+
+	// The post's ETag does not cover its comments, so expanded responses
+	// are sent without one.
+	if expand == "comments" {
+		if post, err = h.withThread(r.Context(), post); err != nil {
+			if errors.Is(err, database.ErrPostNotFound) {
+				h.notFound(w, r, err)
+			} else {
+				h.serverError(w, r, "Failed to get comments", err)
+			}
+			return
+		}
+		h.writePost(w, r, post, fields)
+		return
+	}
+
+	etag := postETag(post)
+	if lang != "" {
+		etag = localizedETag(etag, lang)
+	}
+	if fields != nil {
+		etag = projectedETag(etag, fields)
+	}
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.writePost(w, r, post, fields)
+}
+
+// writePost responds 200 with post, keeping only fields when they are set.
+func (h *PostHandler) writePost(w http.ResponseWriter, r *http.Request, post *model.Post, fields []string) {
+	projected, err := project(post, fields)
+	if err != nil {
+		h.serverError(w, r, "Failed to encode post", err)
+		return
+	}
+	h.writeJSON(w, r, http.StatusOK, projected)
 }
 
 // UpdatePost handles PUT /posts/{id}
 func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request, id int64) {
-	var post model.Post
-	if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	post, err := h.decodePost(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	if errs := h.validate(post); len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	if post.Slug != "" && !h.slugAvailable(w, r, post.Slug, id) {
 		return
 	}
 
-	// Basic validation
-	if post.Title == "" || post.Content == "" {
-		http.Error(w, `{"error": "title and content are required"}`, http.StatusBadRequest)
+	if r.URL.Query().Get("upsert") == "true" {
+		upsertedPost, created, err := h.Store.UpsertPost(r.Context(), id, post)
+		if err != nil {
+			switch limitErrs := storeLimitErrors(err); {
+			case limitErrs != nil:
+				writeValidationErrors(w, r, limitErrs)
+			case retryAfter(err) > 0:
+				writeUpdateThrottled(w, r, retryAfter(err))
+			case errors.Is(err, database.ErrSlugTaken):
+				writeError(w, r, http.StatusConflict, CodeSlugConflict, err.Error())
+			case errors.Is(err, database.ErrStoreFull):
+				writeError(w, r, http.StatusInsufficientStorage, CodeStoreFull, err.Error())
+			default:
+				h.serverError(w, r, "Failed to update post", err)
+			}
+			return
+		}
+
+		status, event := http.StatusOK, webhook.PostUpdated
+		if created {
+			status, event = http.StatusCreated, webhook.PostCreated
+		}
+		h.Webhook.Notify(event, upsertedPost.ID, upsertedPost)
+
+		h.writeJSON(w, r, status, upsertedPost)
 		return
 	}
 
-	updatedPost, err := h.Store.UpdatePost(id, &post)
+	updatedPost, err := h.Store.UpdatePost(r.Context(), id, post)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, err.Error(), http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to update post", http.StatusInternalServerError)
+		switch limitErrs := storeLimitErrors(err); {
+		case limitErrs != nil:
+			writeValidationErrors(w, r, limitErrs)
+		case retryAfter(err) > 0:
+			writeUpdateThrottled(w, r, retryAfter(err))
+		case errors.Is(err, database.ErrSlugTaken):
+			writeError(w, r, http.StatusConflict, CodeSlugConflict, err.Error())
+		case errors.Is(err, database.ErrPostNotFound):
+			h.notFound(w, r, err)
+		default:
+			h.serverError(w, r, "Failed to update post", err)
 		}
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(updatedPost)
+	h.Webhook.Notify(webhook.PostUpdated, updatedPost.ID, updatedPost)
+
+	h.writeJSON(w, r, http.StatusOK, updatedPost)
 }
 
-// DeletePost handles DELETE /posts/{id}
+// DeletePost handles DELETE /posts/{id}. With ?idempotent=true, deleting a
+// post that does not exist succeeds, so retries don't fail. An If-Match
+// header makes the delete conditional on the post's current ETag.
 func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request, id int64) {
-	err := h.Store.DeletePost(id)
+	if header := r.Header.Get("If-Match"); header != "" {
+		post, err := h.Store.GetPost(r.Context(), id)
+		switch {
+		case errors.Is(err, database.ErrPostNotFound):
+			// Let the delete below report the missing post as usual.
+		case err != nil:
+			h.serverError(w, r, "Failed to delete post", err)
+			return
+		case !ifMatch(header, postETag(post)):
+			writeError(w, r, http.StatusPreconditionFailed, CodePreconditionFailed, "Post has changed since it was fetched")
+			return
+		}
+	}
+
+	err := h.Store.DeletePost(r.Context(), id)
+	switch {
+	case err == nil:
+		h.Webhook.Notify(webhook.PostDeleted, id, nil)
+	case errors.Is(err, database.ErrPostNotFound) && r.URL.Query().Get("idempotent") == "true":
+		// Already gone, which is what the client wanted.
+	case errors.Is(err, database.ErrPostNotFound):
+		h.notFound(w, r, err)
+		return
+	default:
+		h.serverError(w, r, "Failed to delete post", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PublishPost handles POST /posts/{id}/publish
+func (h *PostHandler) PublishPost(w http.ResponseWriter, r *http.Request, id int64) {
+	post, err := h.Store.PublishPost(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrAlreadyPublished):
+			writeError(w, r, http.StatusConflict, CodeInvalidState, err.Error())
+		case errors.Is(err, database.ErrPostNotFound):
+			h.notFound(w, r, err)
+		default:
+			h.serverError(w, r, "Failed to publish post", err)
+		}
+		return
+	}
+	h.Webhook.Notify(webhook.PostPublished, post.ID, post)
+
+	h.writeJSON(w, r, http.StatusOK, post)
+}
+
+// TouchPost handles POST /posts/{id}/touch, marking the post as updated now
+// so it resurfaces in listings sorted by update time.
+func (h *PostHandler) TouchPost(w http.ResponseWriter, r *http.Request, id int64) {
+	post, err := h.Store.TouchPost(r.Context(), id)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, err.Error(), http.StatusNotFound)
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
 		} else {
-			http.Error(w, "Failed to delete post", http.StatusInternalServerError)
+			h.serverError(w, r, "Failed to touch post", err)
 		}
 		return
 	}
+	h.Webhook.Notify(webhook.PostUpdated, post.ID, post)
 
-	w.WriteHeader(http.StatusNoContent)
+	h.writeJSON(w, r, http.StatusOK, post)
 }
 
-// HealthCheckHandler provides a simple health check endpoint.
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	// A simple health check which returns status 200
-	data := map[string]string{"status": "ok"}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(data)
+// maxBulkIDs caps how many posts a single bulk request may touch.
+const maxBulkIDs = 100
+
+// bulkRequest is the body of bulk endpoints such as POST /posts/bulk-publish.
+type bulkRequest struct {
+	IDs []postIDParam `json:"ids"`
+}
+
+// BulkPublish handles POST /posts/bulk-publish
+func (h *PostHandler) BulkPublish(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	var req bulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	var errs model.ValidationErrors
+	switch {
+	case len(req.IDs) == 0:
+		errs.Add("ids", "required")
+	case len(req.IDs) > maxBulkIDs:
+		errs.Add("ids", "too many")
+	}
+	ids, err := h.parseIDParams(req.IDs)
+	if err != nil {
+		errs.Add("ids", "invalid post id")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	results, err := h.Store.BulkPublish(r.Context(), ids)
+	if err != nil {
+		h.serverError(w, r, "Failed to publish posts", err)
+		return
+	}
+
+	h.writeBulkResults(w, r, results, h.jsonID)
+}
+
+// lookupRequest is the body of POST /posts/lookup.
+type lookupRequest struct {
+	IDs []postIDParam `json:"ids"`
+}
+
+// LookupPosts handles POST /posts/lookup, returning the requested posts as
+// an object keyed by ID, as it appears in URLs, so that clients can index
+// them directly. IDs of posts that do not exist or are in the trash are
+// absent from it.
+func (h *PostHandler) LookupPosts(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	var req lookupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	var errs model.ValidationErrors
+	switch {
+	case len(req.IDs) == 0:
+		errs.Add("ids", "required")
+	case len(req.IDs) > maxBulkIDs:
+		errs.Add("ids", "too many")
+	}
+	ids, err := h.parseIDParams(req.IDs)
+	if err != nil {
+		errs.Add("ids", "invalid post id")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	posts, err := h.Store.GetPostsByIDs(r.Context(), ids)
+	if err != nil {
+		h.serverError(w, r, "Failed to get posts", err)
+		return
+	}
+
+	byID := make(map[string]*model.Post, len(posts))
+	for id, post := range posts {
+		byID[h.formatID(id)] = post
+	}
+	h.writeJSON(w, r, http.StatusOK, byID)
+}
+
+// bulkStatusRequest is the body of POST /posts/bulk-status.
+type bulkStatusRequest struct {
+	IDs    []postIDParam `json:"ids"`
+	Status string        `json:"status"`
+}
+
+// BulkSetStatus handles POST /posts/bulk-status
+func (h *PostHandler) BulkSetStatus(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	var req bulkStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	var errs model.ValidationErrors
+	switch {
+	case len(req.IDs) == 0:
+		errs.Add("ids", "required")
+	case len(req.IDs) > maxBulkIDs:
+		errs.Add("ids", "too many")
+	}
+	ids, err := h.parseIDParams(req.IDs)
+	if err != nil {
+		errs.Add("ids", "invalid post id")
+	}
+	switch req.Status {
+	case "":
+		errs.Add("status", "required")
+	case model.StatusDraft, model.StatusPublished, model.StatusScheduled:
+	default:
+		errs.Add("status", "invalid")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	results, err := h.Store.BulkSetStatus(r.Context(), ids, req.Status)
+	if err != nil {
+		h.serverError(w, r, "Failed to update posts", err)
+		return
+	}
+
+	h.writeBulkResults(w, r, results, h.jsonID)
+}
+
+// UnpublishPost handles POST /posts/{id}/unpublish
+func (h *PostHandler) UnpublishPost(w http.ResponseWriter, r *http.Request, id int64) {
+	post, err := h.Store.UnpublishPost(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrAlreadyDraft):
+			writeError(w, r, http.StatusConflict, CodeInvalidState, err.Error())
+		case errors.Is(err, database.ErrPostNotFound):
+			h.notFound(w, r, err)
+		default:
+			h.serverError(w, r, "Failed to unpublish post", err)
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, post)
 }
@@ -1,20 +1,35 @@
 package handler
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/hashid"
 	"github.com/gemini/go-blog-api/internal/model"
+	"github.com/gemini/go-blog-api/internal/webhook"
 )
 
 // mockStore is a mock implementation of the database.Store for testing purposes.
+// The embedded Store is left nil, so calling a method the mock does not
+// override panics; tests for those methods use a real MemoryStore instead.
 type mockStore struct {
+	database.Store
+
 	posts  map[int64]*model.Post
 	nextID int64
 	err    error // To simulate database errors
@@ -27,7 +42,7 @@ func newMockStore() *mockStore {
 	}
 }
 
-func (m *mockStore) CreatePost(post *model.Post) (int64, error) {
+func (m *mockStore) CreatePost(ctx context.Context, post *model.Post) (int64, error) {
 	if m.err != nil {
 		return 0, m.err
 	}
@@ -41,35 +56,42 @@ func (m *mockStore) CreatePost(post *model.Post) (int64, error) {
 	return id, nil
 }
 
-func (m *mockStore) GetPost(id int64) (*model.Post, error) {
+func (m *mockStore) GetPost(ctx context.Context, id int64) (*model.Post, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
 	post, ok := m.posts[id]
 	if !ok {
-		return nil, errors.New("not found")
+		return nil, database.ErrPostNotFound
 	}
 	return post, nil
 }
 
-func (m *mockStore) GetAllPosts(term string) ([]*model.Post, error) {
+func (m *mockStore) GetAllPosts(ctx context.Context, filter database.PostFilter) ([]*model.Post, int, error) {
 	if m.err != nil {
-		return nil, m.err
+		return nil, 0, m.err
 	}
 	posts := make([]*model.Post, 0, len(m.posts))
 	for _, p := range m.posts {
 		posts = append(posts, p)
 	}
-	return posts, nil
+	return posts, len(posts), nil
+}
+
+func (m *mockStore) FindByNormalizedTitle(ctx context.Context, title string) (*model.Post, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return nil, nil
 }
 
-func (m *mockStore) UpdatePost(id int64, post *model.Post) (*model.Post, error) {
+func (m *mockStore) UpdatePost(ctx context.Context, id int64, post *model.Post) (*model.Post, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
 	_, ok := m.posts[id]
 	if !ok {
-		return nil, errors.New("not found")
+		return nil, database.ErrPostNotFound
 	}
 	post.ID = id
 	post.UpdatedAt = time.Now().UTC()
@@ -77,13 +99,13 @@ func (m *mockStore) UpdatePost(id int64, post *model.Post) (*model.Post, error)
 	return post, nil
 }
 
-func (m *mockStore) DeletePost(id int64) error {
+func (m *mockStore) DeletePost(ctx context.Context, id int64) error {
 	if m.err != nil {
 		return m.err
 	}
 	_, ok := m.posts[id]
 	if !ok {
-		return errors.New("not found")
+		return database.ErrPostNotFound
 	}
 	delete(m.posts, id)
 	return nil
@@ -98,7 +120,7 @@ func TestPostHandler(t *testing.T) {
 		Title:   "Initial Post",
 		Content: "Initial Content",
 	}
-	store.CreatePost(initialPost)
+	store.CreatePost(context.Background(), initialPost)
 
 	t.Run("CreatePost", func(t *testing.T) {
 		t.Run("success", func(t *testing.T) {
@@ -109,6 +131,7 @@ func TestPostHandler(t *testing.T) {
 			body, _ := json.Marshal(postData)
 
 			req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
 			rr := httptest.NewRecorder()
 
 			handler.ServeHTTP(rr, req)
@@ -124,8 +147,28 @@ func TestPostHandler(t *testing.T) {
 			}
 		})
 
+		t.Run("sanitizes content", func(t *testing.T) {
+			postData := map[string]interface{}{
+				"title":   "Sanitized",
+				"content": `<b>Hi</b><script>alert("x")</script>`,
+			}
+			body, _ := json.Marshal(postData)
+
+			req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var createdPost model.Post
+			json.Unmarshal(rr.Body.Bytes(), &createdPost)
+			if createdPost.Content != "<b>Hi</b>" {
+				t.Errorf("handler did not sanitize content: got %q want %q", createdPost.Content, "<b>Hi</b>")
+			}
+		})
+
 		t.Run("bad request - invalid json", func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader([]byte("{invalid")))
+			req.Header.Set("Content-Type", "application/json")
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
 
@@ -134,15 +177,60 @@ func TestPostHandler(t *testing.T) {
 			}
 		})
 
-		t.Run("bad request - missing title", func(t *testing.T) {
+		t.Run("unprocessable - missing title", func(t *testing.T) {
 			postData := map[string]interface{}{"content": "Some content"}
 			body, _ := json.Marshal(postData)
 			req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
 
-			if status := rr.Code; status != http.StatusBadRequest {
-				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+			}
+
+			var resp struct {
+				Errors model.ValidationErrors `json:"errors"`
+			}
+			json.Unmarshal(rr.Body.Bytes(), &resp)
+			if len(resp.Errors) != 1 || resp.Errors[0].Field != "title" {
+				t.Errorf("handler returned wrong errors: got %v want a single title error", resp.Errors)
+			}
+		})
+
+		t.Run("unprocessable - multiple invalid fields", func(t *testing.T) {
+			tags := make([]string, MaxTags+1)
+			for i := range tags {
+				tags[i] = fmt.Sprintf("tag%d", i)
+			}
+			postData := map[string]interface{}{
+				"title":   "",
+				"content": strings.Repeat("a", MaxContentLength+1),
+				"status":  "archived",
+				"tags":    tags,
+			}
+			body, _ := json.Marshal(postData)
+			req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+			}
+
+			var resp struct {
+				Errors model.ValidationErrors `json:"errors"`
+			}
+			json.Unmarshal(rr.Body.Bytes(), &resp)
+			want := model.ValidationErrors{
+				{Field: "title", Message: "required"},
+				{Field: "content", Message: "too long"},
+				{Field: "tags", Message: "too many"},
+				{Field: "status", Message: "invalid"},
+			}
+			if fmt.Sprint(resp.Errors) != fmt.Sprint(want) {
+				t.Errorf("handler returned wrong errors: got %v want %v", resp.Errors, want)
 			}
 		})
 	})
@@ -173,6 +261,75 @@ func TestPostHandler(t *testing.T) {
 				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
 			}
 		})
+
+		t.Run("translated", func(t *testing.T) {
+			translatedPost := &model.Post{
+				Title:   "Hello",
+				Content: "Hello world",
+				Translations: map[string]model.Translation{
+					"fr": {Title: "Bonjour", Content: "Bonjour le monde"},
+					"de": {Title: "Hallo", Content: "Hallo Welt"},
+				},
+			}
+			id, _ := store.CreatePost(context.Background(), translatedPost)
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/posts/%d", id), nil)
+			req.Header.Set("Accept-Language", "es;q=0.9, fr-CA, de;q=0.5")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			if lang := rr.Header().Get("Content-Language"); lang != "fr" {
+				t.Errorf("handler returned wrong Content-Language: got %v want %v", lang, "fr")
+			}
+
+			var post model.Post
+			json.Unmarshal(rr.Body.Bytes(), &post)
+			if post.Title != "Bonjour" || post.Content != "Bonjour le monde" {
+				t.Errorf("handler returned wrong variant: got %q/%q want %q/%q", post.Title, post.Content, "Bonjour", "Bonjour le monde")
+			}
+
+			// The stored post must keep its default fields.
+			if translatedPost.Title != "Hello" {
+				t.Errorf("handler modified stored post: got title %v want %v", translatedPost.Title, "Hello")
+			}
+		})
+
+		t.Run("untranslated falls back to default", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+			req.Header.Set("Accept-Language", "fr")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if lang := rr.Header().Get("Content-Language"); lang != "" {
+				t.Errorf("handler returned unexpected Content-Language: got %v", lang)
+			}
+		})
+	})
+
+	t.Run("GetPost store failure", func(t *testing.T) {
+		failing := newMockStore()
+		failing.err = errors.New("connection refused")
+
+		var logs bytes.Buffer
+		failingHandler := NewPostHandler(failing)
+		failingHandler.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+		req := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+		rr := httptest.NewRecorder()
+		failingHandler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusInternalServerError {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
+		}
+		if strings.Contains(rr.Body.String(), "connection refused") {
+			t.Errorf("handler leaked the store error: %q", rr.Body.String())
+		}
+		if !strings.Contains(logs.String(), "connection refused") {
+			t.Errorf("handler did not log the store error: %q", logs.String())
+		}
 	})
 
 	t.Run("GetAllPosts", func(t *testing.T) {
@@ -199,6 +356,7 @@ func TestPostHandler(t *testing.T) {
 		body, _ := json.Marshal(updateData)
 
 		req := httptest.NewRequest(http.MethodPut, "/posts/1", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
 		rr := httptest.NewRecorder()
 		handler.ServeHTTP(rr, req)
 
@@ -217,7 +375,7 @@ func TestPostHandler(t *testing.T) {
 		t.Run("success", func(t *testing.T) {
 			// Use a new post ID to avoid interfering with other tests
 			postToDelete := &model.Post{Title: "To Delete", Content: "Content"}
-			id, _ := store.CreatePost(postToDelete)
+			id, _ := store.CreatePost(context.Background(), postToDelete)
 
 			req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/posts/%d", id), nil)
 			rr := httptest.NewRecorder()
@@ -237,5 +395,2280 @@ func TestPostHandler(t *testing.T) {
 				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
 			}
 		})
+
+		t.Run("idempotent not found", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/posts/999?idempotent=true", nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusNoContent {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+			}
+		})
+
+		t.Run("idempotent store error", func(t *testing.T) {
+			store.err = errors.New("database is down")
+			defer func() { store.err = nil }()
+
+			req := httptest.NewRequest(http.MethodDelete, "/posts/999?idempotent=true", nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusInternalServerError {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
+			}
+		})
+	})
+}
+
+func TestPublishPost(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	draftID, _ := store.CreatePost(context.Background(), &model.Post{Title: "Draft", Content: "Content", Status: model.StatusDraft})
+
+	t.Run("success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/posts/%d/publish", draftID), nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		var post model.Post
+		json.Unmarshal(rr.Body.Bytes(), &post)
+		if post.Status != model.StatusPublished {
+			t.Errorf("handler returned wrong status: got %v want %v", post.Status, model.StatusPublished)
+		}
+		if post.PublishedAt == nil {
+			t.Error("handler did not set publishedAt")
+		}
+	})
+
+	t.Run("already published", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/posts/%d/publish", draftID), nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusConflict {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/posts/999/publish", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+}
+
+func TestUnpublishPost(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	id, _ := store.CreatePost(context.Background(), &model.Post{Title: "Draft", Content: "Content", Status: model.StatusDraft})
+
+	listIDs := func() []int64 {
+		req := httptest.NewRequest(http.MethodGet, "/posts/", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var posts []model.Post
+		json.Unmarshal(rr.Body.Bytes(), &posts)
+		ids := make([]int64, 0, len(posts))
+		for _, p := range posts {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	t.Run("already draft", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/posts/%d/unpublish", id), nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusConflict {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+		}
+	})
+
+	t.Run("publish then unpublish", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/posts/%d/publish", id), nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if ids := listIDs(); len(ids) != 1 || ids[0] != id {
+			t.Fatalf("published post missing from listing: got %v", ids)
+		}
+
+		req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/posts/%d/unpublish", id), nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		var post model.Post
+		json.Unmarshal(rr.Body.Bytes(), &post)
+		if post.Status != model.StatusDraft || post.Content != "Content" {
+			t.Errorf("handler returned wrong post: got status %v content %q", post.Status, post.Content)
+		}
+
+		if ids := listIDs(); len(ids) != 0 {
+			t.Errorf("unpublished post still listed: got %v", ids)
+		}
+	})
+}
+
+func TestTouchPost(t *testing.T) {
+	ctx := context.Background()
+	stale := time.Now().Add(-24 * time.Hour).UTC()
+	clock := stale
+	store := database.NewMemoryStore(database.WithClock(func() time.Time { return clock }))
+	handler := NewPostHandler(store)
+
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"})
+	clock = time.Now()
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/posts/%d/touch", id), nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var post model.Post
+	json.Unmarshal(rr.Body.Bytes(), &post)
+	if !post.UpdatedAt.After(stale) {
+		t.Errorf("handler did not advance updatedAt: got %v, was %v", post.UpdatedAt, stale)
+	}
+	if post.Title != "Title" || post.Content != "Content" {
+		t.Errorf("handler changed the post: got %q, %q", post.Title, post.Content)
+	}
+	if revisions, _ := store.GetRevisions(ctx, id); len(revisions) != 1 {
+		t.Errorf("touch recorded a revision: got %d want %d", len(revisions), 1)
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/posts/999/touch", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+}
+
+func TestUpdateThrottle(t *testing.T) {
+	store := database.NewMemoryStore(database.WithMinUpdateInterval(time.Minute))
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	store.CreatePost(ctx, &model.Post{Title: "First", Content: "Content"})
+	store.CreatePost(ctx, &model.Post{Title: "Second", Content: "Content"})
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if status := put("/posts/1", `{"title":"First","content":"Draft one"}`).Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	rr := put("/posts/1", `{"title":"First","content":"Draft two"}`)
+	if status := rr.Code; status != http.StatusTooManyRequests {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusTooManyRequests)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("handler returned wrong Retry-After: got %q want %q", got, "60")
+	}
+	if !strings.Contains(rr.Body.String(), string(CodeUpdateThrottled)) {
+		t.Errorf("handler returned wrong error code: %s", rr.Body.String())
+	}
+	if post, _ := store.GetPost(ctx, 1); post.Content != "Draft one" {
+		t.Errorf("throttled update was stored: got %q want %q", post.Content, "Draft one")
+	}
+
+	// Throttling is per post, and covers the other ways of updating one.
+	if status := put("/posts/2", `{"title":"Second","content":"Edited"}`).Code; status != http.StatusOK {
+		t.Errorf("another post was throttled: got %v want %v", status, http.StatusOK)
+	}
+	for _, path := range []string{"/posts/1?upsert=true", "/posts/1/tags"} {
+		body := `{"title":"First","content":"Draft two"}`
+		if strings.HasSuffix(path, "/tags") {
+			body = `{"tags":["go"]}`
+		}
+		if status := put(path, body).Code; status != http.StatusTooManyRequests {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", path, status, http.StatusTooManyRequests)
+		}
+	}
+}
+
+func TestCategoryAllowlist(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+	handler.AllowedCategories = []string{"Technology", "Travel"}
+
+	create := func(category string) *httptest.ResponseRecorder {
+		postData := map[string]interface{}{
+			"title":    "Title",
+			"content":  "Content",
+			"category": category,
+		}
+		body, _ := json.Marshal(postData)
+		req := httptest.NewRequest(http.MethodPost, "/posts/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		if status := create("travel").Code; status != http.StatusCreated {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+	})
+
+	t.Run("disallowed", func(t *testing.T) {
+		rr := create("Cooking")
+		if status := rr.Code; status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+		}
+
+		var resp struct {
+			Errors model.ValidationErrors `json:"errors"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		if len(resp.Errors) != 1 || resp.Errors[0].Field != "category" {
+			t.Errorf("handler returned wrong errors: got %v want a single category error", resp.Errors)
+		}
+	})
+}
+
+func TestDuplicateTitle(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	create := func(title, query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"title": title, "content": "Content"})
+		req := httptest.NewRequest(http.MethodPost, "/posts/"+query, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if status := create("Hello, World!", "").Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	t.Run("conflict", func(t *testing.T) {
+		rr := create("hello world", "")
+		if status := rr.Code; status != http.StatusConflict {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+		}
+
+		var resp struct {
+			ConflictingID int64 `json:"conflictingId"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		if resp.ConflictingID != 1 {
+			t.Errorf("handler returned wrong conflicting ID: got %v want %v", resp.ConflictingID, 1)
+		}
+	})
+
+	t.Run("allowDuplicate", func(t *testing.T) {
+		if status := create("HELLO WORLD", "?allowDuplicate=true").Code; status != http.StatusCreated {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
 	})
-}
\ No newline at end of file
+}
+
+func TestCreateIfAbsent(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/posts?createIfAbsent=true", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	const body = `{"title":"Imported","content":"Content","slug":"imported"}`
+
+	first := create(body)
+	if status := first.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	second := create(body)
+	if status := second.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code for repeat import: got %v want %v", status, http.StatusOK)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("handler returned a different post for repeat import: got %s want %s", second.Body, first.Body)
+	}
+	if count, _ := handler.Store.CountPosts(context.Background()); count != 1 {
+		t.Errorf("store has %d posts after repeat import, want 1", count)
+	}
+
+	if status := create(`{"title":"No slug","content":"Content"}`).Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code without a slug: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestServerManagedFields(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	const managed = `"id":42,"createdAt":"2000-01-01T00:00:00Z","views":1000,"wordCount":99,"pinOrder":1`
+
+	for _, tt := range []struct {
+		name, method, path string
+		want               int
+	}{
+		{"create", http.MethodPost, "/posts", http.StatusCreated},
+		{"update", http.MethodPut, "/posts/1", http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := do(tt.method, tt.path, `{"title":"Title","content":"Two words",`+managed+`}`)
+			if status := rr.Code; status != tt.want {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+
+			var post map[string]interface{}
+			json.Unmarshal(rr.Body.Bytes(), &post)
+			if post["id"] != float64(1) {
+				t.Errorf("handler honored id: got %v want %v", post["id"], 1)
+			}
+			if post["createdAt"] == "2000-01-01T00:00:00.000000000Z" {
+				t.Errorf("handler honored createdAt: got %v", post["createdAt"])
+			}
+			if post["wordCount"] != float64(2) {
+				t.Errorf("handler honored wordCount: got %v want %v", post["wordCount"], 2)
+			}
+			for _, field := range []string{"views", "pinOrder"} {
+				if v, ok := post[field]; ok {
+					t.Errorf("handler honored %s: got %v", field, v)
+				}
+			}
+		})
+	}
+}
+
+func TestPathNormalization(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"collection without slash", http.MethodGet, "/posts", http.StatusOK},
+		{"collection with slash", http.MethodGet, "/posts/", http.StatusOK},
+		{"id with trailing slash", http.MethodGet, "/posts/1/", http.StatusOK},
+		{"action with trailing slash", http.MethodPost, "/posts/1/unpublish/", http.StatusOK},
+		{"unknown action", http.MethodGet, "/posts/1/extra", http.StatusNotFound},
+		{"extra segments", http.MethodGet, "/posts/1/publish/extra", http.StatusNotFound},
+		{"non-numeric id", http.MethodGet, "/posts/abc", http.StatusBadRequest},
+		{"zero id", http.MethodGet, "/posts/0", http.StatusBadRequest},
+		{"negative id", http.MethodGet, "/posts/-5", http.StatusBadRequest},
+		{"negative id action", http.MethodPost, "/posts/-5/publish", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.want {
+				t.Errorf("handler returned wrong status code for %s %s: got %v want %v", tt.method, tt.path, status, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorFilter(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	store.CreatePost(context.Background(), &model.Post{Title: "By Jane", Content: "Content", Author: "Jane"})
+	store.CreatePost(context.Background(), &model.Post{Title: "By Bob", Content: "Content", Author: "bob"})
+	store.CreatePost(context.Background(), &model.Post{Title: "By Alice", Content: "Content", Author: "alice"})
+
+	list := func(query string) (*httptest.ResponseRecorder, []model.Post) {
+		req := httptest.NewRequest(http.MethodGet, "/posts"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var posts []model.Post
+		json.Unmarshal(rr.Body.Bytes(), &posts)
+		return rr, posts
+	}
+
+	t.Run("multiple authors", func(t *testing.T) {
+		rr, posts := list("?author=jane,BOB")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if len(posts) != 2 {
+			t.Fatalf("handler returned wrong number of posts: got %v want %v", len(posts), 2)
+		}
+		for _, p := range posts {
+			if p.Author == "alice" {
+				t.Errorf("handler returned post by unlisted author %q", p.Author)
+			}
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		rr, posts := list("?author=carol")
+		if rr.Body.String() != "[]" || len(posts) != 0 {
+			t.Errorf("handler returned unexpected body: got %q want empty array", rr.Body.String())
+		}
+	})
+
+	t.Run("empty param", func(t *testing.T) {
+		rr, _ := list("?author=")
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestAuthorName(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send(http.MethodPost, "/posts", `{"title":"Hello","content":"Content","author":"jdoe","authorName":"Jane Doe"}`)
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	var created model.Post
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	if created.Author != "jdoe" || created.AuthorName != "Jane Doe" {
+		t.Errorf("handler returned wrong author: got %q (%q) want %q (%q)", created.Author, created.AuthorName, "jdoe", "Jane Doe")
+	}
+
+	t.Run("filter by handle", func(t *testing.T) {
+		var posts []model.Post
+		json.Unmarshal(send(http.MethodGet, "/posts?author=jdoe", "").Body.Bytes(), &posts)
+		if len(posts) != 1 || posts[0].AuthorName != "Jane Doe" {
+			t.Errorf("handler returned wrong posts for the handle: got %+v", posts)
+		}
+
+		json.Unmarshal(send(http.MethodGet, "/posts?author="+url.QueryEscape("Jane Doe"), "").Body.Bytes(), &posts)
+		if len(posts) != 0 {
+			t.Errorf("handler matched the display name: got %+v want none", posts)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		long := strings.Repeat("a", maxAuthorLength+1)
+		for _, body := range []string{
+			`{"title":"Bad","content":"Content","authorName":"Jane Doe"}`,
+			`{"title":"Bad","content":"Content","author":"jdoe,bob"}`,
+			`{"title":"Bad","content":"Content","author":"` + long + `"}`,
+			`{"title":"Bad","content":"Content","author":"jdoe","authorName":"` + long + `"}`,
+		} {
+			if status := send(http.MethodPost, "/posts", body).Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", body, status, http.StatusUnprocessableEntity)
+			}
+		}
+	})
+}
+
+func TestSearchTermLength(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	store.CreatePost(context.Background(), &model.Post{Title: "Go tips", Content: "Content"})
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"too short", "?term=g", http.StatusBadRequest},
+		{"acceptable", "?term=go", http.StatusOK},
+		{"empty", "?term=", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/posts"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxQueryFilters(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+	handler.MaxQueryFilters = 3
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"within limit", "?term=go&tag=go&category=web&sort=newest&limit=5&offset=0", http.StatusOK},
+		{"over limit", "?term=go&tag=go&category=web&author=jane", http.StatusBadRequest},
+		{"empty values ignored", "?term=go&tag=go&category=web&hasImage=", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/posts"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestIDRange(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	for i := 1; i <= 5; i++ {
+		store.CreatePost(context.Background(), &model.Post{Title: fmt.Sprintf("Post %d", i), Content: "Content"})
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+		ids   []int64
+	}{
+		{"mid range", "?idFrom=2&idTo=4", http.StatusOK, []int64{2, 3, 4}},
+		{"single ID", "?idFrom=3&idTo=3", http.StatusOK, []int64{3}},
+		{"reversed", "?idFrom=4&idTo=2", http.StatusBadRequest, nil},
+		{"not an integer", "?idFrom=abc", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/posts"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.want {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+			if tt.ids == nil {
+				return
+			}
+			var posts []model.Post
+			json.Unmarshal(rr.Body.Bytes(), &posts)
+			var ids []int64
+			for _, p := range posts {
+				ids = append(ids, p.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("handler returned wrong posts: got IDs %v want %v", ids, tt.ids)
+			}
+		})
+	}
+}
+
+func TestSinceDays(t *testing.T) {
+	now := time.Now()
+	var clock time.Time
+	store := database.NewMemoryStore(database.WithClock(func() time.Time { return clock }))
+	handler := NewPostHandler(store)
+	for i, age := range []time.Duration{time.Hour, 3 * 24 * time.Hour, 8 * 24 * time.Hour, 40 * 24 * time.Hour} {
+		clock = now.Add(-age)
+		store.CreatePost(context.Background(), &model.Post{Title: fmt.Sprintf("Post %d", i+1), Content: "Content"})
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+		ids   []int64
+	}{
+		{"last day", "?sinceDays=1", http.StatusOK, []int64{1}},
+		{"last week", "?sinceDays=7", http.StatusOK, []int64{1, 2}},
+		{"last year", "?sinceDays=365", http.StatusOK, []int64{1, 2, 3, 4}},
+		{"later from wins", "?sinceDays=30&from=" + now.Add(-2*24*time.Hour).Format(time.RFC3339), http.StatusOK, []int64{1}},
+		{"zero", "?sinceDays=0", http.StatusBadRequest, nil},
+		{"too many", "?sinceDays=1000", http.StatusBadRequest, nil},
+		{"not an integer", "?sinceDays=week", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/posts"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.want {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+			if tt.ids == nil {
+				return
+			}
+			var posts []model.Post
+			json.Unmarshal(rr.Body.Bytes(), &posts)
+			var ids []int64
+			for _, p := range posts {
+				ids = append(ids, p.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("handler returned wrong posts: got IDs %v want %v", ids, tt.ids)
+			}
+		})
+	}
+}
+
+func TestRecentPosts(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := base
+	store := database.NewMemoryStore(database.WithClock(func() time.Time { return clock }))
+	handler := NewPostHandler(store)
+
+	for i := 1; i <= 4; i++ {
+		clock = base.Add(time.Duration(i) * time.Hour)
+		store.CreatePost(context.Background(), &model.Post{Title: fmt.Sprintf("Post %d", i), Content: "Content"})
+	}
+	store.CreatePost(context.Background(), &model.Post{Title: "Draft", Content: "Content", Status: model.StatusDraft})
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/recent?limit=3", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var posts []model.Post
+	json.Unmarshal(rr.Body.Bytes(), &posts)
+	var ids []int64
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	if fmt.Sprint(ids) != "[4 3 2]" {
+		t.Errorf("handler returned wrong posts: got %v want %v", ids, "[4 3 2]")
+	}
+
+	t.Run("invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts/recent?limit=0", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestMostCommented(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	seed := []struct {
+		title            string
+		status           string
+		approved, queued int
+	}{
+		{"Two older", model.StatusPublished, 2, 3},
+		{"Three", model.StatusPublished, 3, 0},
+		{"Two newer", model.StatusPublished, 2, 0},
+		{"Busy draft", model.StatusDraft, 5, 0},
+		{"Quiet", model.StatusPublished, 0, 1},
+	}
+	for _, p := range seed {
+		id, _ := store.CreatePost(ctx, &model.Post{Title: p.title, Content: "Content", Status: p.status})
+		for i := 0; i < p.approved+p.queued; i++ {
+			comment, _ := store.AddComment(ctx, id, &model.Comment{Content: "Comment"})
+			if i < p.approved {
+				store.ApproveComment(ctx, id, comment.ID)
+			}
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/most-commented?limit=10", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var posts []model.Post
+	json.Unmarshal(rr.Body.Bytes(), &posts)
+	var got []string
+	for _, p := range posts {
+		got = append(got, fmt.Sprintf("%s:%d", p.Title, p.CommentCount))
+	}
+	if want := []string{"Three:3", "Two newer:2", "Two older:2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("handler returned wrong ranking: got %v want %v", got, want)
+	}
+}
+
+func TestStrictText(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.StrictText = true
+	store.CreatePost(context.Background(), &model.Post{Title: "Existing", Content: "Content"})
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"invalid UTF-8", "{\"title\":\"Bad \xff\xfe title\",\"content\":\"Content\"}", "title"},
+		{"NUL byte", `{"title":"Title","content":"Nul \u0000 byte"}`, "content"},
+		{"control character", `{"title":"Title","content":"Content","tags":["bell\u0007"]}`, "tags"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, method := range []string{http.MethodPost, http.MethodPut} {
+				path := "/posts"
+				if method == http.MethodPut {
+					path = "/posts/1"
+				}
+				rr := send(method, path, tt.body)
+				if status := rr.Code; status != http.StatusUnprocessableEntity {
+					t.Fatalf("%s: handler returned wrong status code: got %v want %v", method, status, http.StatusUnprocessableEntity)
+				}
+				if !strings.Contains(rr.Body.String(), `{"field":"`+tt.field+`","message":"invalid characters"}`) {
+					t.Errorf("%s: handler returned wrong errors: got %s want %q invalid", method, rr.Body.String(), tt.field)
+				}
+			}
+		})
+	}
+
+	t.Run("whitespace allowed", func(t *testing.T) {
+		if status := send(http.MethodPost, "/posts", `{"title":"Title","content":"Line one\n\tLine two\r\n"}`).Code; status != http.StatusCreated {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		handler.StrictText = false
+		defer func() { handler.StrictText = true }()
+		if status := send(http.MethodPost, "/posts", tests[0].body).Code; status != http.StatusCreated {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+	})
+}
+
+func TestOnThisDay(t *testing.T) {
+	var clock time.Time
+	store := database.NewMemoryStore(database.WithClock(func() time.Time { return clock }))
+	handler := NewPostHandler(store)
+
+	// Whole multiples of four years keep the date valid when the test runs
+	// on February 29.
+	now := time.Now().UTC()
+	today := func(yearsAgo int) time.Time {
+		return time.Date(now.Year()-yearsAgo, now.Month(), now.Day(), 12, 0, 0, 0, time.UTC)
+	}
+	for _, p := range []struct {
+		title     string
+		status    string
+		createdAt time.Time
+	}{
+		{"Four years ago", model.StatusPublished, today(4)},
+		{"Eight years ago", model.StatusPublished, today(8)},
+		{"This year", model.StatusPublished, today(0)},
+		{"Day before", model.StatusPublished, today(4).AddDate(0, 0, -1)},
+		{"Draft", model.StatusDraft, today(4)},
+	} {
+		clock = p.createdAt
+		store.CreatePost(context.Background(), &model.Post{Title: p.title, Content: "Content", Status: p.status})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/on-this-day", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var posts []model.Post
+	json.Unmarshal(rr.Body.Bytes(), &posts)
+	if len(posts) != 2 || posts[0].Title != "Four years ago" || posts[1].Title != "Eight years ago" {
+		t.Errorf("handler returned wrong posts: got %+v want %q and %q", posts, "Four years ago", "Eight years ago")
+	}
+
+	t.Run("none", func(t *testing.T) {
+		handler := NewPostHandler(database.NewMemoryStore())
+		req := httptest.NewRequest(http.MethodGet, "/posts/on-this-day", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Body.String() != "[]" {
+			t.Errorf("handler returned unexpected body: got %q want %q", rr.Body.String(), "[]")
+		}
+	})
+}
+
+func TestNeighbors(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := base
+	store := database.NewMemoryStore(database.WithClock(func() time.Time { return clock }))
+	handler := NewPostHandler(store)
+
+	// IDs don't follow creation order, and a draft between the posts is
+	// skipped.
+	for i, hours := range []int{3, 1, 2} {
+		clock = base.Add(time.Duration(hours) * time.Hour)
+		store.CreatePost(context.Background(), &model.Post{Title: fmt.Sprintf("Post %d", i+1), Content: "Content"})
+	}
+	clock = base.Add(90 * time.Minute)
+	store.CreatePost(context.Background(), &model.Post{Title: "Draft", Content: "Content", Status: model.StatusDraft})
+
+	get := func(path string) (*httptest.ResponseRecorder, neighbors) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		var resp neighbors
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr, resp
+	}
+
+	rr, resp := get("/posts/3/neighbors")
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if resp.Previous == nil || resp.Previous.ID != 2 || resp.Next == nil || resp.Next.ID != 1 {
+		t.Errorf("handler returned wrong neighbors: got %+v, %+v want 2, 1", resp.Previous, resp.Next)
+	}
+
+	t.Run("ends", func(t *testing.T) {
+		if _, resp := get("/posts/2/neighbors"); resp.Previous != nil || resp.Next == nil || resp.Next.ID != 3 {
+			t.Errorf("oldest post: got %+v, %+v want nil, 3", resp.Previous, resp.Next)
+		}
+		rr, _ := get("/posts/1/neighbors")
+		if body := rr.Body.String(); !strings.Contains(body, `"next":null`) {
+			t.Errorf("newest post: body %s has no null next", body)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if rr, _ := get("/posts/99/neighbors"); rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
+
+func TestUpsertPost(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	put := func(path string, title string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"title": title, "content": "Content"})
+		req := httptest.NewRequest(http.MethodPut, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("without upsert", func(t *testing.T) {
+		if status := put("/posts/5", "Missing").Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+
+	t.Run("creates missing post", func(t *testing.T) {
+		rr := put("/posts/5?upsert=true", "Upserted")
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+
+		var post model.Post
+		json.Unmarshal(rr.Body.Bytes(), &post)
+		if post.ID != 5 || post.Title != "Upserted" {
+			t.Errorf("handler returned wrong post: got id %v title %q", post.ID, post.Title)
+		}
+	})
+
+	t.Run("updates existing post", func(t *testing.T) {
+		if status := put("/posts/5?upsert=true", "Upserted again").Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+	})
+
+	t.Run("later create does not collide", func(t *testing.T) {
+		id, _ := store.CreatePost(context.Background(), &model.Post{Title: "Fresh", Content: "Content"})
+		if id != 6 {
+			t.Errorf("store assigned wrong ID: got %v want %v", id, 6)
+		}
+	})
+}
+
+func TestBulkPublish(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	draftID, _ := store.CreatePost(context.Background(), &model.Post{Title: "Draft", Content: "Content", Status: model.StatusDraft})
+	publishedID, _ := store.CreatePost(context.Background(), &model.Post{Title: "Published", Content: "Content"})
+
+	body, _ := json.Marshal(map[string]interface{}{"ids": []int64{draftID, publishedID, 999}})
+	req := httptest.NewRequest(http.MethodPost, "/posts/bulk-publish", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var resp struct {
+		Results []database.BulkResult `json:"results"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	want := []string{database.BulkPublished, database.BulkSkipped, database.BulkNotFound}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("handler returned wrong number of results: got %v want %v", len(resp.Results), len(want))
+	}
+	for i, result := range resp.Results {
+		if result.Result != want[i] {
+			t.Errorf("result %d for id %d: got %v want %v", i, result.ID, result.Result, want[i])
+		}
+	}
+
+	if post, _ := store.GetPost(context.Background(), draftID); post.Status != model.StatusPublished {
+		t.Errorf("draft was not published: got status %v", post.Status)
+	}
+
+	t.Run("empty ids", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/posts/bulk-publish", bytes.NewReader([]byte(`{"ids":[]}`)))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+		}
+	})
+}
+
+func TestPreferMinimal(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	store.CreatePost(ctx, &model.Post{Title: "Published", Content: "Content"})
+	store.CreatePost(ctx, &model.Post{Title: "Draft", Content: "Content", Status: model.StatusDraft})
+
+	do := func(method, path, body, prefer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if prefer != "" {
+			req.Header.Set("Prefer", prefer)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		return rr
+	}
+
+	t.Run("list", func(t *testing.T) {
+		rr := do(http.MethodGet, "/posts", "", "respond-async, RETURN=minimal")
+		if got, want := rr.Body.String(), `[{"id":1}]`; got != want {
+			t.Errorf("handler returned wrong body: got %s want %s", got, want)
+		}
+		if got := rr.Header().Get("Preference-Applied"); got != "return=minimal" {
+			t.Errorf("wrong Preference-Applied header: got %q want %q", got, "return=minimal")
+		}
+
+		rr = do(http.MethodGet, "/posts", "", "return=representation")
+		if !strings.Contains(rr.Body.String(), `"title":"Published"`) || rr.Header().Get("Preference-Applied") != "" {
+			t.Errorf("handler returned a minimal response without the preference: %s", rr.Body.String())
+		}
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		rr := do(http.MethodPost, "/posts/bulk-publish", `{"ids":[1,2,99]}`, "return=minimal")
+		if got, want := rr.Body.String(), `{"ids":[2]}`; got != want {
+			t.Errorf("handler returned wrong body: got %s want %s", got, want)
+		}
+		if got := rr.Header().Get("Preference-Applied"); got != "return=minimal" {
+			t.Errorf("wrong Preference-Applied header: got %q want %q", got, "return=minimal")
+		}
+	})
+}
+
+func TestLookupPosts(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	first, _ := store.CreatePost(ctx, &model.Post{Title: "First", Content: "Content"})
+	trashed, _ := store.CreatePost(ctx, &model.Post{Title: "Trashed", Content: "Content"})
+	draft, _ := store.CreatePost(ctx, &model.Post{Title: "Draft", Content: "Content", Status: model.StatusDraft})
+	store.DeletePost(ctx, trashed)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/posts/lookup", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("present and absent", func(t *testing.T) {
+		rr := send(fmt.Sprintf(`{"ids": [%d, %d, %d, 99]}`, first, trashed, draft))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var posts map[string]model.Post
+		if err := json.Unmarshal(rr.Body.Bytes(), &posts); err != nil {
+			t.Fatalf("could not decode response: %v", err)
+		}
+		keys := make([]string, 0, len(posts))
+		for key := range posts {
+			keys = append(keys, key)
+		}
+		if len(posts) != 2 {
+			t.Fatalf("handler returned wrong keys: got %v want [1 3]", keys)
+		}
+		if posts["1"].Title != "First" || posts["3"].Title != "Draft" {
+			t.Errorf("handler returned wrong posts: got %q, %q", posts["1"].Title, posts["3"].Title)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tooMany := make([]int64, maxBulkIDs+1)
+		for i := range tooMany {
+			tooMany[i] = int64(i + 1)
+		}
+		body, _ := json.Marshal(map[string][]int64{"ids": tooMany})
+		for _, body := range []string{`{"ids": []}`, string(body), `{"ids": [0]}`, `{"ids": ["abc"]}`} {
+			if status := send(body).Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", body, status, http.StatusUnprocessableEntity)
+			}
+		}
+		if status := send(`{"ids":`).Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("string ids", func(t *testing.T) {
+		var posts map[string]model.Post
+		json.Unmarshal(send(`{"ids": ["1"]}`).Body.Bytes(), &posts)
+		if len(posts) != 1 || posts["1"].Title != "First" {
+			t.Errorf("handler returned wrong posts: got %v want post 1", posts)
+		}
+	})
+
+	t.Run("tokens", func(t *testing.T) {
+		codec := hashid.New("secret")
+		model.SetIDCodec(codec)
+		t.Cleanup(func() { model.SetIDCodec(nil) })
+		handler.IDCodec = codec
+		t.Cleanup(func() { handler.IDCodec = nil })
+
+		token := codec.Encode(first)
+		rr := send(fmt.Sprintf(`{"ids": [%q, %q]}`, token, codec.Encode(99)))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var posts map[string]json.RawMessage
+		json.Unmarshal(rr.Body.Bytes(), &posts)
+		if _, ok := posts[token]; !ok || len(posts) != 1 {
+			t.Errorf("handler returned wrong keys: got %s want only %q", rr.Body.String(), token)
+		}
+		// An integer is read as a token, which names some other ID or none.
+		rr = send(fmt.Sprintf(`{"ids": [%d]}`, first))
+		if strings.Contains(rr.Body.String(), `"First"`) {
+			t.Errorf("handler served a post for the integer ID: got %s", rr.Body.String())
+		}
+	})
+}
+
+func TestBulkSetStatus(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	draftID, _ := store.CreatePost(ctx, &model.Post{Title: "Draft", Content: "Content", Status: model.StatusDraft})
+	publishedID, _ := store.CreatePost(ctx, &model.Post{Title: "Published", Content: "Content"})
+	deletedID, _ := store.CreatePost(ctx, &model.Post{Title: "Deleted", Content: "Content", Status: model.StatusDraft})
+	store.DeletePost(ctx, deletedID)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/posts/bulk-status", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	results := func(rr *httptest.ResponseRecorder) []database.BulkResult {
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var resp struct {
+			Results []database.BulkResult `json:"results"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return resp.Results
+	}
+
+	t.Run("publish", func(t *testing.T) {
+		got := results(send(fmt.Sprintf(`{"ids":[%d,%d,%d,999],"status":"published"}`, draftID, publishedID, deletedID)))
+		want := []string{database.BulkUpdated, database.BulkSkipped, database.BulkRejected, database.BulkNotFound}
+		if len(got) != len(want) {
+			t.Fatalf("handler returned wrong number of results: got %v want %v", len(got), len(want))
+		}
+		for i, result := range got {
+			if result.Result != want[i] {
+				t.Errorf("result %d for id %d: got %v want %v", i, result.ID, result.Result, want[i])
+			}
+		}
+		if got[2].Reason == "" {
+			t.Error("rejected result has no reason")
+		}
+		if post, _ := store.GetPost(ctx, draftID); post.Status != model.StatusPublished || post.PublishedAt == nil {
+			t.Errorf("draft was not published: got status %v", post.Status)
+		}
+	})
+
+	t.Run("schedule without publishAt", func(t *testing.T) {
+		got := results(send(fmt.Sprintf(`{"ids":[%d],"status":"scheduled"}`, publishedID)))
+		if len(got) != 1 || got[0].Result != database.BulkRejected {
+			t.Errorf("handler returned wrong results: got %+v want a rejection", got)
+		}
+	})
+
+	t.Run("unpublish", func(t *testing.T) {
+		got := results(send(fmt.Sprintf(`{"ids":[%d],"status":"draft"}`, publishedID)))
+		if len(got) != 1 || got[0].Result != database.BulkUpdated {
+			t.Errorf("handler returned wrong results: got %+v want an update", got)
+		}
+		if post, _ := store.GetPost(ctx, publishedID); post.Status != model.StatusDraft || post.PublishedAt != nil {
+			t.Errorf("post was not unpublished: got status %v", post.Status)
+		}
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		if status := send(`{"ids":[1],"status":"archived"}`).Code; status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+		}
+	})
+}
+
+func TestUncategorizedFilter(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	store.CreatePost(context.Background(), &model.Post{Title: "Tech", Content: "Content", Category: "Technology"})
+	for i := 0; i < 3; i++ {
+		store.CreatePost(context.Background(), &model.Post{Title: fmt.Sprintf("Import %d", i), Content: "Content", Category: "  "})
+	}
+
+	list := func(query string) (*httptest.ResponseRecorder, []model.Post) {
+		req := httptest.NewRequest(http.MethodGet, "/posts"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var posts []model.Post
+		json.Unmarshal(rr.Body.Bytes(), &posts)
+		return rr, posts
+	}
+
+	t.Run("only uncategorized", func(t *testing.T) {
+		_, posts := list("?uncategorized=true")
+		if len(posts) != 3 {
+			t.Fatalf("handler returned wrong number of posts: got %v want %v", len(posts), 3)
+		}
+		for _, p := range posts {
+			if p.Category == "Technology" {
+				t.Errorf("handler returned categorized post %d", p.ID)
+			}
+		}
+	})
+
+	t.Run("paginated", func(t *testing.T) {
+		rr, posts := list("?uncategorized=true&limit=2&offset=2")
+		if len(posts) != 1 || posts[0].ID != 4 {
+			t.Errorf("handler returned wrong page: got %v", posts)
+		}
+		if total := rr.Header().Get("X-Total-Count"); total != "3" {
+			t.Errorf("handler returned wrong X-Total-Count: got %v want %v", total, "3")
+		}
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		rr, _ := list("?limit=0")
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestListDrafts(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := base
+	store := database.NewMemoryStore(database.WithClock(func() time.Time { return clock }))
+	handler := NewPostHandler(store)
+
+	seed := []struct {
+		author string
+		status string
+	}{
+		{"jane", model.StatusDraft},
+		{"bob", model.StatusDraft},
+		{"jane", model.StatusDraft},
+		{"jane", model.StatusPublished},
+	}
+	for i, s := range seed {
+		clock = base.Add(time.Duration(i) * time.Hour)
+		store.CreatePost(context.Background(), &model.Post{Title: fmt.Sprintf("Post %d", i+1), Content: "Content", Author: s.author, Status: s.status})
+	}
+
+	t.Run("author drafts newest first", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts/drafts?author=Jane", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		var posts []model.Post
+		json.Unmarshal(rr.Body.Bytes(), &posts)
+		var ids []int64
+		for _, p := range posts {
+			ids = append(ids, p.ID)
+		}
+		if fmt.Sprint(ids) != "[3 1]" {
+			t.Errorf("handler returned wrong drafts: got %v want %v", ids, "[3 1]")
+		}
+	})
+
+	t.Run("author required", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts/drafts", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestListScheduled(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	ctx := context.Background()
+
+	at := func(d time.Duration) *time.Time {
+		when := time.Now().Add(d)
+		return &when
+	}
+	for _, p := range []*model.Post{
+		{Title: "In two days", Status: model.StatusScheduled, PublishAt: at(48 * time.Hour)},
+		{Title: "Draft", Status: model.StatusDraft, PublishAt: at(time.Hour)},
+		{Title: "Tomorrow", Status: model.StatusScheduled, PublishAt: at(24 * time.Hour)},
+		{Title: "Published", Status: model.StatusPublished, PublishAt: at(2 * time.Hour)},
+		// The scheduler hasn't published this one yet, but it is no longer
+		// queued ahead.
+		{Title: "Overdue", Status: model.StatusScheduled, PublishAt: at(-time.Minute)},
+	} {
+		p.Content = "Content"
+		store.CreatePost(ctx, p)
+	}
+
+	list := func(admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/posts/scheduled", nil)
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := list(true)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var posts []model.Post
+	json.Unmarshal(rr.Body.Bytes(), &posts)
+	var titles []string
+	for _, p := range posts {
+		titles = append(titles, p.Title)
+	}
+	if want := []string{"Tomorrow", "In two days"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("handler returned wrong queue: got %v want %v", titles, want)
+	}
+	if got := rr.Header().Get("X-Total-Count"); got != "2" {
+		t.Errorf("handler returned wrong X-Total-Count: got %q want %q", got, "2")
+	}
+
+	if status := list(false).Code; status != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code without the token: got %v want %v", status, http.StatusUnauthorized)
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	long := strings.Repeat("filler ", 40) + "the <b>needle</b> appears here " + strings.Repeat("padding ", 40)
+	store.CreatePost(context.Background(), &model.Post{Title: "Haystack", Content: long})
+	store.CreatePost(context.Background(), &model.Post{Title: "Needle in the title", Content: "Nothing to see."})
+
+	req := httptest.NewRequest(http.MethodGet, "/posts?term=needle&snippet=true", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var posts []model.Post
+	json.Unmarshal(rr.Body.Bytes(), &posts)
+	if len(posts) != 2 {
+		t.Fatalf("handler returned wrong number of posts: got %v want %v", len(posts), 2)
+	}
+
+	snippets := make(map[string]string)
+	for _, p := range posts {
+		snippets[p.Title] = p.Snippet
+	}
+
+	snippet := snippets["Haystack"]
+	if !strings.Contains(snippet, "the <mark>needle</mark> appears") {
+		t.Errorf("snippet does not mark the term: %q", snippet)
+	}
+	if !strings.HasPrefix(snippet, "…") || !strings.HasSuffix(snippet, "…") {
+		t.Errorf("snippet is not truncated on both sides: %q", snippet)
+	}
+	if words := len(strings.Fields(snippet)); words > 32 {
+		t.Errorf("snippet has %d words, want about 30", words)
+	}
+	if got := snippets["Needle in the title"]; got != "Nothing to see." {
+		t.Errorf("title match snippet = %q, want the excerpt %q", got, "Nothing to see.")
+	}
+}
+
+func TestSearchComments(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	titleID, _ := store.CreatePost(ctx, &model.Post{Title: "Sourdough basics", Content: "Flour and water."})
+	commentedID, _ := store.CreatePost(ctx, &model.Post{Title: "Bread", Content: "Flour and water."})
+	pendingID, _ := store.CreatePost(ctx, &model.Post{Title: "Baking", Content: "Flour and water."})
+
+	comment, _ := store.AddComment(ctx, commentedID, &model.Comment{Content: "My SOURDOUGH starter loves this."})
+	store.ApproveComment(ctx, commentedID, comment.ID)
+	store.AddComment(ctx, pendingID, &model.Comment{Content: "Sourdough spam"})
+
+	search := func(query string) map[int64]string {
+		req := httptest.NewRequest(http.MethodGet, "/posts?term=sourdough"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var posts []model.Post
+		json.Unmarshal(rr.Body.Bytes(), &posts)
+		matched := make(map[int64]string)
+		for _, p := range posts {
+			matched[p.ID] = p.MatchedField
+		}
+		return matched
+	}
+
+	if got, want := search(""), map[int64]string{titleID: "title"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("search without comments matched %v, want %v", got, want)
+	}
+	if got, want := search("&includeComments=true"), map[int64]string{titleID: "title", commentedID: "comments"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("search with comments matched %v, want %v", got, want)
+	}
+}
+
+func TestListETag(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	store.CreatePost(context.Background(), &model.Post{Title: "First", Content: "Content"})
+
+	list := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	etag := list("/posts", "").Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("handler did not set an ETag")
+	}
+
+	if status := list("/posts", etag).Code; status != http.StatusNotModified {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotModified)
+	}
+	if other := list("/posts?category=tech", "").Header().Get("ETag"); other == etag {
+		t.Errorf("different queries share ETag %s", etag)
+	}
+
+	store.CreatePost(context.Background(), &model.Post{Title: "Second", Content: "Content"})
+	rr := list("/posts", etag)
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if rr.Header().Get("ETag") == etag {
+		t.Errorf("ETag %s unchanged after adding a post", etag)
+	}
+}
+
+func TestPostETag(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	id, _ := store.CreatePost(ctx, &model.Post{
+		Title:        "Hello",
+		Content:      "Hello world",
+		Translations: map[string]model.Translation{"fr": {Title: "Bonjour", Content: "Bonjour le monde"}},
+	})
+
+	get := func(etag, lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/posts/%d", id), nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("pin", func(t *testing.T) {
+		etag := get("", "").Header().Get("ETag")
+		store.PinPost(ctx, id, 1)
+		rr := get(etag, "")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var post model.Post
+		json.Unmarshal(rr.Body.Bytes(), &post)
+		if post.PinOrder == nil || *post.PinOrder != 1 {
+			t.Errorf("handler returned wrong pinOrder: got %v want %v", post.PinOrder, 1)
+		}
+	})
+
+	t.Run("languages", func(t *testing.T) {
+		english, french := get("", "").Header().Get("ETag"), get("", "fr").Header().Get("ETag")
+		if english == french {
+			t.Errorf("translations share ETag %s", english)
+		}
+		if status := get(english, "fr").Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if status := get(french, "fr").Code; status != http.StatusNotModified {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotModified)
+		}
+	})
+}
+
+func TestDeleteIfMatch(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"})
+
+	do := func(method, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, fmt.Sprintf("/posts/%d", id), nil)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	stale := do(http.MethodGet, "").Header().Get("ETag")
+	if stale == "" {
+		t.Fatal("handler did not set an ETag")
+	}
+	store.UpdatePost(ctx, id, &model.Post{Title: "Edited", Content: "Content"})
+
+	if status := do(http.MethodDelete, stale).Code; status != http.StatusPreconditionFailed {
+		t.Errorf("stale ETag: handler returned wrong status code: got %v want %v", status, http.StatusPreconditionFailed)
+	}
+
+	current := do(http.MethodGet, "").Header().Get("ETag")
+	if current == stale {
+		t.Fatalf("ETag %s unchanged after an update", current)
+	}
+	if status := do(http.MethodDelete, "W/"+current).Code; status != http.StatusPreconditionFailed {
+		t.Errorf("weak ETag: handler returned wrong status code: got %v want %v", status, http.StatusPreconditionFailed)
+	}
+	if status := do(http.MethodDelete, current).Code; status != http.StatusNoContent {
+		t.Errorf("current ETag: handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+	}
+	if status := do(http.MethodDelete, current).Code; status != http.StatusNotFound {
+		t.Errorf("deleted post: handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+}
+
+func TestContentTypeEnforcement(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	body := `{"title":"Title","content":"Content"}`
+
+	tests := []struct {
+		name        string
+		contentType string
+		want        int
+	}{
+		{"json", "application/json", http.StatusCreated},
+		{"json with charset", "application/json; charset=utf-8", http.StatusCreated},
+		{"plain text", "text/plain", http.StatusUnsupportedMediaType},
+		{"form", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"missing", "", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/posts?allowDuplicate=true", strings.NewReader(body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		handler.RequireJSONContentType = false
+		req := httptest.NewRequest(http.MethodPost, "/posts?allowDuplicate=true", strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/plain")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusCreated {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+	})
+}
+
+func TestListNDJSON(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	for i := 0; i < 5; i++ {
+		category := "tech"
+		if i%2 == 1 {
+			category = "travel"
+		}
+		store.CreatePost(context.Background(), &model.Post{Title: fmt.Sprintf("Post %d", i), Content: "Content", Category: category})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/posts?format=ndjson&category=tech", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("handler returned wrong Content-Type: got %v want %v", ct, "application/x-ndjson")
+	}
+
+	scanner := bufio.NewScanner(rr.Body)
+	records := 0
+	for scanner.Scan() {
+		var post model.Post
+		if err := json.Unmarshal(scanner.Bytes(), &post); err != nil {
+			t.Fatalf("line %d is not a post: %v", records+1, err)
+		}
+		if post.Category != "tech" {
+			t.Errorf("stream included post with category %q", post.Category)
+		}
+		records++
+	}
+	if records != 3 {
+		t.Errorf("stream has wrong number of records: got %v want %v", records, 3)
+	}
+
+	t.Run("draining", func(t *testing.T) {
+		draining := make(chan struct{})
+		close(draining)
+		handler.Draining = draining
+		defer func() { handler.Draining = nil }()
+
+		req := httptest.NewRequest(http.MethodGet, "/posts?format=ndjson", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK || rr.Body.Len() != 0 {
+			t.Errorf("draining stream = %v %q, want 200 and no records", rr.Code, rr.Body.String())
+		}
+	})
+}
+
+func TestPostBounds(t *testing.T) {
+	var clock time.Time
+	store := database.NewMemoryStore(database.WithClock(func() time.Time { return clock }))
+	handler := NewPostHandler(store)
+
+	bounds := func() map[string]*string {
+		req := httptest.NewRequest(http.MethodGet, "/posts/bounds", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var resp map[string]*string
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return resp
+	}
+
+	if resp := bounds(); resp["first"] != nil || resp["last"] != nil {
+		t.Errorf("handler returned bounds for an empty store: %v", resp)
+	}
+
+	dates := []time.Time{
+		time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 1, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+	}
+	for _, date := range dates {
+		clock = date
+		store.CreatePost(context.Background(), &model.Post{Title: "Post", Content: "Content"})
+	}
+	clock = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.CreatePost(context.Background(), &model.Post{Title: "Draft", Content: "Content", Status: model.StatusDraft})
+
+	resp := bounds()
+	if got, want := resp["first"], model.FormatTime(dates[1]); got == nil || *got != want {
+		t.Errorf("handler returned wrong first: got %v want %v", got, want)
+	}
+	if got, want := resp["last"], model.FormatTime(dates[2]); got == nil || *got != want {
+		t.Errorf("handler returned wrong last: got %v want %v", got, want)
+	}
+}
+
+func TestMaxPostsPerAuthor(t *testing.T) {
+	store := database.NewMemoryStore(database.WithMaxPostsPerAuthor(2))
+	handler := NewPostHandler(store)
+
+	create := func(author string, n int) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"title":"Post %d by %s","content":"Content","author":%q}`, n, author, author)
+		req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 1; i <= 2; i++ {
+		if status := create("jane", i).Code; status != http.StatusCreated {
+			t.Fatalf("post %d: handler returned wrong status code: got %v want %v", i, status, http.StatusCreated)
+		}
+	}
+	rr := create("Jane", 3)
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code at the limit: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+	if !strings.Contains(rr.Body.String(), `"field":"author"`) {
+		t.Errorf("handler returned %s, want an author field error", rr.Body.String())
+	}
+	if status := create("bob", 1).Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code for another author: got %v want %v", status, http.StatusCreated)
+	}
+}
+
+func TestSlugConflict(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := send(http.MethodPost, "/posts", `{"title":"First","content":"Content","slug":"hello-world"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+	}
+
+	t.Run("create with taken slug", func(t *testing.T) {
+		rr := send(http.MethodPost, "/posts", `{"title":"Second","content":"Content","slug":"hello-world"}`)
+		if status := rr.Code; status != http.StatusConflict {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+		}
+		var resp map[string]interface{}
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		if resp["conflictingId"] != float64(1) {
+			t.Errorf("handler returned wrong conflictingId: got %v want %v", resp["conflictingId"], 1)
+		}
+	})
+
+	t.Run("auto slugs disambiguate", func(t *testing.T) {
+		rr := send(http.MethodPost, "/posts?allowDuplicate=true", `{"title":"Hello, World!","content":"Content"}`)
+		var post model.Post
+		json.Unmarshal(rr.Body.Bytes(), &post)
+		if post.Slug != "hello-world-2" {
+			t.Errorf("handler returned wrong slug: got %q want %q", post.Slug, "hello-world-2")
+		}
+	})
+
+	t.Run("update to taken slug", func(t *testing.T) {
+		rr := send(http.MethodPut, "/posts/2", `{"title":"Hello, World!","content":"Content","slug":"hello-world"}`)
+		if status := rr.Code; status != http.StatusConflict {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+		}
+		rr = send(http.MethodPut, "/posts/1", `{"title":"First","content":"Edited","slug":"hello-world"}`)
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code keeping own slug: got %v want %v", status, http.StatusOK)
+		}
+	})
+
+	t.Run("invalid slug", func(t *testing.T) {
+		rr := send(http.MethodPost, "/posts", `{"title":"Third","content":"Content","slug":"Not A Slug"}`)
+		if status := rr.Code; status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+		}
+	})
+}
+
+func TestPrettyJSON(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content"})
+
+	for _, path := range []string{"/posts", "/posts/1"} {
+		t.Run(path, func(t *testing.T) {
+			get := func(query string) string {
+				req := httptest.NewRequest(http.MethodGet, path+query, nil)
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+				if status := rr.Code; status != http.StatusOK {
+					t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+				}
+				return strings.TrimSuffix(rr.Body.String(), "\n")
+			}
+
+			if body := get(""); strings.Contains(body, "\n") {
+				t.Errorf("default response is indented: %q", body)
+			}
+			if body := get("?pretty=true"); !strings.Contains(body, "\n  ") {
+				t.Errorf("pretty response is not indented: %q", body)
+			}
+		})
+	}
+}
+
+func TestSearchRelevance(t *testing.T) {
+	store := database.NewMemoryStore(database.WithDefaultSort(database.SortNewest))
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	store.CreatePost(ctx, &model.Post{Title: "Notes", Content: "Some golang tips"})
+	store.CreatePost(ctx, &model.Post{Title: "Golang tips", Content: "Tips"})
+	store.CreatePost(ctx, &model.Post{Title: "More notes", Content: "More golang", Tags: []string{"golang"}})
+	// Matching everywhere but the title still ranks below a title match.
+	store.CreatePost(ctx, &model.Post{Title: "Misc", Content: "Golang", Category: "golang", Tags: []string{"golang"}})
+
+	ids := func(path string) []int64 {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var posts []model.Post
+		json.Unmarshal(rr.Body.Bytes(), &posts)
+		ids := make([]int64, len(posts))
+		for i, post := range posts {
+			ids[i] = post.ID
+		}
+		return ids
+	}
+
+	if got, want := ids("/posts?term=golang&sort=relevance"), []int64{2, 4, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("handler returned wrong order: got %v want %v", got, want)
+	}
+	if got, want := ids("/posts?term=golang"), []int64{4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DEFAULT_SORT: handler returned wrong order: got %v want %v", got, want)
+	}
+}
+
+func TestIDsAsStringsParam(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content", AllowComments: true})
+	store.AddComment(ctx, 1, &model.Comment{Author: "ana", Content: "Nice"})
+	store.ApproveComment(ctx, 1, 1)
+
+	get := func(path string) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		return rr.Body.String()
+	}
+
+	tests := []struct {
+		path         string
+		idsAsStrings bool
+		want         string
+	}{
+		{"/posts/1", false, `"id":1,`},
+		{"/posts/1?idsAsStrings=true", false, `"id":"1",`},
+		{"/posts/1?idsAsStrings=true&pretty=true", false, `"id": "1",`},
+		{"/posts?idsAsStrings=true&format=ndjson", false, `"id":"1",`},
+		{"/posts/1?idsAsStrings=true", false, `"wordCount":1,`},
+		{"/posts/1/comments", false, `"postId":1,`},
+		{"/posts/1/comments?idsAsStrings=true", false, `"postId":"1",`},
+		{"/posts/1", true, `"id":"1",`},
+		{"/posts/1?idsAsStrings=false", true, `"id":1,`},
+		{"/posts/1?idsAsStrings=bogus", true, `"id":"1",`},
+	}
+	for _, tt := range tests {
+		handler.IDsAsStrings = tt.idsAsStrings
+		if body := get(tt.path); !strings.Contains(body, tt.want) {
+			t.Errorf("%s (IDsAsStrings %v): handler returned %s, want it to contain %s", tt.path, tt.idsAsStrings, body, tt.want)
+		}
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	store.CreatePost(context.Background(), &model.Post{Title: "Title", Content: "Content", Tags: []string{"go"}})
+
+	tests := []struct {
+		name, method, path, body string
+	}{
+		{"list", http.MethodGet, "/posts", ""},
+		{"post", http.MethodGet, "/posts/1", ""},
+		{"create", http.MethodPost, "/posts", `{"title":"Another","content":"Content"}`},
+		{"validation errors", http.MethodPost, "/posts", `{"title":"","content":""}`},
+		{"comments", http.MethodGet, "/posts/1/comments", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if body := rr.Body.String(); body == "" || strings.HasSuffix(body, "\n") {
+				t.Errorf("handler returned body with trailing newline: %q", body)
+			}
+		})
+	}
+}
+
+func TestCreatePostStoreFull(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore(database.WithMaxPosts(1)))
+
+	create := func(title string) int {
+		req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"title":"`+title+`","content":"Content"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if status := create("First"); status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	if status := create("Second"); status != http.StatusInsufficientStorage {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInsufficientStorage)
+	}
+}
+
+func TestMinContentLength(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore(database.WithMinContentLength(20)))
+
+	send := func(method, path, content string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"title": "Title", "content": content})
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send(http.MethodPost, "/posts", "Oops")
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+	if !strings.Contains(rr.Body.String(), "too short") {
+		t.Errorf("handler returned wrong body: got %s want a too short error", rr.Body.String())
+	}
+	if status := send(http.MethodPost, "/posts", "Twenty characters or more").Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	if status := send(http.MethodPut, "/posts/1", "Oops").Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestDefaultCategory(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+	handler.DefaultCategory = "uncategorized"
+
+	create := func(title, category string) model.Post {
+		body, _ := json.Marshal(map[string]interface{}{"title": title, "content": "Content", "category": category})
+		req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+		var post model.Post
+		json.Unmarshal(rr.Body.Bytes(), &post)
+		return post
+	}
+
+	if post := create("No category", "  "); post.Category != "uncategorized" {
+		t.Errorf("handler returned wrong category: got %q want %q", post.Category, "uncategorized")
+	}
+	if post := create("Has category", "Travel"); post.Category != "Travel" {
+		t.Errorf("handler returned wrong category: got %q want %q", post.Category, "Travel")
+	}
+
+	handler.DefaultCategory = ""
+	if post := create("Disabled default", ""); post.Category != "" {
+		t.Errorf("handler returned wrong category: got %q want empty", post.Category)
+	}
+}
+
+func TestWebhookOnCreate(t *testing.T) {
+	deliveries := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- r
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	handler := NewPostHandler(database.NewMemoryStore())
+	handler.Webhook = webhook.New(receiver.URL, "secret", slog.Default())
+
+	req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"title":"Hooked","content":"Content"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	select {
+	case delivery := <-deliveries:
+		body := <-bodies
+		if got := delivery.Header.Get(webhook.EventHeader); got != webhook.PostCreated {
+			t.Errorf("webhook has wrong event: got %q want %q", got, webhook.PostCreated)
+		}
+		if got, want := delivery.Header.Get(webhook.SignatureHeader), webhook.Sign("secret", body); got != want {
+			t.Errorf("webhook has wrong signature: got %q want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
+
+func TestStatusSummary(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	publishAt := time.Now().Add(time.Hour)
+	seed := []*model.Post{
+		{Title: "Published one", Content: "Content"},
+		{Title: "Published two", Content: "Content"},
+		{Title: "Draft", Content: "Content", Status: model.StatusDraft},
+		{Title: "Scheduled", Content: "Content", Status: model.StatusScheduled, PublishAt: &publishAt},
+		{Title: "Deleted", Content: "Content"},
+	}
+	var lastID int64
+	for _, post := range seed {
+		lastID, _ = store.CreatePost(ctx, post)
+	}
+	store.DeletePost(ctx, lastID)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/status-summary", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var counts map[string]int
+	json.Unmarshal(rr.Body.Bytes(), &counts)
+	want := map[string]int{"draft": 1, "published": 2, "scheduled": 1, "deleted": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("handler returned wrong counts: got %v want %v", counts, want)
+	}
+}
+
+func TestEmptyList(t *testing.T) {
+	tests := []struct {
+		name       string
+		noContent  bool
+		wantStatus int
+		wantBody   string
+	}{
+		{"empty array by default", false, http.StatusOK, "[]"},
+		{"no content when configured", true, http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPostHandler(database.NewMemoryStore())
+			handler.EmptyListNoContent = tt.noContent
+
+			routes := map[string]http.HandlerFunc{
+				"/posts":               handler.ServeHTTP,
+				"/posts?format=ndjson": handler.ServeHTTP,
+				"/tags/go/posts":       handler.ServeTags,
+			}
+			for path, serve := range routes {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				rr := httptest.NewRecorder()
+				serve(rr, req)
+
+				if status := rr.Code; status != tt.wantStatus {
+					t.Errorf("%s: handler returned wrong status code: got %v want %v", path, status, tt.wantStatus)
+				}
+				if path == "/posts" && rr.Body.String() != tt.wantBody {
+					t.Errorf("%s: handler returned wrong body: got %q want %q", path, rr.Body.String(), tt.wantBody)
+				}
+				if got := rr.Header().Get("X-Total-Count"); got != "0" {
+					t.Errorf("%s: handler returned wrong X-Total-Count: got %q want %q", path, got, "0")
+				}
+			}
+
+			// A missing post is still a 404, not an empty result.
+			req := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestHasImageFilter(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if status := send(http.MethodPost, "/posts", `{"title":"With image","content":"Content","imageUrl":"https://example.com/a.png"}`).Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	if status := send(http.MethodPost, "/posts", `{"title":"Without image","content":"Content"}`).Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		var posts []model.Post
+		json.Unmarshal(send(http.MethodGet, "/posts?hasImage=true", "").Body.Bytes(), &posts)
+		if len(posts) != 1 || posts[0].Title != "With image" {
+			t.Errorf("handler returned wrong posts: got %+v want only %q", posts, "With image")
+		}
+
+		json.Unmarshal(send(http.MethodGet, "/posts", "").Body.Bytes(), &posts)
+		if len(posts) != 2 {
+			t.Errorf("handler returned wrong number of posts without the filter: got %v want %v", len(posts), 2)
+		}
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		for _, imageURL := range []string{"ftp://example.com/a.png", "/relative.png", "not a url"} {
+			rr := send(http.MethodPost, "/posts", `{"title":"Bad image `+imageURL+`","content":"Content","imageUrl":"`+imageURL+`"}`)
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("%q: handler returned wrong status code: got %v want %v", imageURL, status, http.StatusUnprocessableEntity)
+			}
+		}
+		if status := send(http.MethodPut, "/posts/2", `{"title":"Without image","content":"Content","imageUrl":"javascript:alert(1)"}`).Code; status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code on update: got %v want %v", status, http.StatusUnprocessableEntity)
+		}
+	})
+}
+
+func TestLinksToFilter(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	for _, p := range []struct{ title, content string }{
+		{"Links", `See <a href="https://Example.com/Guide">the guide</a>.`},
+		{"Also links", "Plain text mention of https://example.com/guide."},
+		{"Elsewhere", `See <a href="https://example.org/other">another page</a>.`},
+	} {
+		store.CreatePost(ctx, &model.Post{Title: p.title, Content: p.content, Status: model.StatusPublished})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/posts?linksTo="+url.QueryEscape("https://example.com/guide"), nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var posts []model.Post
+	json.Unmarshal(rr.Body.Bytes(), &posts)
+	if len(posts) != 2 || posts[0].Title != "Links" || posts[1].Title != "Also links" {
+		t.Errorf("handler returned wrong posts: got %+v want %q and %q", posts, "Links", "Also links")
+	}
+}
+
+func TestIDObfuscation(t *testing.T) {
+	codec := hashid.New("secret")
+	model.SetIDCodec(codec)
+	t.Cleanup(func() { model.SetIDCodec(nil) })
+
+	handler := NewPostHandler(database.NewMemoryStore())
+	handler.IDCodec = codec
+
+	req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"title":"Secret","content":"Content"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || created.ID != codec.Encode(1) {
+		t.Fatalf("handler returned wrong id: got %s want token %q", rr.Body.String(), codec.Encode(1))
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr = get("/posts/" + created.ID)
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code for token: got %v want %v", status, http.StatusOK)
+	}
+	var post model.Post
+	if err := json.Unmarshal(rr.Body.Bytes(), &post); err != nil || post.ID != 1 {
+		t.Errorf("post did not decode to ID 1: %v, %v", post.ID, err)
+	}
+
+	// An integer is read as a token, which names some other ID or none.
+	if status := get("/posts/1").Code; status == http.StatusOK {
+		t.Errorf("handler served a post for the integer ID: got %v", status)
+	}
+	if status := get("/posts/not-a-token").Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for a malformed token: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	rr = get("/posts/" + codec.Encode(99))
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code for unknown token: got %v want %v", status, http.StatusNotFound)
+	}
+	if strings.Contains(rr.Body.String(), "99") {
+		t.Errorf("not found message reveals the integer ID: %q", rr.Body.String())
+	}
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	token := strconv.Quote(created.ID)
+
+	t.Run("id range", func(t *testing.T) {
+		var posts []model.Post
+		rr := get("/posts?idFrom=" + created.ID + "&idTo=" + created.ID)
+		json.Unmarshal(rr.Body.Bytes(), &posts)
+		if len(posts) != 1 || posts[0].ID != 1 {
+			t.Errorf("handler returned wrong posts: got %s want post 1", rr.Body.String())
+		}
+	})
+
+	t.Run("bulk bodies", func(t *testing.T) {
+		rr := send(http.MethodPost, "/posts/bulk-status", `{"ids": [`+token+`], "status": "draft"}`)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if want := `{"results":[{"id":` + token + `,"result":"updated"}]}`; rr.Body.String() != want {
+			t.Errorf("handler returned wrong results: got %s want %s", rr.Body.String(), want)
+		}
+		if status := send(http.MethodPost, "/posts/bulk-publish", `{"ids": ["not-a-token"]}`).Code; status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+		}
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		send(http.MethodPut, "/posts/"+created.ID, `{"title":"Secret","content":"Content","slug":"taken"}`)
+		rr := send(http.MethodPost, "/posts", `{"title":"Other","content":"Content","slug":"taken"}`)
+		if status := rr.Code; status != http.StatusConflict {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+		}
+		if !strings.Contains(rr.Body.String(), `"conflictingId":`+token) {
+			t.Errorf("conflict does not name the post by token: %s", rr.Body.String())
+		}
+	})
+}
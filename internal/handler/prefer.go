@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gemini/go-blog-api/internal/database"
+)
+
+// preferMinimal reports whether the request carries a Prefer header asking
+// for return=minimal (RFC 7240). Preference names are case-insensitive and
+// the value may be quoted; parameters after a semicolon are ignored.
+func preferMinimal(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(header, ",") {
+			preference, _, _ = strings.Cut(preference, ";")
+			name, value, _ := strings.Cut(preference, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "return") &&
+				strings.Trim(strings.TrimSpace(value), `"`) == "minimal" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyMinimal marks a response as honoring return=minimal.
+func applyMinimal(w http.ResponseWriter) {
+	w.Header().Set("Preference-Applied", "return=minimal")
+}
+
+// bulkResult is a database.BulkResult with its ID as written in responses.
+type bulkResult struct {
+	ID     interface{} `json:"id" id:""`
+	Result string      `json:"result"`
+	Reason string      `json:"reason,omitempty"`
+}
+
+// commentID returns a comment ID as it appears in response bodies. Only
+// post IDs are obfuscated, so it is the integer itself.
+func commentID(id int64) interface{} {
+	return id
+}
+
+// writeBulkResults responds with the results of a bulk operation or, when the
+// request prefers return=minimal, with just the IDs of the posts or comments
+// it created or changed. encodeID writes each ID, such as PostHandler.jsonID
+// for posts.
+func (h *PostHandler) writeBulkResults(w http.ResponseWriter, r *http.Request, results []database.BulkResult, encodeID func(int64) interface{}) {
+	if !preferMinimal(r) {
+		encoded := make([]bulkResult, len(results))
+		for i, result := range results {
+			encoded[i] = bulkResult{ID: encodeID(result.ID), Result: result.Result, Reason: result.Reason}
+		}
+		h.writeJSON(w, r, http.StatusOK, map[string]interface{}{"results": encoded})
+		return
+	}
+
+	ids := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		switch result.Result {
+		case database.BulkCreated, database.BulkPublished, database.BulkUpdated:
+			ids = append(ids, encodeID(result.ID))
+		}
+	}
+	applyMinimal(w)
+	h.writeJSON(w, r, http.StatusOK, bulkIDs{IDs: ids})
+}
+
+// bulkIDs is the minimal response to a bulk operation.
+type bulkIDs struct {
+	IDs []interface{} `json:"ids" id:""`
+}
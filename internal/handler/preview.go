@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// PreviewPost handles POST /posts/preview
+//
+// It accepts the same body as CreatePost and responds with the post as it
+// would be stored: sanitized, with its default category, slug, status, and
+// derived fields filled in, and an excerpt in Snippet. Nothing is written to
+// the store, so the post has no ID, and slug uniqueness is not checked.
+func (h *PostHandler) PreviewPost(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	post, err := h.decodePost(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(post.Category) == "" {
+		post.Category = h.DefaultCategory
+	}
+	if errs := h.validate(post); len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	if post.Slug == "" {
+		post.Slug = model.Slugify(post.Title)
+	}
+	if post.Status == "" {
+		post.Status = model.StatusPublished
+	}
+	post.CreatedAt = time.Now().UTC()
+	post.UpdatedAt = post.CreatedAt
+	if post.Status == model.StatusPublished {
+		publishedAt := post.CreatedAt
+		post.PublishedAt = &publishedAt
+	}
+	post.Derive()
+	post.Snippet = excerpt(post.Content)
+
+	h.writeJSON(w, r, http.StatusOK, post)
+}
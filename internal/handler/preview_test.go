@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestPreviewPost(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.DefaultCategory = "general"
+
+	preview := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/posts/preview", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("rendered", func(t *testing.T) {
+		content := "<p>Hello <script>alert(1)</script>world.</p> " + strings.Repeat("word ", 450)
+		body, _ := json.Marshal(map[string]string{"title": "Draft Idea", "content": content})
+		rr := preview(string(body))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		var post model.Post
+		json.Unmarshal(rr.Body.Bytes(), &post)
+		if post.ReadingTime != 3 || post.WordCount != 452 {
+			t.Errorf("handler returned wrong derived fields: got %v words, %v minutes want 452, 3", post.WordCount, post.ReadingTime)
+		}
+		if !strings.HasPrefix(post.Snippet, "Hello world. word") || !strings.HasSuffix(post.Snippet, "…") {
+			t.Errorf("handler returned wrong excerpt: %q", post.Snippet)
+		}
+		if strings.Contains(post.Content, "<script>") {
+			t.Errorf("handler did not sanitize content: %q", post.Content)
+		}
+		if post.Slug != "draft-idea" || post.Category != "general" || post.Status != model.StatusPublished {
+			t.Errorf("handler returned wrong defaults: slug %q, category %q, status %q", post.Slug, post.Category, post.Status)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if status := preview(`{"title":""}`).Code; status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+		}
+	})
+
+	if posts, total, _ := store.GetAllPosts(context.Background(), database.PostFilter{}); total != 0 {
+		t.Errorf("preview stored posts: %v", posts)
+	}
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/database"
+)
+
+// Default review queue thresholds: posts under 100 words or a year without
+// an update are flagged.
+const (
+	DefaultReviewMinWords   = 100
+	DefaultReviewStaleAfter = 365 * 24 * time.Hour
+)
+
+// ReviewQueue handles GET /posts/review-queue, listing published posts that
+// look like they need an editor's attention, each with the reasons it was
+// flagged. Admin only.
+func (h *PostHandler) ReviewQueue(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	limit, offset, err := parsePagination(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	criteria := database.ReviewCriteria{
+		DefaultCategory: h.DefaultCategory,
+		MinWords:        h.ReviewMinWords,
+	}
+	if h.ReviewStaleAfter > 0 {
+		criteria.StaleBefore = time.Now().Add(-h.ReviewStaleAfter)
+	}
+	items, err := h.Store.ReviewQueue(r.Context(), criteria)
+	if err != nil {
+		h.serverError(w, r, "Failed to get review queue", err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(items)))
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	h.writeJSON(w, r, http.StatusOK, items)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestReviewQueue(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	handler.DefaultCategory = "uncategorized"
+	handler.ReviewMinWords = 5
+	ctx := context.Background()
+
+	long := "one two three four five six"
+	old := time.Now().AddDate(-2, 0, 0)
+	store.CreatePost(ctx, &model.Post{Title: "Fine", Content: long, Category: "go", Tags: []string{"go"}})
+	store.CreatePost(ctx, &model.Post{Title: "Default category", Content: long, Category: "uncategorized", Tags: []string{"go"}})
+	store.CreatePost(ctx, &model.Post{Title: "No tags", Content: long, Category: "go"})
+	store.CreatePost(ctx, &model.Post{Title: "Short", Content: "Too short", Category: "go", Tags: []string{"go"}})
+	store.CreatePost(ctx, &model.Post{Title: "Old", Content: long, Category: "go", Tags: []string{"go"}, PublishedAt: &old})
+	store.CreatePost(ctx, &model.Post{Title: "Everything", Content: "Short", PublishedAt: &old})
+	store.CreatePost(ctx, &model.Post{Title: "Draft", Content: "Short", Status: model.StatusDraft})
+
+	get := func(path string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/posts/review-queue", true)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var items []struct {
+		Post    model.Post `json:"post"`
+		Reasons []string   `json:"reasons"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &items)
+
+	want := []struct {
+		title   string
+		reasons []string
+	}{
+		{"Everything", []string{database.ReviewNoCategory, database.ReviewNoTags, database.ReviewShortContent, database.ReviewStale}},
+		{"Default category", []string{database.ReviewNoCategory}},
+		{"No tags", []string{database.ReviewNoTags}},
+		{"Short", []string{database.ReviewShortContent}},
+		{"Old", []string{database.ReviewStale}},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("handler returned wrong number of posts: got %v want %v", len(items), len(want))
+	}
+	for i, w := range want {
+		if items[i].Post.Title != w.title || !reflect.DeepEqual(items[i].Reasons, w.reasons) {
+			t.Errorf("item %d: got %q %v want %q %v", i, items[i].Post.Title, items[i].Reasons, w.title, w.reasons)
+		}
+	}
+	if got := rr.Header().Get("X-Total-Count"); got != "5" {
+		t.Errorf("wrong X-Total-Count: got %q want %q", got, "5")
+	}
+
+	t.Run("paginated", func(t *testing.T) {
+		rr := get("/posts/review-queue?limit=2&offset=4", true)
+		json.Unmarshal(rr.Body.Bytes(), &items)
+		if len(items) != 1 || items[0].Post.Title != "Old" {
+			t.Errorf("handler returned wrong page: %s", rr.Body.String())
+		}
+		if rr := get("/posts/review-queue?offset=10", true); rr.Body.String() != "[]" {
+			t.Errorf("handler returned wrong page past the end: %s", rr.Body.String())
+		}
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		if status := get("/posts/review-queue", false).Code; status != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnauthorized)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// ListRevisions handles GET /posts/{id}/revisions
+func (h *PostHandler) ListRevisions(w http.ResponseWriter, r *http.Request, postID int64) {
+	revisions, err := h.Store.GetRevisions(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to get revisions", err)
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, revisions)
+}
+
+// fieldDiff is the before and after value of a single-valued field.
+type fieldDiff struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Changed bool   `json:"changed"`
+}
+
+// tagsDiff reports the tags added and removed between two versions.
+type tagsDiff struct {
+	From    []string `json:"from"`
+	To      []string `json:"to"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// versionDiff is the field-level difference between two versions of a
+// post.
+type versionDiff struct {
+	Title    fieldDiff  `json:"title"`
+	Category fieldDiff  `json:"category"`
+	Tags     tagsDiff   `json:"tags"`
+	Content  []lineDiff `json:"content"`
+}
+
+// postVersion holds the fields of a post that diffs compare.
+type postVersion struct {
+	Title    string
+	Category string
+	Tags     []string
+	Content  string
+}
+
+// diff returns the changes turning a into b.
+func (a postVersion) diff(b postVersion) versionDiff {
+	return versionDiff{
+		Title:    fieldDiff{From: a.Title, To: b.Title, Changed: a.Title != b.Title},
+		Category: fieldDiff{From: a.Category, To: b.Category, Changed: a.Category != b.Category},
+		Tags: tagsDiff{
+			From:    nonNil(a.Tags),
+			To:      nonNil(b.Tags),
+			Added:   missingFrom(b.Tags, a.Tags),
+			Removed: missingFrom(a.Tags, b.Tags),
+		},
+		Content: diffLines(splitLines(a.Content), splitLines(b.Content)),
+	}
+}
+
+// revisionDiff is the response of GET /posts/{id}/revisions/diff.
+type revisionDiff struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	versionDiff
+}
+
+// DiffRevisions handles GET /posts/{id}/revisions/diff?from=2&to=5
+func (h *PostHandler) DiffRevisions(w http.ResponseWriter, r *http.Request, postID int64) {
+	query := r.URL.Query()
+	from, errFrom := strconv.Atoi(query.Get("from"))
+	to, errTo := strconv.Atoi(query.Get("to"))
+	if errFrom != nil || errTo != nil || from < 1 || to < 1 {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, "from and to must be revision numbers")
+		return
+	}
+
+	var revisions [2]*model.Revision
+	for i, number := range []int{from, to} {
+		revision, err := h.Store.GetRevision(r.Context(), postID, number)
+		if err != nil {
+			if errors.Is(err, database.ErrPostNotFound) || errors.Is(err, database.ErrRevisionNotFound) {
+				h.notFound(w, r, err)
+			} else {
+				h.serverError(w, r, "Failed to get revision", err)
+			}
+			return
+		}
+		revisions[i] = revision
+	}
+	a, b := revisions[0], revisions[1]
+
+	h.writeJSON(w, r, http.StatusOK, revisionDiff{
+		From:        from,
+		To:          to,
+		versionDiff: versionOfRevision(a).diff(versionOfRevision(b)),
+	})
+}
+
+// DiffPost handles POST /posts/{id}/diff, comparing a proposed update body
+// with the stored post without saving it. The body is sanitized and
+// validated as PUT /posts/{id} would.
+func (h *PostHandler) DiffPost(w http.ResponseWriter, r *http.Request, postID int64) {
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	proposed, err := h.decodePost(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return
+	}
+	if errs := h.validate(proposed); len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	current, err := h.Store.GetPost(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to get post", err)
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, versionOfPost(current).diff(versionOfPost(proposed)))
+}
+
+func versionOfRevision(r *model.Revision) postVersion {
+	return postVersion{Title: r.Title, Category: r.Category, Tags: r.Tags, Content: r.Content}
+}
+
+func versionOfPost(p *model.Post) postVersion {
+	return postVersion{Title: p.Title, Category: p.Category, Tags: p.Tags, Content: p.Content}
+}
+
+// missingFrom returns the tags in list that are not in other, ignoring case.
+func missingFrom(list, other []string) []string {
+	missing := []string{}
+	for _, tag := range list {
+		if !containsFold(other, tag) {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// nonNil returns list, or an empty slice if it is nil, so it encodes as [].
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// splitLines splits content into lines. Empty content has no lines.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
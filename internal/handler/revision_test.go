@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestRevisions(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Draft title", Content: "one\ntwo\nthree", Category: "Tech", Tags: []string{"go", "web"}})
+	store.UpdatePost(ctx, id, &model.Post{Title: "Draft title", Content: "one\n2\nthree", Category: "Tech", Tags: []string{"go", "web"}})
+	store.UpdatePost(ctx, id, &model.Post{Title: "Final title", Content: "one\n2\nthree\nfour", Category: "Tech", Tags: []string{"go", "api"}})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("list", func(t *testing.T) {
+		rr := get(fmt.Sprintf("/posts/%d/revisions", id))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var revisions []model.Revision
+		json.Unmarshal(rr.Body.Bytes(), &revisions)
+		if len(revisions) != 3 || revisions[0].Number != 1 || revisions[2].Title != "Final title" {
+			t.Errorf("handler returned wrong revisions: %+v", revisions)
+		}
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		rr := get(fmt.Sprintf("/posts/%d/revisions/diff?from=1&to=3", id))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var diff revisionDiff
+		json.Unmarshal(rr.Body.Bytes(), &diff)
+
+		if want := (fieldDiff{From: "Draft title", To: "Final title", Changed: true}); diff.Title != want {
+			t.Errorf("title diff = %+v, want %+v", diff.Title, want)
+		}
+		if diff.Category.Changed {
+			t.Errorf("category diff = %+v, want unchanged", diff.Category)
+		}
+		if !reflect.DeepEqual(diff.Tags.Added, []string{"api"}) || !reflect.DeepEqual(diff.Tags.Removed, []string{"web"}) {
+			t.Errorf("tags diff = %+v, want api added and web removed", diff.Tags)
+		}
+		want := []lineDiff{
+			{diffEqual, "one"},
+			{diffDelete, "two"},
+			{diffInsert, "2"},
+			{diffEqual, "three"},
+			{diffInsert, "four"},
+		}
+		if !reflect.DeepEqual(diff.Content, want) {
+			t.Errorf("content diff = %+v, want %+v", diff.Content, want)
+		}
+	})
+
+	t.Run("missing revision", func(t *testing.T) {
+		if status := get(fmt.Sprintf("/posts/%d/revisions/diff?from=1&to=9", id)).Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+		}
+		if status := get("/posts/999/revisions/diff?from=1&to=2").Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+
+	t.Run("invalid numbers", func(t *testing.T) {
+		if status := get(fmt.Sprintf("/posts/%d/revisions/diff?from=x&to=2", id)).Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []lineDiff
+	}{
+		{"identical", []string{"a", "b"}, []string{"a", "b"}, []lineDiff{{diffEqual, "a"}, {diffEqual, "b"}}},
+		{"empty to lines", nil, []string{"a"}, []lineDiff{{diffInsert, "a"}}},
+		{"lines to empty", []string{"a"}, nil, []lineDiff{{diffDelete, "a"}}},
+		{"middle insert", []string{"a", "c"}, []string{"a", "b", "c"},
+			[]lineDiff{{diffEqual, "a"}, {diffInsert, "b"}, {diffEqual, "c"}}},
+		{"both empty", nil, nil, []lineDiff{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffPost(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Old title", Content: "Line one\nLine two", Category: "tech", Tags: []string{"go"}})
+
+	diffPost := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := diffPost("/posts/1/diff", `{"title": "New title", "content": "Line one\nLine 2", "category": "tech", "tags": ["go", "web"]}`)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var diff versionDiff
+	if err := json.Unmarshal(rr.Body.Bytes(), &diff); err != nil {
+		t.Fatalf("could not decode diff: %v", err)
+	}
+	if want := (fieldDiff{From: "Old title", To: "New title", Changed: true}); diff.Title != want {
+		t.Errorf("wrong title diff: got %+v want %+v", diff.Title, want)
+	}
+	if diff.Category.Changed {
+		t.Errorf("category reported as changed: %+v", diff.Category)
+	}
+	if !reflect.DeepEqual(diff.Tags.Added, []string{"web"}) || len(diff.Tags.Removed) != 0 {
+		t.Errorf("wrong tags diff: %+v", diff.Tags)
+	}
+	wantContent := []lineDiff{{diffEqual, "Line one"}, {diffDelete, "Line two"}, {diffInsert, "Line 2"}}
+	if !reflect.DeepEqual(diff.Content, wantContent) {
+		t.Errorf("wrong content diff: got %v want %v", diff.Content, wantContent)
+	}
+	if post, _ := store.GetPost(ctx, id); post.Title != "Old title" {
+		t.Errorf("diff saved the proposed post: title is %q", post.Title)
+	}
+
+	t.Run("missing post", func(t *testing.T) {
+		if rr := diffPost("/posts/99/diff", `{"title": "New title", "content": "Content"}`); rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		if rr := diffPost("/posts/1/diff", `{"title": ""}`); rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+		}
+	})
+}
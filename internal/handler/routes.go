@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// route maps a method and a path pattern under /posts, or /comments, to a
+// handler. Pattern segments are either literals or one of the parameters
+// {id} and {commentID}; the empty pattern is /posts itself.
+type route struct {
+	method  string
+	pattern string
+	handle  routeFunc
+}
+
+// routeParams holds the parameters parsed from a matched path.
+type routeParams struct {
+	postID    int64
+	commentID int64
+}
+
+type routeFunc func(h *PostHandler, w http.ResponseWriter, r *http.Request, p routeParams)
+
+// collection adapts a handler that takes no path parameters.
+func collection(f func(*PostHandler, http.ResponseWriter, *http.Request)) routeFunc {
+	return func(h *PostHandler, w http.ResponseWriter, r *http.Request, _ routeParams) { f(h, w, r) }
+}
+
+// onPost adapts a handler for a single post.
+func onPost(f func(*PostHandler, http.ResponseWriter, *http.Request, int64)) routeFunc {
+	return func(h *PostHandler, w http.ResponseWriter, r *http.Request, p routeParams) { f(h, w, r, p.postID) }
+}
+
+// onCommentID adapts a handler for a single comment, named by its ID alone.
+func onCommentID(f func(*PostHandler, http.ResponseWriter, *http.Request, int64)) routeFunc {
+	return func(h *PostHandler, w http.ResponseWriter, r *http.Request, p routeParams) { f(h, w, r, p.commentID) }
+}
+
+// onComment adapts a handler for a single comment of a post.
+func onComment(f func(*PostHandler, http.ResponseWriter, *http.Request, int64, int64)) routeFunc {
+	return func(h *PostHandler, w http.ResponseWriter, r *http.Request, p routeParams) {
+		f(h, w, r, p.postID, p.commentID)
+	}
+}
+
+// postRoutes lists every endpoint under /posts. A path is served by the
+// first pattern in the list that matches it, so named endpoints such as
+// /posts/recent are listed before /posts/{id}, which shares their position.
+// Methods not listed for the matched pattern get 405 with an Allow header.
+var postRoutes = []route{
+	{http.MethodGet, "", collection((*PostHandler).GetAllPosts)},
+	{http.MethodPost, "", collection((*PostHandler).CreatePost)},
+	{http.MethodGet, "recent", collection((*PostHandler).RecentPosts)},
+	{http.MethodGet, "most-commented", collection((*PostHandler).MostCommented)},
+	{http.MethodGet, "on-this-day", collection((*PostHandler).OnThisDay)},
+	{http.MethodPost, "bulk-publish", collection((*PostHandler).BulkPublish)},
+	{http.MethodPost, "bulk-status", collection((*PostHandler).BulkSetStatus)},
+	{http.MethodPost, "sync", collection((*PostHandler).SyncPosts)},
+	{http.MethodPost, "lookup", collection((*PostHandler).LookupPosts)},
+	{http.MethodGet, "drafts", collection((*PostHandler).ListDrafts)},
+	{http.MethodGet, "scheduled", collection((*PostHandler).ListScheduled)},
+	{http.MethodGet, "review-queue", collection((*PostHandler).ReviewQueue)},
+	{http.MethodGet, "bounds", collection((*PostHandler).PostBounds)},
+	{http.MethodGet, "status-summary", collection((*PostHandler).StatusSummary)},
+	{http.MethodPost, "preview", collection((*PostHandler).PreviewPost)},
+	{http.MethodGet, "schema", collection((*PostHandler).PostSchema)},
+	{http.MethodGet, "trash", collection((*PostHandler).ListTrash)},
+	{http.MethodDelete, "trash", collection((*PostHandler).PurgeTrash)},
+
+	{http.MethodGet, "{id}", onPost((*PostHandler).GetPost)},
+	{http.MethodPut, "{id}", onPost((*PostHandler).UpdatePost)},
+	{http.MethodDelete, "{id}", onPost((*PostHandler).DeletePost)},
+	{http.MethodPost, "{id}/publish", onPost((*PostHandler).PublishPost)},
+	{http.MethodPost, "{id}/unpublish", onPost((*PostHandler).UnpublishPost)},
+	{http.MethodPost, "{id}/pin", onPost((*PostHandler).PinPost)},
+	{http.MethodPost, "{id}/unpin", onPost((*PostHandler).UnpinPost)},
+	{http.MethodPost, "{id}/touch", onPost((*PostHandler).TouchPost)},
+	{http.MethodPut, "{id}/tags", onPost((*PostHandler).SetTags)},
+	{http.MethodPost, "{id}/tags/import", onPost((*PostHandler).ImportTags)},
+	{http.MethodPost, "{id}/diff", onPost((*PostHandler).DiffPost)},
+	{http.MethodGet, "{id}/neighbors", onPost((*PostHandler).Neighbors)},
+	{http.MethodGet, "{id}/permalink", onPost((*PostHandler).Permalink)},
+
+	{http.MethodGet, "{id}/comments", onPost((*PostHandler).ListComments)},
+	{http.MethodPost, "{id}/comments", onPost((*PostHandler).AddComment)},
+	{http.MethodPost, "{id}/comments/bulk", onPost((*PostHandler).BulkAddComments)},
+	{http.MethodDelete, "{id}/comments/{commentID}", onComment((*PostHandler).DeleteComment)},
+	{http.MethodPost, "{id}/comments/{commentID}/approve", onComment((*PostHandler).ApproveComment)},
+	{http.MethodPost, "{id}/comments/{commentID}/reject", onComment((*PostHandler).RejectComment)},
+	{http.MethodPost, "{id}/comments/{commentID}/restore", onComment((*PostHandler).RestoreComment)},
+
+	{http.MethodGet, "{id}/revisions", onPost((*PostHandler).ListRevisions)},
+	{http.MethodGet, "{id}/revisions/diff", onPost((*PostHandler).DiffRevisions)},
+}
+
+// commentRoutes lists every endpoint under /comments, matched like
+// postRoutes.
+var commentRoutes = []route{
+	{http.MethodPost, "{commentID}/promote", onCommentID((*PostHandler).PromoteComment)},
+}
+
+// matchRoutes returns the routes sharing the first pattern in table that
+// matches the path segments, or nil if none does.
+func matchRoutes(table []route, segments []string) []route {
+	var routes []route
+	for _, rt := range table {
+		if len(routes) > 0 {
+			if rt.pattern == routes[0].pattern {
+				routes = append(routes, rt)
+			}
+			continue
+		}
+		if patternMatches(rt.pattern, segments) {
+			routes = append(routes, rt)
+		}
+	}
+	return routes
+}
+
+// patternMatches reports whether a pattern has the same shape as the path
+// segments: the same length and the same literals. Parameters match any
+// segment and are validated once the pattern is chosen.
+func patternMatches(pattern string, segments []string) bool {
+	parts := strings.Split(pattern, "/")
+	if len(parts) != len(segments) {
+		return false
+	}
+	for i, part := range parts {
+		if !isRouteParam(part) && part != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isRouteParam(part string) bool {
+	return strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}")
+}
+
+// parseRouteParams parses the parameters of a matched pattern. A GET of
+// /posts/{id} also accepts a post's slug in place of its ID, so permalinks
+// resolve; IDs win when a segment could be either, and a segment that is
+// neither is an invalid ID. It responds
+// 400 and reports false if one is invalid.
+func (h *PostHandler) parseRouteParams(w http.ResponseWriter, r *http.Request, pattern string, segments []string) (routeParams, bool) {
+	var p routeParams
+	for i, part := range strings.Split(pattern, "/") {
+		switch part {
+		case "{id}":
+			id, err := h.parseID(segments[i])
+			if err != nil && pattern == "{id}" && r.Method == http.MethodGet && model.ValidSlug(segments[i]) {
+				owner, slugErr := h.Store.SlugExists(r.Context(), segments[i])
+				if slugErr != nil {
+					h.serverError(w, r, "Failed to get post", slugErr)
+					return p, false
+				}
+				if owner != 0 {
+					id, err = owner, nil
+				}
+			}
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid post ID")
+				return p, false
+			}
+			p.postID = id
+		case "{commentID}":
+			id, err := strconv.ParseInt(segments[i], 10, 64)
+			if err != nil || id <= 0 {
+				writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid comment ID")
+				return p, false
+			}
+			p.commentID = id
+		}
+	}
+	return p, true
+}
+
+// allowedMethods returns the value of the Allow header for routes.
+func allowedMethods(routes []route) string {
+	methods := make([]string, 0, len(routes))
+	for _, rt := range routes {
+		methods = append(methods, rt.method)
+	}
+	return strings.Join(methods, ", ")
+}
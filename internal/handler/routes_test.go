@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestRoutes(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Post", Content: "Content", AllowComments: true})
+	store.AddComment(ctx, id, &model.Comment{Author: "ana", Content: "Nice"})
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("method not allowed", func(t *testing.T) {
+		tests := []struct {
+			method string
+			path   string
+			allow  string
+		}{
+			{http.MethodDelete, "/posts", "GET, POST"},
+			{http.MethodPatch, "/posts/", "GET, POST"},
+			{http.MethodPost, "/posts/recent", "GET"},
+			{http.MethodGet, "/posts/bulk-publish", "POST"},
+			{http.MethodPut, "/posts/trash", "GET, DELETE"},
+			{http.MethodPatch, "/posts/1", "GET, PUT, DELETE"},
+			{http.MethodGet, "/posts/1/publish", "POST"},
+			{http.MethodGet, "/posts/1/tags", "PUT"},
+			{http.MethodDelete, "/posts/1/comments", "GET, POST"},
+			{http.MethodGet, "/posts/1/comments/1", "DELETE"},
+			{http.MethodGet, "/posts/1/comments/1/approve", "POST"},
+			{http.MethodPost, "/posts/1/revisions/diff", "GET"},
+		}
+		for _, tt := range tests {
+			rr := do(tt.method, tt.path)
+			if rr.Code != http.StatusMethodNotAllowed {
+				t.Errorf("%s %s: handler returned wrong status code: got %v want %v", tt.method, tt.path, rr.Code, http.StatusMethodNotAllowed)
+			}
+			if got := rr.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("%s %s: wrong Allow header: got %q want %q", tt.method, tt.path, got, tt.allow)
+			}
+		}
+	})
+
+	t.Run("unmatched", func(t *testing.T) {
+		tests := []struct {
+			method string
+			path   string
+			want   int
+		}{
+			{http.MethodGet, "/postsx", http.StatusNotFound},
+			{http.MethodPost, "/posts/1/archive", http.StatusNotFound},
+			{http.MethodGet, "/posts/1/comments/1/2/3", http.StatusNotFound},
+			{http.MethodGet, "/posts/1/revisions/latest", http.StatusNotFound},
+			{http.MethodGet, "/posts/abc", http.StatusBadRequest},
+			{http.MethodPatch, "/posts/abc", http.StatusBadRequest},
+			{http.MethodDelete, "/posts/1/comments/abc", http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			if rr := do(tt.method, tt.path); rr.Code != tt.want {
+				t.Errorf("%s %s: handler returned wrong status code: got %v want %v", tt.method, tt.path, rr.Code, tt.want)
+			}
+		}
+	})
+
+	t.Run("matched", func(t *testing.T) {
+		for _, path := range []string{"/posts", "/posts/1/", "/posts/recent", "/posts/1/comments", "/posts/1/revisions"} {
+			if rr := do(http.MethodGet, path); rr.Code != http.StatusOK {
+				t.Errorf("GET %s: handler returned wrong status code: got %v want %v", path, rr.Code, http.StatusOK)
+			}
+		}
+	})
+}
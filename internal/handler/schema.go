@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// slugPattern is the JSON Schema equivalent of model.ValidSlug, apart from
+// the length limit.
+const slugPattern = "^[a-z0-9]+(-[a-z0-9]+)*$"
+
+// PostSchema handles GET /posts/schema, describing the body of POST /posts
+// and PUT /posts/{id} as a JSON Schema document. The constraints come from
+// the same limits validatePost enforces.
+func (h *PostHandler) PostSchema(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, r, http.StatusOK, h.postSchema())
+}
+
+// postSchema builds the schema served by PostSchema.
+func (h *PostHandler) postSchema() map[string]interface{} {
+	category := map[string]interface{}{"type": "string"}
+	if len(h.AllowedCategories) > 0 {
+		category["enum"] = h.AllowedCategories
+	}
+
+	return map[string]interface{}{
+		"$schema":  "https://json-schema.org/draft/2020-12/schema",
+		"title":    "Post",
+		"type":     "object",
+		"required": []string{"title", "content"},
+		"properties": map[string]interface{}{
+			"title":    map[string]interface{}{"type": "string", "minLength": 1, "maxLength": MaxTitleLength},
+			"content":  map[string]interface{}{"type": "string", "minLength": max(1, h.MinContentLength), "maxLength": MaxContentLength},
+			"slug":     map[string]interface{}{"type": "string", "pattern": slugPattern, "maxLength": model.MaxSlugLength},
+			"category": category,
+			"tags": map[string]interface{}{
+				"type":     "array",
+				"maxItems": MaxTags,
+				"items":    map[string]interface{}{"type": "string", "minLength": 1, "maxLength": maxTagLength},
+			},
+			"author":     map[string]interface{}{"type": "string", "pattern": "^[^,]*$", "maxLength": maxAuthorLength},
+			"authorName": map[string]interface{}{"type": "string", "maxLength": maxAuthorLength},
+			"status": map[string]interface{}{
+				"type": "string",
+				"enum": []string{model.StatusDraft, model.StatusPublished, model.StatusScheduled},
+			},
+			"publishAt": map[string]interface{}{
+				"type":        "string",
+				"format":      "date-time",
+				"description": "Required, and in the future, when status is scheduled.",
+			},
+			"translations": map[string]interface{}{
+				"type": "object",
+				"additionalProperties": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"title":   map[string]interface{}{"type": "string"},
+						"content": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+			"imageUrl":      map[string]interface{}{"type": "string", "format": "uri", "pattern": "^https?://"},
+			"allowComments": map[string]interface{}{"type": "boolean", "default": true},
+			"featured":      map[string]interface{}{"type": "boolean", "default": false},
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+)
+
+func TestPostSchema(t *testing.T) {
+	handler := NewPostHandler(database.NewMemoryStore())
+	handler.AllowedCategories = []string{"go", "rust"}
+	handler.MinContentLength = 20
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/schema", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var schema struct {
+		Type       string   `json:"type"`
+		Required   []string `json:"required"`
+		Properties map[string]struct {
+			Type      string   `json:"type"`
+			MinLength int      `json:"minLength"`
+			MaxLength int      `json:"maxLength"`
+			MaxItems  int      `json:"maxItems"`
+			Enum      []string `json:"enum"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &schema); err != nil {
+		t.Fatalf("handler returned invalid JSON: %v", err)
+	}
+
+	if schema.Type != "object" || len(schema.Required) != 2 || schema.Required[0] != "title" || schema.Required[1] != "content" {
+		t.Errorf("handler returned wrong required fields: got %v want %v", schema.Required, []string{"title", "content"})
+	}
+	if got := schema.Properties["title"].MaxLength; got != MaxTitleLength {
+		t.Errorf("handler returned wrong title maxLength: got %v want %v", got, MaxTitleLength)
+	}
+	if got := schema.Properties["content"].MinLength; got != handler.MinContentLength {
+		t.Errorf("handler returned wrong content minLength: got %v want %v", got, handler.MinContentLength)
+	}
+	if got := schema.Properties["tags"].MaxItems; got != MaxTags {
+		t.Errorf("handler returned wrong tags maxItems: got %v want %v", got, MaxTags)
+	}
+	if got := schema.Properties["category"].Enum; len(got) != 2 {
+		t.Errorf("handler returned wrong category enum: got %v want %v", got, handler.AllowedCategories)
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gemini/go-blog-api/internal/model"
+	"github.com/gemini/go-blog-api/internal/sanitize"
+)
+
+// snippetWords is roughly how many words a snippet or excerpt contains.
+const snippetWords = 30
+
+// plainText strips every element, leaving only the text of a post.
+var plainText = sanitize.NewPolicy(nil)
+
+// snippet returns about snippetWords words of content surrounding the first
+// occurrence of term, ignoring case, with the match wrapped in <mark>. It
+// reports false if content does not contain term.
+func snippet(content, term string) (string, bool) {
+	text := plainText.Sanitize(content)
+	start := indexFold(text, term)
+	if start < 0 {
+		return "", false
+	}
+	end := start + len(term)
+
+	// Split the remaining words evenly around the match.
+	room := max(snippetWords-len(strings.Fields(text[start:end])), 0)
+	before := strings.Fields(text[:start])
+	after := strings.Fields(text[end:])
+	nBefore := min(len(before), room/2)
+	nAfter := min(len(after), room-nBefore)
+	nBefore = min(len(before), room-nAfter)
+
+	var b strings.Builder
+	if nBefore < len(before) {
+		b.WriteString("…")
+	}
+	b.WriteString(strings.Join(before[len(before)-nBefore:], " "))
+	if nBefore > 0 && endsWithSpace(text[:start]) {
+		b.WriteString(" ")
+	}
+	b.WriteString("<mark>" + text[start:end] + "</mark>")
+	if nAfter > 0 && startsWithSpace(text[end:]) {
+		b.WriteString(" ")
+	}
+	b.WriteString(strings.Join(after[:nAfter], " "))
+	if nAfter < len(after) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// withSnippets returns copies of posts with Snippet set for term. Posts that
+// matched on their title or category get an excerpt instead.
+func withSnippets(posts []*model.Post, term string) []*model.Post {
+	results := make([]*model.Post, len(posts))
+	for i, post := range posts {
+		result := *post
+		if s, ok := snippet(post.Content, term); ok {
+			result.Snippet = s
+		} else {
+			result.Snippet = excerpt(post.Content)
+		}
+		results[i] = &result
+	}
+	return results
+}
+
+// withMatchedFields returns copies of posts with MatchedField set for term.
+// A post that matched without the term in its own fields matched on a
+// comment.
+func withMatchedFields(posts []*model.Post, term string) []*model.Post {
+	results := make([]*model.Post, len(posts))
+	for i, post := range posts {
+		result := *post
+		switch {
+		case indexFold(post.Title, term) >= 0:
+			result.MatchedField = "title"
+		case indexFold(post.Content, term) >= 0:
+			result.MatchedField = "content"
+		case indexFold(post.Category, term) >= 0:
+			result.MatchedField = "category"
+		default:
+			result.MatchedField = "comments"
+		}
+		results[i] = &result
+	}
+	return results
+}
+
+// excerpt returns the first snippetWords words of content as plain text.
+func excerpt(content string) string {
+	words := strings.Fields(plainText.Sanitize(content))
+	if len(words) <= snippetWords {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(words[:snippetWords], " ") + "…"
+}
+
+// indexFold returns the byte index of the first case-insensitive match of
+// substr in s, or -1 if there is none.
+func indexFold(s, substr string) int {
+	if substr == "" {
+		return -1
+	}
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if utf8.RuneStart(s[i]) && strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func endsWithSpace(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return unicode.IsSpace(r)
+}
+
+func startsWithSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(r)
+}
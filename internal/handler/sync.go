@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// maxSyncIDs caps how many posts a single sync request may check.
+const maxSyncIDs = 500
+
+// syncRequest is the body of POST /posts/sync: the ETags a client holds,
+// keyed by post ID as it appears in URLs.
+type syncRequest struct {
+	ETags map[string]string `json:"etags"`
+}
+
+// syncResponse is the delta for a syncRequest. Deleted holds the request's
+// keys verbatim so clients can drop them from their own map.
+type syncResponse struct {
+	Changed []*model.Post `json:"changed"`
+	Deleted []string      `json:"deleted"`
+}
+
+// SyncPosts handles POST /posts/sync, comparing the ETags a client holds
+// with each post's current one. It returns the posts that changed since,
+// and the IDs of those that no longer exist or are in the trash.
+func (h *PostHandler) SyncPosts(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	var req syncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	var errs model.ValidationErrors
+	switch {
+	case len(req.ETags) == 0:
+		errs.Add("etags", "required")
+	case len(req.ETags) > maxSyncIDs:
+		errs.Add("etags", "too many")
+	}
+	ids := make(map[string]int64, len(req.ETags))
+	for key := range req.ETags {
+		id, err := h.parseID(key)
+		if err != nil {
+			errs.Add("etags", "invalid post id")
+			break
+		}
+		ids[key] = id
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	// Visit posts in ID order so that the response is stable.
+	keys := make([]string, 0, len(ids))
+	for key := range ids {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return ids[keys[i]] < ids[keys[j]] })
+
+	resp := syncResponse{Changed: make([]*model.Post, 0), Deleted: make([]string, 0)}
+	for _, key := range keys {
+		post, err := h.Store.GetPost(r.Context(), ids[key])
+		switch {
+		case errors.Is(err, database.ErrPostNotFound):
+			resp.Deleted = append(resp.Deleted, key)
+		case err != nil:
+			h.serverError(w, r, "Failed to get posts", err)
+			return
+		case !etagMatches(req.ETags[key], postETag(post)):
+			resp.Changed = append(resp.Changed, post)
+		}
+	}
+	h.writeJSON(w, r, http.StatusOK, resp)
+}
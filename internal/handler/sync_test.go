@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestSyncPosts(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	changedID, _ := store.CreatePost(ctx, &model.Post{Title: "Changed", Content: "Content"})
+	unchangedID, _ := store.CreatePost(ctx, &model.Post{Title: "Unchanged", Content: "Content"})
+	deletedID, _ := store.CreatePost(ctx, &model.Post{Title: "Deleted", Content: "Content"})
+
+	etags := map[string]string{}
+	for _, id := range []int64{changedID, unchangedID, deletedID} {
+		post, _ := store.GetPost(ctx, id)
+		etags[handler.formatID(id)] = postETag(post)
+	}
+	etags["99"] = `"gone"`
+	store.UpdatePost(ctx, changedID, &model.Post{Title: "Changed again", Content: "Content"})
+	store.DeletePost(ctx, deletedID)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/posts/sync", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	body, _ := json.Marshal(syncRequest{ETags: etags})
+	rr := send(string(body))
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var resp struct {
+		Changed []model.Post `json:"changed"`
+		Deleted []string     `json:"deleted"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Changed) != 1 || resp.Changed[0].ID != changedID || resp.Changed[0].Title != "Changed again" {
+		t.Errorf("handler returned wrong changed posts: %+v", resp.Changed)
+	}
+	if want := []string{"3", "99"}; strings.Join(resp.Deleted, ",") != strings.Join(want, ",") {
+		t.Errorf("handler returned wrong deleted IDs: got %v want %v", resp.Deleted, want)
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, body := range []string{`{"etags":{}}`, `{"etags":{"abc":"x"}}`} {
+			if status := send(body).Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", body, status, http.StatusUnprocessableEntity)
+			}
+		}
+		if status := send(`{"etags":`).Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,383 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/webhook"
+)
+
+// maxTagsCSVBytes limits the body of POST /posts/{id}/tags/import.
+const maxTagsCSVBytes = 64 << 10
+
+// ServeTags handles GET /tags, listing every tag with its post count,
+// GET /tags/suggest, and GET /tags/{tag}/posts, listing the published posts
+// with that tag, newest first. An unknown tag gives an empty list.
+func (h *PostHandler) ServeTags(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/tags":
+		h.ListTags(w, r)
+		return
+	case "/tags/suggest":
+		h.SuggestTags(w, r)
+		return
+	case "/tags/conflicts":
+		h.TagConflicts(w, r)
+		return
+	}
+
+	tag, ok, err := collectionName(r, "/tags/")
+	switch {
+	case err != nil:
+		writeError(w, r, http.StatusBadRequest, CodeInvalidName, err.Error())
+		return
+	case !ok:
+		writeNotFound(w, r)
+		return
+	}
+	h.listCollection(w, r, database.PostFilter{Tag: tag})
+}
+
+// ListTags handles GET /tags
+func (h *PostHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	tags, err := h.Store.ListTags(r.Context())
+	if err != nil {
+		h.serverError(w, r, "Failed to get tags", err)
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, tags)
+}
+
+// TagConflicts handles GET /tags/conflicts, listing tags spelled more than
+// one way across posts so that an admin can pick one. Admin only, since it
+// covers drafts.
+func (h *PostHandler) TagConflicts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	conflicts, err := h.Store.TagConflicts(r.Context())
+	if err != nil {
+		h.serverError(w, r, "Failed to get tag conflicts", err)
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, conflicts)
+}
+
+// Limits on the suggestions returned by autocomplete endpoints.
+const (
+	defaultSuggestions = 10
+	maxSuggestions     = 50
+)
+
+// SuggestTags handles GET /tags/suggest?q=go, returning the most used tags
+// that start with or contain q.
+func (h *PostHandler) SuggestTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	q, limit, err := parseSuggest(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	tags, err := h.Store.SuggestTags(r.Context(), q, limit)
+	if err != nil {
+		h.serverError(w, r, "Failed to suggest tags", err)
+		return
+	}
+	if tags == nil {
+		tags = []database.TagCount{}
+	}
+
+	h.writeJSON(w, r, http.StatusOK, tags)
+}
+
+// SuggestCategories handles GET /categories/suggest?q=te, returning the most
+// used categories starting with q.
+func (h *PostHandler) SuggestCategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	q, limit, err := parseSuggest(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	categories, err := h.Store.SuggestCategories(r.Context(), q, limit)
+	if err != nil {
+		h.serverError(w, r, "Failed to suggest categories", err)
+		return
+	}
+	if categories == nil {
+		categories = []database.TagCount{}
+	}
+
+	h.writeJSON(w, r, http.StatusOK, categories)
+}
+
+// parseSuggest reads the q and limit query parameters of an autocomplete
+// endpoint. The query is required.
+func parseSuggest(query url.Values) (string, int, error) {
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		return "", 0, errors.New("q is required")
+	}
+	limit := defaultSuggestions
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSuggestions {
+			return "", 0, fmt.Errorf("limit must be between 1 and %d", maxSuggestions)
+		}
+		limit = n
+	}
+	return q, limit, nil
+}
+
+// ServeCategoryPosts handles GET /categories/suggest and
+// GET /categories/{category}/posts, listing the published posts in that
+// category, newest first. The category segment may be URL-escaped, so names
+// with spaces or slashes work.
+func (h *PostHandler) ServeCategoryPosts(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimSuffix(r.URL.Path, "/") == "/categories/suggest" {
+		h.SuggestCategories(w, r)
+		return
+	}
+	category, ok, err := collectionName(r, "/categories/")
+	switch {
+	case err != nil:
+		writeError(w, r, http.StatusBadRequest, CodeInvalidName, err.Error())
+		return
+	case !ok:
+		writeNotFound(w, r)
+		return
+	}
+	h.listCollection(w, r, database.PostFilter{Category: category})
+}
+
+// maxCollectionNameLength bounds the tag or category name in a collection
+// path, comfortably above any stored tag.
+const maxCollectionNameLength = 100
+
+var errCollectionNameTooLong = fmt.Errorf("name must be at most %d characters", maxCollectionNameLength)
+
+// collectionName extracts and unescapes {name} from a path of the form
+// prefix + "{name}/posts", ignoring a trailing slash. It reports false if
+// the path has another shape, and an error if the name is malformed or
+// longer than maxCollectionNameLength.
+func collectionName(r *http.Request, prefix string) (string, bool, error) {
+	rest, ok := strings.CutPrefix(r.URL.EscapedPath(), prefix)
+	if !ok {
+		return "", false, nil
+	}
+	segments := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	if len(segments) != 2 || segments[1] != "posts" {
+		return "", false, nil
+	}
+	// Check the escaped length first so huge segments are never decoded.
+	if len(segments[0]) > 3*maxCollectionNameLength*utf8.UTFMax {
+		return "", false, errCollectionNameTooLong
+	}
+	name, err := url.PathUnescape(segments[0])
+	if err != nil || !utf8.ValidString(name) {
+		return "", false, errors.New("name is not valid percent-encoded UTF-8")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", false, nil
+	}
+	if utf8.RuneCountInString(name) > maxCollectionNameLength {
+		return "", false, errCollectionNameTooLong
+	}
+	return name, true, nil
+}
+
+// listCollection responds with a page of the published posts matching
+// filter, newest first.
+func (h *PostHandler) listCollection(w http.ResponseWriter, r *http.Request, filter database.PostFilter) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	limit, offset, err := parsePagination(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+	filter.Limit, filter.Offset = limit, offset
+	filter.Sort = database.SortNewest
+
+	posts, total, err := h.Store.GetAllPosts(r.Context(), filter)
+	if err != nil {
+		h.serverError(w, r, "Failed to get posts", err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	h.writePosts(w, r, posts)
+}
+
+// ImportTags handles POST /posts/{id}/tags/import, merging the tag names in
+// a CSV body into the post's tags and responding with the updated list.
+// Names may be spread over any number of rows and columns; blank fields are
+// ignored. Whitespace inside a name is collapsed, and names matching an
+// existing tag or an earlier name, ignoring case, are dropped.
+func (h *PostHandler) ImportTags(w http.ResponseWriter, r *http.Request, id int64) {
+	reader := csv.NewReader(http.MaxBytesReader(w, r.Body, maxTagsCSVBytes))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid CSV")
+		return
+	}
+	var names []string
+	for _, record := range records {
+		for _, field := range record {
+			if name := strings.Join(strings.Fields(field), " "); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "CSV contains no tag names")
+		return
+	}
+
+	post, err := h.Store.GetPost(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to import tags", err)
+		}
+		return
+	}
+
+	// The store hands out its own post, so the merge works on a copy.
+	merged := *post
+	merged.Tags = normalizeTags(append([]string(nil), post.Tags...), names)
+	if errs := h.validate(&merged); len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	updatedPost, err := h.Store.UpdatePost(r.Context(), id, &merged)
+	if err != nil {
+		switch limitErrs := storeLimitErrors(err); {
+		case limitErrs != nil:
+			writeValidationErrors(w, r, limitErrs)
+		case retryAfter(err) > 0:
+			writeUpdateThrottled(w, r, retryAfter(err))
+		case errors.Is(err, database.ErrPostNotFound):
+			h.notFound(w, r, err)
+		default:
+			h.serverError(w, r, "Failed to import tags", err)
+		}
+		return
+	}
+
+	h.Webhook.Notify(webhook.PostUpdated, updatedPost.ID, updatedPost)
+
+	h.writeJSON(w, r, http.StatusOK, map[string][]string{"tags": updatedPost.Tags})
+}
+
+// tagsRequest is the body of PUT /posts/{id}/tags.
+type tagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// SetTags handles PUT /posts/{id}/tags, replacing the post's tags with the
+// list in the body, in its order. Names are normalized as in ImportTags, so
+// blank names and repeats are dropped. An empty list clears the tags.
+func (h *PostHandler) SetTags(w http.ResponseWriter, r *http.Request, id int64) {
+	if !h.requireJSON(w, r) {
+		return
+	}
+
+	var req tagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Tags == nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	post, err := h.Store.GetPost(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrPostNotFound) {
+			h.notFound(w, r, err)
+		} else {
+			h.serverError(w, r, "Failed to set tags", err)
+		}
+		return
+	}
+
+	// The store hands out its own post, so the change works on a copy.
+	tagged := *post
+	tagged.Tags = normalizeTags([]string{}, req.Tags)
+	if errs := h.validate(&tagged); len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	updatedPost, err := h.Store.UpdatePost(r.Context(), id, &tagged)
+	if err != nil {
+		switch limitErrs := storeLimitErrors(err); {
+		case limitErrs != nil:
+			writeValidationErrors(w, r, limitErrs)
+		case retryAfter(err) > 0:
+			writeUpdateThrottled(w, r, retryAfter(err))
+		case errors.Is(err, database.ErrPostNotFound):
+			h.notFound(w, r, err)
+		default:
+			h.serverError(w, r, "Failed to set tags", err)
+		}
+		return
+	}
+
+	h.Webhook.Notify(webhook.PostUpdated, updatedPost.ID, updatedPost)
+
+	h.writeJSON(w, r, http.StatusOK, updatedPost)
+}
+
+// normalizeTags appends names to tags in order, collapsing whitespace inside
+// each name and skipping blank names and any matching a tag already in the
+// list, ignoring case.
+func normalizeTags(tags, names []string) []string {
+	seen := make(map[string]bool, len(tags)+len(names))
+	for _, tag := range tags {
+		seen[strings.ToLower(tag)] = true
+	}
+	for _, name := range names {
+		name = strings.Join(strings.Fields(name), " ")
+		if key := strings.ToLower(name); name != "" && !seen[key] {
+			seen[key] = true
+			tags = append(tags, name)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,366 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestTagPosts(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	store.CreatePost(ctx, &model.Post{Title: "Go 1", Content: "Content", Tags: []string{"Go"}})
+	store.CreatePost(ctx, &model.Post{Title: "Rust", Content: "Content", Tags: []string{"rust"}})
+	store.CreatePost(ctx, &model.Post{Title: "Go 2", Content: "Content", Tags: []string{"web", "go"}})
+	store.CreatePost(ctx, &model.Post{Title: "Go draft", Content: "Content", Tags: []string{"go"}, Status: model.StatusDraft})
+
+	list := func(path string) (*httptest.ResponseRecorder, []model.Post) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeTags(rr, req)
+
+		var posts []model.Post
+		json.Unmarshal(rr.Body.Bytes(), &posts)
+		return rr, posts
+	}
+
+	t.Run("existing tag", func(t *testing.T) {
+		rr, posts := list("/tags/GO/posts")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if len(posts) != 2 {
+			t.Fatalf("handler returned wrong number of posts: got %v want %v", len(posts), 2)
+		}
+		if posts[0].Title != "Go 2" {
+			t.Errorf("handler returned wrong first post: got %q want %q", posts[0].Title, "Go 2")
+		}
+	})
+
+	t.Run("unknown tag", func(t *testing.T) {
+		rr, posts := list("/tags/python/posts")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if posts == nil || len(posts) != 0 {
+			t.Errorf("handler returned %v, want an empty array", posts)
+		}
+	})
+
+	t.Run("bad path", func(t *testing.T) {
+		if rr, _ := list("/tags/go"); rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("rejected names", func(t *testing.T) {
+		for _, path := range []string{
+			"/tags/" + strings.Repeat("a", maxCollectionNameLength+1) + "/posts",
+			"/tags/" + strings.Repeat("%E2%82%AC", 5000) + "/posts",
+			"/tags/%ff/posts",
+		} {
+			if rr, _ := list(path); rr.Code != http.StatusBadRequest {
+				t.Errorf("%.40s: handler returned wrong status code: got %v want %v", path, rr.Code, http.StatusBadRequest)
+			}
+		}
+		if rr, _ := list("/tags/" + strings.Repeat("€", maxCollectionNameLength) + "/posts"); rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code at the limit: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+}
+
+func TestCategoryPosts(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	store.CreatePost(ctx, &model.Post{Title: "Older", Content: "Content", Category: "Web Development"})
+	store.CreatePost(ctx, &model.Post{Title: "Other", Content: "Content", Category: "Web"})
+	store.CreatePost(ctx, &model.Post{Title: "Newer", Content: "Content", Category: "web development"})
+
+	req := httptest.NewRequest(http.MethodGet, "/categories/web%20development/posts?limit=10", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeCategoryPosts(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var posts []model.Post
+	json.Unmarshal(rr.Body.Bytes(), &posts)
+	if len(posts) != 2 {
+		t.Fatalf("handler returned wrong number of posts: got %v want %v", len(posts), 2)
+	}
+	if posts[0].Title != "Newer" || posts[1].Title != "Older" {
+		t.Errorf("handler returned posts in wrong order: got %q, %q", posts[0].Title, posts[1].Title)
+	}
+	if total := rr.Header().Get("X-Total-Count"); total != "2" {
+		t.Errorf("handler returned wrong X-Total-Count: got %v want %v", total, "2")
+	}
+}
+
+func TestListTags(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	for _, tags := range [][]string{{"Go"}, {"Go", "web"}, {"go"}, {"Go", "GO"}, {"Web"}} {
+		store.CreatePost(ctx, &model.Post{Title: "Post", Content: "Content", Tags: tags})
+	}
+	store.CreatePost(ctx, &model.Post{Title: "Draft", Content: "Content", Tags: []string{"go", "go2"}, Status: model.StatusDraft})
+
+	req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeTags(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var tags []database.TagCount
+	json.Unmarshal(rr.Body.Bytes(), &tags)
+	want := []database.TagCount{{Name: "Go", Count: 4}, {Name: "Web", Count: 2}}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("handler returned wrong tags: got %v want %v", tags, want)
+	}
+}
+
+func TestTagConflicts(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+	ctx := context.Background()
+	for _, tags := range [][]string{{"Go", "web"}, {"Go"}, {"go", "rust"}, {"Web"}, {"rust"}} {
+		store.CreatePost(ctx, &model.Post{Title: "Post", Content: "Content", Tags: tags})
+	}
+	store.CreatePost(ctx, &model.Post{Title: "Draft", Content: "Content", Tags: []string{" GO "}, Status: model.StatusDraft})
+	trashed, _ := store.CreatePost(ctx, &model.Post{Title: "Trashed", Content: "Content", Tags: []string{"RUST"}})
+	store.DeletePost(ctx, trashed)
+
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tags/conflicts", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeTags(rr, req)
+		return rr
+	}
+
+	t.Run("groups", func(t *testing.T) {
+		rr := get("secret")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var conflicts []database.TagConflict
+		json.Unmarshal(rr.Body.Bytes(), &conflicts)
+		want := []database.TagConflict{
+			{Tag: "go", Forms: []database.TagCount{{Name: "Go", Count: 2}, {Name: " GO ", Count: 1}, {Name: "go", Count: 1}}},
+			{Tag: "web", Forms: []database.TagCount{{Name: "Web", Count: 1}, {Name: "web", Count: 1}}},
+		}
+		if !reflect.DeepEqual(conflicts, want) {
+			t.Errorf("handler returned wrong conflicts: got %v want %v", conflicts, want)
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		if status := get("").Code; status != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnauthorized)
+		}
+	})
+}
+
+func TestSuggestTags(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	store.CreatePost(ctx, &model.Post{Title: "One", Content: "Content", Tags: []string{"Go", "golang", "web"}})
+	store.CreatePost(ctx, &model.Post{Title: "Two", Content: "Content", Tags: []string{"golang", "mongo"}})
+	store.CreatePost(ctx, &model.Post{Title: "Three", Content: "Content", Tags: []string{"golang", "rust"}})
+
+	suggest := func(query string) (*httptest.ResponseRecorder, []database.TagCount) {
+		req := httptest.NewRequest(http.MethodGet, "/tags/suggest"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeTags(rr, req)
+
+		var tags []database.TagCount
+		json.Unmarshal(rr.Body.Bytes(), &tags)
+		return rr, tags
+	}
+
+	t.Run("prefix first by count", func(t *testing.T) {
+		rr, tags := suggest("?q=GO")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		want := []database.TagCount{{Name: "golang", Count: 3}, {Name: "Go", Count: 1}, {Name: "mongo", Count: 1}}
+		if !reflect.DeepEqual(tags, want) {
+			t.Errorf("handler returned wrong tags: got %v want %v", tags, want)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		if _, tags := suggest("?q=go&limit=1"); len(tags) != 1 || tags[0].Name != "golang" {
+			t.Errorf("handler returned wrong tags: got %v want [golang]", tags)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		rr, _ := suggest("?q=python")
+		if body := strings.TrimSpace(rr.Body.String()); body != "[]" {
+			t.Errorf("handler returned wrong body: got %s want []", body)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, query := range []string{"", "?q=%20", "?q=go&limit=0"} {
+			if rr, _ := suggest(query); rr.Code != http.StatusBadRequest {
+				t.Errorf("%q: handler returned wrong status code: got %v want %v", query, rr.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
+
+func TestSuggestCategories(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	for _, category := range []string{"Technology", "technology", "Teaching", "Travel", "Fintech", ""} {
+		store.CreatePost(ctx, &model.Post{Title: "Post " + category, Content: "Content", Category: category})
+	}
+	store.CreatePost(ctx, &model.Post{Title: "Draft", Content: "Content", Category: "Tennis", Status: model.StatusDraft})
+
+	suggest := func(query string) (*httptest.ResponseRecorder, []database.TagCount) {
+		req := httptest.NewRequest(http.MethodGet, "/categories/suggest"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeCategoryPosts(rr, req)
+
+		var categories []database.TagCount
+		json.Unmarshal(rr.Body.Bytes(), &categories)
+		return rr, categories
+	}
+
+	rr, categories := suggest("?q=te")
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	want := []database.TagCount{{Name: "Technology", Count: 2}, {Name: "Teaching", Count: 1}}
+	if !reflect.DeepEqual(categories, want) {
+		t.Errorf("handler returned wrong categories: got %v want %v", categories, want)
+	}
+
+	if rr, _ := suggest("?q=zzz"); strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("handler returned wrong body: got %s want []", rr.Body.String())
+	}
+	if rr, _ := suggest("?q="); rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestImportTags(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Post", Content: "Content", Tags: []string{"Go", "web"}})
+
+	importCSV := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := importCSV("/posts/1/tags/import", "go, apis,\"http  servers\"\n\nWeb,testing\napis\n")
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", status, http.StatusOK, rr.Body.String())
+	}
+	var resp struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	want := []string{"Go", "web", "apis", "http servers", "testing"}
+	if !reflect.DeepEqual(resp.Tags, want) {
+		t.Errorf("handler returned wrong tags: got %v want %v", resp.Tags, want)
+	}
+	if post, _ := store.GetPost(ctx, id); !reflect.DeepEqual(post.Tags, want) {
+		t.Errorf("stored tags were not updated: got %v want %v", post.Tags, want)
+	}
+
+	t.Run("rejected", func(t *testing.T) {
+		tests := []struct {
+			name string
+			path string
+			body string
+			want int
+		}{
+			{"malformed", "/posts/1/tags/import", "a,\"unterminated\n", http.StatusBadRequest},
+			{"empty", "/posts/1/tags/import", " , \n", http.StatusBadRequest},
+			{"over the cap", "/posts/1/tags/import", "a,b,c,d,e,f", http.StatusUnprocessableEntity},
+			{"missing post", "/posts/99/tags/import", "a", http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			if rr := importCSV(tt.path, tt.body); rr.Code != tt.want {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", tt.name, rr.Code, tt.want)
+			}
+		}
+		if post, _ := store.GetPost(ctx, id); !reflect.DeepEqual(post.Tags, want) {
+			t.Errorf("rejected imports changed the tags: got %v want %v", post.Tags, want)
+		}
+	})
+}
+
+func TestSetTags(t *testing.T) {
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	ctx := context.Background()
+	id, _ := store.CreatePost(ctx, &model.Post{Title: "Post", Content: "Content", Tags: []string{"Go", "web", "apis"}})
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := put("/posts/1/tags", `{"tags":["apis"," web  servers ","Go","APIS",""]}`)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", status, http.StatusOK, rr.Body.String())
+	}
+	var updated model.Post
+	if err := json.Unmarshal(rr.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	want := []string{"apis", "web servers", "Go"}
+	if !reflect.DeepEqual(updated.Tags, want) {
+		t.Errorf("handler returned wrong tags: got %v want %v", updated.Tags, want)
+	}
+	if post, _ := store.GetPost(ctx, id); !reflect.DeepEqual(post.Tags, want) || post.Title != "Post" {
+		t.Errorf("stored post was not updated as expected: got %+v want tags %v", post, want)
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			path string
+			body string
+			want int
+		}{
+			{"/posts/1/tags", `{"tags":`, http.StatusBadRequest},
+			{"/posts/1/tags", `{}`, http.StatusBadRequest},
+			{"/posts/99/tags", `{"tags":["go"]}`, http.StatusNotFound},
+			{"/posts/1/tags", `{"tags":["1","2","3","4","5","6","7","8","9","10","11"]}`, http.StatusUnprocessableEntity},
+		}
+		for _, tt := range tests {
+			if status := put(tt.path, tt.body).Code; status != tt.want {
+				t.Errorf("%s %s: handler returned wrong status code: got %v want %v", tt.path, tt.body, status, tt.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListTrash handles GET /posts/trash, listing soft-deleted posts most
+// recently deleted first.
+func (h *PostHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	limit, offset, err := parsePagination(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	posts, total, err := h.Store.ListDeleted(r.Context(), limit, offset)
+	if err != nil {
+		h.serverError(w, r, "Failed to list deleted posts", err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	h.writePosts(w, r, posts)
+}
+
+// PurgeTrash handles DELETE /posts/trash?olderThan=30d. It permanently
+// removes posts soft-deleted more than olderThan ago.
+func (h *PostHandler) PurgeTrash(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	age, err := parseAge(r.URL.Query().Get("olderThan"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, "olderThan "+err.Error())
+		return
+	}
+
+	purged, err := h.Store.PurgeDeleted(r.Context(), time.Now().Add(-age))
+	if err != nil {
+		h.serverError(w, r, "Failed to purge deleted posts", err)
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, map[string]int{"purged": purged})
+}
+
+// maxAgeDays bounds day counts accepted by parseAge so they cannot overflow
+// a time.Duration.
+const maxAgeDays = 36500
+
+var errInvalidAge = errors.New("must be a number of days such as 30d, or a duration such as 12h")
+
+// parseAge parses a non-negative age given as whole days ("30d") or as a Go
+// duration ("12h", "90m").
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.New("is required")
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 || n > maxAgeDays {
+			return 0, errInvalidAge
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, errInvalidAge
+	}
+	return d, nil
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestPurgeTrash(t *testing.T) {
+	ctx := context.Background()
+	var age time.Duration
+	store := database.NewMemoryStore(database.WithClock(func() time.Time { return time.Now().Add(-age) }))
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+
+	// Soft-delete posts as if they had been deleted age ago.
+	trash := func(ago time.Duration) int64 {
+		age = ago
+		defer func() { age = 0 }()
+		id, _ := store.CreatePost(ctx, &model.Post{Title: "Title", Content: "Content"})
+		store.DeletePost(ctx, id)
+		return id
+	}
+	trash(45 * 24 * time.Hour)
+	trash(31 * 24 * time.Hour)
+	trash(2 * 24 * time.Hour)
+
+	do := func(query string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/posts/trash"+query, nil)
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("requires admin", func(t *testing.T) {
+		if status := do("?olderThan=30d", false).Code; status != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("invalid age", func(t *testing.T) {
+		for _, query := range []string{"", "?olderThan=", "?olderThan=thirty", "?olderThan=-1d", "?olderThan=-5h", "?olderThan=99999999999d"} {
+			if status := do(query, true).Code; status != http.StatusBadRequest {
+				t.Errorf("%q: handler returned wrong status code: got %v want %v", query, status, http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("purges old posts only", func(t *testing.T) {
+		rr := do("?olderThan=30d", true)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var body map[string]int
+		json.Unmarshal(rr.Body.Bytes(), &body)
+		if body["purged"] != 2 {
+			t.Errorf("handler purged %d posts, want 2", body["purged"])
+		}
+
+		json.Unmarshal(do("?olderThan=24h", true).Body.Bytes(), &body)
+		if body["purged"] != 1 {
+			t.Errorf("second purge removed %d posts, want 1", body["purged"])
+		}
+	})
+}
+
+func TestListTrash(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	handler := NewPostHandler(store)
+	handler.AdminToken = "secret"
+
+	first, _ := store.CreatePost(ctx, &model.Post{Title: "First", Content: "Content"})
+	second, _ := store.CreatePost(ctx, &model.Post{Title: "Second", Content: "Content"})
+	store.CreatePost(ctx, &model.Post{Title: "Kept", Content: "Content"})
+	store.DeletePost(ctx, first)
+	store.DeletePost(ctx, second)
+
+	do := func(query string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/posts/trash"+query, nil)
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if status := do("", false).Code; status != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnauthorized)
+	}
+
+	rr := do("", true)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var posts []model.Post
+	json.Unmarshal(rr.Body.Bytes(), &posts)
+	if len(posts) != 2 || posts[0].ID != second || posts[1].ID != first {
+		t.Fatalf("handler returned wrong posts: got %+v want IDs [%d %d]", posts, second, first)
+	}
+	if posts[0].DeletedAt == nil {
+		t.Error("handler returned a post without deletedAt")
+	}
+	if got := rr.Header().Get("X-Total-Count"); got != "2" {
+		t.Errorf("handler returned wrong X-Total-Count: got %q want %q", got, "2")
+	}
+
+	json.Unmarshal(do("?limit=1&offset=1", true).Body.Bytes(), &posts)
+	if len(posts) != 1 || posts[0].ID != first {
+		t.Errorf("handler returned wrong page: got %+v want ID %d", posts, first)
+	}
+}
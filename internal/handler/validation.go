@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// MaxTitleLength and MaxContentLength are the longest title and content, in
+// characters, a post may have.
+const (
+	MaxTitleLength   = 200
+	MaxContentLength = 50000
+)
+
+// maxTagLength bounds each tag.
+const maxTagLength = 50
+
+// maxCommentAuthorLength bounds a comment's author name.
+const maxCommentAuthorLength = 100
+
+// maxAuthorLength bounds a post's author handle and display name.
+const maxAuthorLength = 100
+
+// DefaultMaxCommentLength is the default value of
+// PostHandler.MaxCommentLength.
+const DefaultMaxCommentLength = 2000
+
+// MaxTags is the most tags a post may have.
+const MaxTags = 10
+
+// storeLimitErrors maps the store's errors for posts outside its limits to
+// the validation errors the handler reports for them, or returns nil for any
+// other error. The handler validates first, so these only surface when the
+// store's limits are stricter than its own.
+func storeLimitErrors(err error) model.ValidationErrors {
+	var errs model.ValidationErrors
+	switch {
+	case errors.Is(err, database.ErrTitleTooLong):
+		errs.Add("title", "too long")
+	case errors.Is(err, database.ErrContentTooLong):
+		errs.Add("content", "too long")
+	case errors.Is(err, database.ErrContentTooShort):
+		errs.Add("content", "too short")
+	case errors.Is(err, database.ErrTooManyTags):
+		errs.Add("tags", "too many")
+	case errors.Is(err, database.ErrAuthorLimitReached):
+		errs.Add("author", "has reached the post limit")
+	}
+	return errs
+}
+
+// validatePost runs every check against a decoded post and returns all of the
+// problems found. An empty result means the post is valid.
+func validatePost(post *model.Post) model.ValidationErrors {
+	var errs model.ValidationErrors
+
+	switch {
+	case strings.TrimSpace(post.Title) == "":
+		errs.Add("title", "required")
+	case utf8.RuneCountInString(post.Title) > MaxTitleLength:
+		errs.Add("title", "too long")
+	}
+
+	switch {
+	case strings.TrimSpace(post.Content) == "":
+		errs.Add("content", "required")
+	case utf8.RuneCountInString(post.Content) > MaxContentLength:
+		errs.Add("content", "too long")
+	}
+
+	// The handle is what the author filter matches, so it can't hold the
+	// comma that separates names there.
+	switch {
+	case strings.TrimSpace(post.Author) == "" && post.AuthorName != "":
+		errs.Add("author", "required")
+	case utf8.RuneCountInString(post.Author) > maxAuthorLength:
+		errs.Add("author", "too long")
+	case strings.Contains(post.Author, ","):
+		errs.Add("author", "invalid")
+	}
+	if utf8.RuneCountInString(post.AuthorName) > maxAuthorLength {
+		errs.Add("authorName", "too long")
+	}
+
+	if post.Slug != "" && !model.ValidSlug(post.Slug) {
+		errs.Add("slug", "invalid")
+	}
+
+	if post.ImageURL != "" && !validImageURL(post.ImageURL) {
+		errs.Add("imageUrl", "invalid")
+	}
+
+	if len(post.Tags) > MaxTags {
+		errs.Add("tags", "too many")
+	}
+	for _, tag := range post.Tags {
+		if strings.TrimSpace(tag) == "" || utf8.RuneCountInString(tag) > maxTagLength {
+			errs.Add("tags", "invalid tag")
+			break
+		}
+	}
+
+	switch post.Status {
+	case "", model.StatusDraft, model.StatusPublished:
+	case model.StatusScheduled:
+		switch {
+		case post.PublishAt == nil:
+			errs.Add("publishAt", "required")
+		case !post.PublishAt.After(time.Now()):
+			errs.Add("publishAt", "must be in the future")
+		}
+	default:
+		errs.Add("status", "invalid")
+	}
+
+	return errs
+}
+
+// textErrors reports each text field of post that fails validText.
+func textErrors(post *model.Post) model.ValidationErrors {
+	var errs model.ValidationErrors
+	for _, f := range []struct{ field, value string }{
+		{"title", post.Title},
+		{"content", post.Content},
+		{"category", post.Category},
+		{"author", post.Author},
+		{"authorName", post.AuthorName},
+	} {
+		if !validText(f.value) {
+			errs.Add(f.field, "invalid characters")
+		}
+	}
+	for _, tag := range post.Tags {
+		if !validText(tag) {
+			errs.Add("tags", "invalid characters")
+			break
+		}
+	}
+	for _, translation := range post.Translations {
+		if !validText(translation.Title) || !validText(translation.Content) {
+			errs.Add("translations", "invalid characters")
+			break
+		}
+	}
+	return errs
+}
+
+// validText reports whether s is valid UTF-8 without NUL bytes or control
+// characters other than tab, newline, and carriage return.
+func validText(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// validImageURL reports whether s is an absolute http or https URL.
+func validImageURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validate runs validatePost plus the checks that depend on handler
+// configuration.
+func (h *PostHandler) validate(post *model.Post) model.ValidationErrors {
+	errs := validatePost(post)
+	if !h.categoryAllowed(post.Category) {
+		errs.Add("category", "not allowed")
+	}
+	if h.StrictText {
+		errs = append(errs, textErrors(post)...)
+	}
+	return errs
+}
+
+// validateComment checks a decoded comment against the handler's limits and
+// returns every problem found. Anything that stores comments should run it.
+func (h *PostHandler) validateComment(comment *model.Comment) model.ValidationErrors {
+	var errs model.ValidationErrors
+
+	switch {
+	case strings.TrimSpace(comment.Content) == "":
+		errs.Add("content", "required")
+	case h.MaxCommentLength > 0 && utf8.RuneCountInString(comment.Content) > h.MaxCommentLength:
+		errs.Add("content", "too long")
+	}
+
+	if utf8.RuneCountInString(comment.Author) > maxCommentAuthorLength {
+		errs.Add("author", "too long")
+	}
+
+	return errs
+}
+
+// categoryAllowed reports whether the category is in the configured
+// allowlist. Any category is allowed when the allowlist is empty.
+func (h *PostHandler) categoryAllowed(category string) bool {
+	if len(h.AllowedCategories) == 0 {
+		return true
+	}
+	category = strings.TrimSpace(category)
+	for _, allowed := range h.AllowedCategories {
+		if strings.EqualFold(allowed, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeValidationErrors responds with 422 and the per-field problems.
+func writeValidationErrors(w http.ResponseWriter, r *http.Request, errs model.ValidationErrors) {
+	writeJSON(w, r, http.StatusUnprocessableEntity, struct {
+		errorResponse
+		Errors model.ValidationErrors `json:"errors"`
+	}{errorResponse{Code: CodeValidationFailed, Error: "Validation failed"}, errs})
+}
@@ -0,0 +1,412 @@
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/database"
+	"github.com/gemini/go-blog-api/internal/model"
+	"github.com/gemini/go-blog-api/internal/webhook"
+)
+
+// WXR (WordPress eXtended RSS) namespaces.
+const (
+	wxrVersion          = "1.2"
+	wxrNamespace        = "http://wordpress.org/export/1.2/"
+	wxrExcerptNamespace = "http://wordpress.org/export/1.2/excerpt/"
+	contentNamespace    = "http://purl.org/rss/1.0/modules/content/"
+	dcNamespace         = "http://purl.org/dc/elements/1.1/"
+)
+
+// wxrTimeFormat is the layout of wp:post_date and wp:post_date_gmt.
+const wxrTimeFormat = "2006-01-02 15:04:05"
+
+// maxWXRImportBytes limits the size of an uploaded WXR file.
+const maxWXRImportBytes = 32 << 20
+
+// Prefixed element names below are written literally, with the prefixes
+// bound by the attributes on wxrDocument, which is how WordPress itself
+// writes them.
+
+// wxrDocument is the root of a WXR file.
+type wxrDocument struct {
+	XMLName        xml.Name   `xml:"rss"`
+	Version        string     `xml:"version,attr"`
+	XMLNSExcerpt   string     `xml:"xmlns:excerpt,attr"`
+	XMLNSContent   string     `xml:"xmlns:content,attr"`
+	XMLNSDC        string     `xml:"xmlns:dc,attr"`
+	XMLNSWordPress string     `xml:"xmlns:wp,attr"`
+	Channel        wxrChannel `xml:"channel"`
+}
+
+type wxrChannel struct {
+	Title      string        `xml:"title"`
+	Link       string        `xml:"link"`
+	PubDate    string        `xml:"pubDate"`
+	WXRVersion string        `xml:"wp:wxr_version"`
+	Categories []wxrCategory `xml:"wp:category"`
+	Tags       []wxrTag      `xml:"wp:tag"`
+	Items      []wxrItem     `xml:"item"`
+}
+
+type wxrCategory struct {
+	Nicename string `xml:"wp:category_nicename"`
+	Name     cdata  `xml:"wp:cat_name"`
+}
+
+type wxrTag struct {
+	Slug string `xml:"wp:tag_slug"`
+	Name cdata  `xml:"wp:tag_name"`
+}
+
+type wxrItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	PubDate     string        `xml:"pubDate"`
+	Creator     cdata         `xml:"dc:creator"`
+	GUID        wxrGUID       `xml:"guid"`
+	Content     cdata         `xml:"content:encoded"`
+	Excerpt     cdata         `xml:"excerpt:encoded"`
+	PostID      int64         `xml:"wp:post_id,omitempty"`
+	PostDate    string        `xml:"wp:post_date"`
+	PostDateGMT string        `xml:"wp:post_date_gmt"`
+	PostName    string        `xml:"wp:post_name"`
+	Status      string        `xml:"wp:status"`
+	PostType    string        `xml:"wp:post_type"`
+	Terms       []wxrItemTerm `xml:"category"`
+}
+
+type wxrGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// wxrItemTerm assigns a category or tag to an item.
+type wxrItemTerm struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",cdata"`
+}
+
+// cdata is element text written as a CDATA section.
+type cdata struct {
+	Text string `xml:",cdata"`
+}
+
+// Term domains used by WordPress.
+const (
+	wxrDomainCategory = "category"
+	wxrDomainTag      = "post_tag"
+)
+
+// ExportWXR handles GET /export/wxr, writing the published posts as a
+// WordPress eXtended RSS file that WordPress's importer accepts. It takes
+// the same filters as GET /posts.
+func (h *PostHandler) ExportWXR(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	filter, err := h.postFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	posts, _, err := h.Store.GetAllPosts(r.Context(), filter)
+	if err != nil {
+		h.serverError(w, r, "Failed to export posts", err)
+		return
+	}
+
+	body, err := xml.MarshalIndent(h.wxrDocument(h.siteURL(r), posts), "", "  ")
+	if err != nil {
+		h.serverError(w, r, "Failed to export posts", err)
+		return
+	}
+
+	setDownloadHeaders(w, "application/rss+xml; charset=utf-8", "posts.wxr")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+	w.Write([]byte("\n"))
+}
+
+// wxrDocument builds the WXR document for posts, linking them under base.
+func (h *PostHandler) wxrDocument(base string, posts []*model.Post) wxrDocument {
+	channel := wxrChannel{
+		Title:      "Blog export",
+		Link:       base,
+		PubDate:    time.Now().UTC().Format(time.RFC1123Z),
+		WXRVersion: wxrVersion,
+	}
+
+	// Categories and tags are listed once each, grouped the way the API
+	// matches them: ignoring case.
+	seenCategories := make(map[string]bool)
+	seenTags := make(map[string]bool)
+	for _, post := range posts {
+		item := h.wxrItem(base, post)
+		for _, term := range item.Terms {
+			switch {
+			case term.Domain == wxrDomainCategory && !seenCategories[term.Nicename]:
+				seenCategories[term.Nicename] = true
+				channel.Categories = append(channel.Categories, wxrCategory{Nicename: term.Nicename, Name: cdata{term.Name}})
+			case term.Domain == wxrDomainTag && !seenTags[term.Nicename]:
+				seenTags[term.Nicename] = true
+				channel.Tags = append(channel.Tags, wxrTag{Slug: term.Nicename, Name: cdata{term.Name}})
+			}
+		}
+		channel.Items = append(channel.Items, item)
+	}
+
+	return wxrDocument{
+		Version:        "2.0",
+		XMLNSExcerpt:   wxrExcerptNamespace,
+		XMLNSContent:   contentNamespace,
+		XMLNSDC:        dcNamespace,
+		XMLNSWordPress: wxrNamespace,
+		Channel:        channel,
+	}
+}
+
+// wxrItem converts a post to a WXR item.
+func (h *PostHandler) wxrItem(base string, post *model.Post) wxrItem {
+	link := h.permalink(base, post)
+	published := post.CreatedAt
+	if post.PublishedAt != nil {
+		published = *post.PublishedAt
+	}
+
+	item := wxrItem{
+		Title:       post.Title,
+		Link:        link,
+		PubDate:     published.UTC().Format(time.RFC1123Z),
+		Creator:     cdata{post.Author},
+		GUID:        wxrGUID{Value: link},
+		Content:     cdata{post.Content},
+		Excerpt:     cdata{excerpt(post.Content)},
+		PostDate:    published.UTC().Format(wxrTimeFormat),
+		PostDateGMT: published.UTC().Format(wxrTimeFormat),
+		PostName:    post.Slug,
+		Status:      wxrStatus(post.Status),
+		PostType:    "post",
+	}
+	// Integer IDs stay private when they are obfuscated in the API.
+	if h.IDCodec == nil {
+		item.PostID = post.ID
+	}
+
+	if category := strings.TrimSpace(post.Category); category != "" {
+		item.Terms = append(item.Terms, wxrItemTerm{Domain: wxrDomainCategory, Nicename: termNicename(category), Name: category})
+	}
+	for _, tag := range post.Tags {
+		item.Terms = append(item.Terms, wxrItemTerm{Domain: wxrDomainTag, Nicename: termNicename(tag), Name: tag})
+	}
+	return item
+}
+
+// wxrStatus maps a post status to its WordPress equivalent.
+func wxrStatus(status string) string {
+	switch status {
+	case model.StatusPublished:
+		return "publish"
+	case model.StatusScheduled:
+		return "future"
+	default:
+		return "draft"
+	}
+}
+
+// termNicename returns the WordPress slug for a category or tag name. Names
+// without ASCII letters or digits, which Slugify maps to "post", keep their
+// lowercased text instead.
+func termNicename(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.ContainsFunc(name, func(r rune) bool { return r >= 'a' && r <= 'z' || r >= '0' && r <= '9' }) {
+		return name
+	}
+	return model.Slugify(name)
+}
+
+// baseURL returns the scheme and host the request was made to.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// The types below read a WXR file. encoding/xml matches prefixed elements
+// by namespace URL when decoding, so they can't share the export types.
+
+type wxrImportDocument struct {
+	XMLName xml.Name `xml:"rss"`
+	Channel *struct {
+		Items []wxrImportItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrImportItem struct {
+	Title         string        `xml:"title"`
+	PubDate       string        `xml:"pubDate"`
+	Creator       string        `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Content       string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostDateGMT   string        `xml:"http://wordpress.org/export/1.2/ post_date_gmt"`
+	PostName      string        `xml:"http://wordpress.org/export/1.2/ post_name"`
+	Status        string        `xml:"http://wordpress.org/export/1.2/ status"`
+	PostType      string        `xml:"http://wordpress.org/export/1.2/ post_type"`
+	CommentStatus string        `xml:"http://wordpress.org/export/1.2/ comment_status"`
+	Terms         []wxrItemTerm `xml:"category"`
+}
+
+// wxrImportResult reports what happened to one item of an imported file.
+// Item is the item's 1-based position in the file.
+type wxrImportResult struct {
+	Item   int         `json:"item"`
+	Title  string      `json:"title"`
+	ID     interface{} `json:"id,omitempty" id:""`
+	Result string      `json:"result"`
+	Reason string      `json:"reason,omitempty"`
+}
+
+// ImportWXR handles POST /import/wxr, creating a post for every post item
+// of the WordPress eXtended RSS file in the request body. Pages,
+// attachments, and other item types are skipped, as are items whose slug
+// is already in use, so a file can be imported again after a partial
+// failure. Items without a slug get one from their title. Admin only.
+func (h *PostHandler) ImportWXR(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	var doc wxrImportDocument
+	if err := xml.NewDecoder(http.MaxBytesReader(w, r.Body, maxWXRImportBytes)).Decode(&doc); err != nil || doc.Channel == nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid WXR file")
+		return
+	}
+
+	results := make([]wxrImportResult, 0, len(doc.Channel.Items))
+	created := 0
+	for i, item := range doc.Channel.Items {
+		result := wxrImportResult{Item: i + 1, Title: item.Title}
+		if postType := strings.TrimSpace(item.PostType); postType != "" && postType != "post" {
+			result.Result, result.Reason = database.BulkSkipped, "not a post: "+postType
+			results = append(results, result)
+			continue
+		}
+
+		post := h.importedPost(item)
+		if errs := h.validate(post); len(errs) > 0 {
+			result.Result, result.Reason = database.BulkRejected, errs.Error()
+			results = append(results, result)
+			continue
+		}
+
+		if post.Slug != "" {
+			owner, err := h.Store.SlugExists(r.Context(), post.Slug)
+			if err != nil {
+				h.serverError(w, r, "Failed to import posts", err)
+				return
+			}
+			if owner != 0 {
+				result.ID, result.Result, result.Reason = h.jsonID(owner), database.BulkSkipped, database.ErrSlugTaken.Error()
+				results = append(results, result)
+				continue
+			}
+		}
+
+		id, err := h.Store.CreatePost(r.Context(), post)
+		switch {
+		case storeLimitErrors(err) != nil, errors.Is(err, database.ErrSlugTaken), errors.Is(err, database.ErrStoreFull):
+			result.Result, result.Reason = database.BulkRejected, err.Error()
+		case err != nil:
+			h.serverError(w, r, "Failed to import posts", err)
+			return
+		default:
+			result.ID, result.Result = h.jsonID(id), database.BulkCreated
+			created++
+			if createdPost, err := h.Store.GetPost(r.Context(), id); err == nil {
+				h.Webhook.Notify(webhook.PostCreated, id, createdPost)
+			}
+		}
+		results = append(results, result)
+	}
+
+	h.writeJSON(w, r, http.StatusOK, map[string]interface{}{"created": created, "results": results})
+}
+
+// importedPost converts a WXR item to a post. The item's first category
+// becomes the post's category; WordPress statuses other than publish and
+// future import as drafts.
+func (h *PostHandler) importedPost(item wxrImportItem) *model.Post {
+	post := &model.Post{
+		Title:         strings.TrimSpace(item.Title),
+		Content:       item.Content,
+		Author:        strings.TrimSpace(item.Creator),
+		AllowComments: item.CommentStatus != "closed",
+	}
+	if slug := strings.TrimSpace(item.PostName); slug != "" {
+		post.Slug = model.Slugify(slug)
+	}
+	for _, term := range item.Terms {
+		name := strings.TrimSpace(term.Name)
+		switch {
+		case name == "":
+		case term.Domain == wxrDomainCategory && post.Category == "":
+			post.Category = name
+		case term.Domain == wxrDomainTag:
+			post.Tags = append(post.Tags, name)
+		}
+	}
+	if post.Category == "" {
+		post.Category = h.DefaultCategory
+	}
+
+	date, dated := wxrItemDate(item)
+	switch item.Status {
+	case "publish":
+		post.Status = model.StatusPublished
+	case "future":
+		post.Status = model.StatusScheduled
+		if dated && !date.After(time.Now()) {
+			post.Status = model.StatusPublished
+		}
+	default:
+		post.Status = model.StatusDraft
+	}
+	if dated {
+		switch post.Status {
+		case model.StatusPublished:
+			post.PublishedAt = &date
+		case model.StatusScheduled:
+			post.PublishAt = &date
+		}
+	}
+
+	h.sanitizePost(post)
+	return post
+}
+
+// wxrItemDate returns an item's publication date, from wp:post_date_gmt or
+// else pubDate. Drafts carry a zero date, which reports false.
+func wxrItemDate(item wxrImportItem) (time.Time, bool) {
+	if t, err := time.Parse(wxrTimeFormat, strings.TrimSpace(item.PostDateGMT)); err == nil {
+		return t, true
+	}
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123} {
+		if t, err := time.Parse(layout, strings.TrimSpace(item.PubDate)); err == nil && t.Year() > 1 {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
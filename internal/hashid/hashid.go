@@ -0,0 +1,93 @@
+// Package hashid encodes int64 IDs as short opaque tokens and back, so
+// public URLs don't reveal how many posts exist or invite enumeration.
+//
+// The encoding is a keyed permutation, not encryption: it hides sequence
+// numbers from casual inspection but is not a substitute for access control.
+package hashid
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// ErrInvalid is returned when a token was not produced by the codec.
+var ErrInvalid = errors.New("invalid ID token")
+
+const alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Codec converts positive IDs to tokens and back using a secret. Codecs
+// built from the same secret agree on every token.
+type Codec struct {
+	mul, inv, xor uint64
+}
+
+// New creates a Codec keyed by secret, or returns nil if secret is empty.
+func New(secret string) *Codec {
+	if secret == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(secret))
+	// An odd multiplier is invertible modulo 2^64, which makes the encoding
+	// a bijection.
+	mul := binary.BigEndian.Uint64(sum[:8]) | 1
+	return &Codec{
+		mul: mul,
+		inv: inverse(mul),
+		xor: binary.BigEndian.Uint64(sum[8:16]),
+	}
+}
+
+// Encode returns the token for id.
+func (c *Codec) Encode(id int64) string {
+	v := uint64(id)*c.mul ^ c.xor
+
+	var buf [11]byte // 62^11 > 2^64
+	i := len(buf)
+	for {
+		i--
+		buf[i] = alphabet[v%62]
+		v /= 62
+		if v == 0 {
+			break
+		}
+	}
+	return string(buf[i:])
+}
+
+// Decode returns the ID encoded in token. It rejects tokens that Encode
+// would not produce, such as ones with leading zeros or for IDs below 1.
+func (c *Codec) Decode(token string) (int64, error) {
+	if token == "" || len(token) > 11 {
+		return 0, ErrInvalid
+	}
+	var v uint64
+	for i := 0; i < len(token); i++ {
+		d := strings.IndexByte(alphabet, token[i])
+		if d < 0 {
+			return 0, ErrInvalid
+		}
+		next := v*62 + uint64(d)
+		if next/62 != v {
+			return 0, ErrInvalid
+		}
+		v = next
+	}
+
+	id := int64((v ^ c.xor) * c.inv)
+	if id <= 0 || c.Encode(id) != token {
+		return 0, ErrInvalid
+	}
+	return id, nil
+}
+
+// inverse returns the multiplicative inverse of an odd x modulo 2^64 by
+// Newton's iteration, which doubles the number of correct bits each step.
+func inverse(x uint64) uint64 {
+	inv := x
+	for i := 0; i < 5; i++ {
+		inv *= 2 - x*inv
+	}
+	return inv
+}
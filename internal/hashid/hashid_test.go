@@ -0,0 +1,46 @@
+package hashid
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	c := New("secret")
+	for _, id := range []int64{1, 2, 3, 42, 1000, 1 << 40, math.MaxInt64} {
+		token := c.Encode(id)
+		got, err := c.Decode(token)
+		if err != nil || got != id {
+			t.Errorf("Decode(Encode(%d)) = %d, %v; token %q", id, got, err, token)
+		}
+	}
+}
+
+func TestOpaque(t *testing.T) {
+	c := New("secret")
+	if a, b := c.Encode(1), c.Encode(2); a == "1" || b == "2" || a == b {
+		t.Errorf("sequential IDs encode transparently: %q, %q", a, b)
+	}
+	if New("other").Encode(1) == c.Encode(1) {
+		t.Error("different secrets produce the same token")
+	}
+	if New("secret").Encode(7) != c.Encode(7) {
+		t.Error("the same secret produces different tokens")
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	c := New("secret")
+	token := c.Encode(5)
+	for _, bad := range []string{"", "0" + token, "!!", "zzzzzzzzzzzz", "ZZZZZZZZZZZ"} {
+		if id, err := c.Decode(bad); err == nil {
+			t.Errorf("Decode(%q) = %d, want an error", bad, id)
+		}
+	}
+}
+
+func TestNewWithoutSecret(t *testing.T) {
+	if c := New(""); c != nil {
+		t.Errorf("New(\"\") = %v, want nil", c)
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CanonicalHost redirects requests for any other host to canonical,
+// keeping the path and query, so that one hostname is indexed. canonical is
+// a host, optionally with a port, which keeps the request's scheme, or an
+// origin such as https://blog.example.com, which sets the scheme too. GET
+// and HEAD requests get 301 Moved Permanently; others get 308 Permanent
+// Redirect so clients resend the same method and body. An empty canonical
+// disables the redirect.
+func CanonicalHost(canonical string) func(http.Handler) http.Handler {
+	scheme, host, found := strings.Cut(canonical, "://")
+	if !found {
+		scheme, host = "", canonical
+	}
+	host = strings.TrimSuffix(host, "/")
+
+	return func(next http.Handler) http.Handler {
+		if host == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.EqualFold(r.Host, host) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			target := scheme
+			if target == "" {
+				target = "http"
+				if r.TLS != nil {
+					target = "https"
+				}
+			}
+			target += "://" + host + r.URL.RequestURI()
+
+			status := http.StatusPermanentRedirect
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				status = http.StatusMovedPermanently
+			}
+			http.Redirect(w, r, target, status)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalHost(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name      string
+		canonical string
+		method    string
+		host      string
+		want      int
+		location  string
+	}{
+		{"canonical", "blog.example.com", http.MethodGet, "blog.example.com", http.StatusOK, ""},
+		{"case", "blog.example.com", http.MethodGet, "Blog.Example.com", http.StatusOK, ""},
+		{"www", "blog.example.com", http.MethodGet, "www.blog.example.com", http.StatusMovedPermanently, "http://blog.example.com/posts?term=go&limit=5"},
+		{"bare ip", "https://blog.example.com", http.MethodGet, "203.0.113.7", http.StatusMovedPermanently, "https://blog.example.com/posts?term=go&limit=5"},
+		{"port", "localhost:8080", http.MethodHead, "127.0.0.1:8080", http.StatusMovedPermanently, "http://localhost:8080/posts?term=go&limit=5"},
+		{"write", "blog.example.com", http.MethodPost, "www.blog.example.com", http.StatusPermanentRedirect, "http://blog.example.com/posts?term=go&limit=5"},
+		{"disabled", "", http.MethodGet, "www.blog.example.com", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/posts?term=go&limit=5", nil)
+			req.Host = tt.host
+			rr := httptest.NewRecorder()
+			CanonicalHost(tt.canonical)(ok).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+			if got := rr.Header().Get("Location"); got != tt.location {
+				t.Errorf("handler returned wrong Location: got %q want %q", got, tt.location)
+			}
+		})
+	}
+}
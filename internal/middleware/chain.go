@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Chain composes middlewares into one, listed outermost first: Chain(a, b)(h)
+// is a(b(h)), so a sees each request before b does.
+func Chain(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// Default is the bundle every server wants outermost: request IDs, client
+// IP resolution through the trusted proxies, security headers, and request
+// logging, in that order. Requests taking at least slow are also logged as
+// slow; see Logging.
+func Default(logger *slog.Logger, trustedProxies []*net.IPNet, slow time.Duration) func(http.Handler) http.Handler {
+	return Chain(
+		RequestID,
+		RealIP(trustedProxies),
+		SecureHeaders,
+		Logging(logger, slow),
+	)
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	record := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := Chain(record("first"), record("second"), record("third"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+	if want := []string{"first", "second", "third", "handler"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("middlewares ran in wrong order: got %v want %v", order, want)
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		called := false
+		Chain()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+		if !called {
+			t.Error("empty chain did not call the handler")
+		}
+	})
+}
+
+func TestDefault(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var seen string
+	handler := Default(logger, nil, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+	if seen == "" || rr.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("request ID not assigned: context %q header %q", seen, rr.Header().Get(RequestIDHeader))
+	}
+	if got := rr.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("middleware returned wrong X-Content-Type-Options: got %q want %q", got, "nosniff")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(seen)) {
+		t.Errorf("request log does not carry the request ID: %s", buf.String())
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const clientIPKey contextKey = iota + 1
+
+// ParseCIDRs parses a list of CIDR ranges. A bare IP address is treated as a
+// single-address range.
+func ParseCIDRs(list []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(list))
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", s)
+			}
+			if ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", s)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// RealIP resolves each request's client IP and stores it in the request
+// context for ClientIP. The X-Forwarded-For and X-Real-IP headers are only
+// honored when the direct peer is in one of the trusted ranges; otherwise
+// the peer address is the client.
+func RealIP(trusted []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolveClientIP(r, trusted)
+			ctx := context.WithValue(r.Context(), clientIPKey, ip)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIP returns the client IP resolved by RealIP, falling back to the
+// host part of r.RemoteAddr when RealIP has not run.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey).(string); ok {
+		return ip
+	}
+	return remoteHost(r.RemoteAddr)
+}
+
+// resolveClientIP walks X-Forwarded-For from the nearest hop outwards,
+// skipping trusted proxies, and returns the first untrusted address. If
+// every hop is trusted the furthest one is used; X-Real-IP is consulted only
+// when X-Forwarded-For is absent.
+func resolveClientIP(r *http.Request, trusted []*net.IPNet) string {
+	peer := remoteHost(r.RemoteAddr)
+	if !isTrusted(peer, trusted) {
+		return peer
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		client := ""
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			client = hop
+			if !isTrusted(hop, trusted) {
+				break
+			}
+		}
+		if client != "" {
+			return client
+		}
+	}
+
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return peer
+}
+
+// isTrusted reports whether ip falls in any of the trusted ranges.
+func isTrusted(ip string, trusted []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteHost strips the port from a RemoteAddr.
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("ParseCIDRs() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"no headers", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer ignores forwarded for", "203.0.113.7:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.7"},
+		{"untrusted peer ignores real ip", "203.0.113.7:5000",
+			map[string]string{"X-Real-IP": "198.51.100.1"}, "203.0.113.7"},
+		{"trusted peer uses forwarded for", "10.1.2.3:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"trusted single address", "192.168.1.1:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"skips trusted hops", "10.1.2.3:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1, 10.9.9.9"}, "198.51.100.1"},
+		{"spoofed leftmost hop is ignored", "10.1.2.3:5000",
+			map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"trusted peer uses real ip", "10.1.2.3:5000",
+			map[string]string{"X-Real-IP": "198.51.100.1"}, "198.51.100.1"},
+		{"malformed header falls back to peer", "10.1.2.3:5000",
+			map[string]string{"X-Forwarded-For": "not-an-ip"}, "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("without middleware", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.RemoteAddr = "203.0.113.7:5000"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		if got := ClientIP(req); got != "203.0.113.7" {
+			t.Errorf("ClientIP() = %q, want %q", got, "203.0.113.7")
+		}
+	})
+}
+
+func TestParseCIDRs(t *testing.T) {
+	if _, err := ParseCIDRs([]string{"10.0.0.0/8", "::1", "2001:db8::/32"}); err != nil {
+		t.Errorf("ParseCIDRs() error = %v, want nil", err)
+	}
+	if _, err := ParseCIDRs([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("ParseCIDRs() error = nil, want an error for a bad mask")
+	}
+	if _, err := ParseCIDRs([]string{"proxy.local"}); err == nil {
+		t.Error("ParseCIDRs() error = nil, want an error for a hostname")
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import "net/http"
+
+// concurrencyRetryAfter is the Retry-After value, in seconds, sent when the
+// server is at capacity.
+const concurrencyRetryAfter = "1"
+
+// ConcurrencyLimit admits at most n requests at a time. Requests arriving
+// while n are in flight are rejected immediately with 503 Service
+// Unavailable rather than queued. A zero n disables the limit.
+func ConcurrencyLimit(n int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		sem := make(chan struct{}, n)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+				next.ServeHTTP(w, r)
+			default:
+				w.Header().Set("Retry-After", concurrencyRetryAfter)
+				WriteError(w, http.StatusServiceUnavailable, CodeServerBusy, "Server is busy")
+			}
+		})
+	}
+}
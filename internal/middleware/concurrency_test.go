@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	const limit = 2
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	h := ConcurrencyLimit(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+
+	// Fill every slot with a request that blocks until released.
+	var wg sync.WaitGroup
+	for i := 0; i < limit; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+		}()
+		<-entered
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/posts", nil))
+	if status := rr.Code; status != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusServiceUnavailable)
+	}
+	if code := errorCode(rr); code != CodeServerBusy {
+		t.Errorf("handler returned wrong error code: got %q want %q", code, CodeServerBusy)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("handler did not set Retry-After")
+	}
+
+	close(release)
+	wg.Wait()
+
+	// Slots are freed once requests finish.
+	done := make(chan struct{})
+	go func() {
+		<-entered
+		close(done)
+	}()
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/posts", nil))
+	<-done
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code after release: got %v want %v", status, http.StatusOK)
+	}
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins lists origins matched exactly. The entry "*" allows any
+	// origin and is meant for development.
+	AllowedOrigins []string
+	// AllowCredentials sets Access-Control-Allow-Credentials. The matching
+	// origin is always reflected instead of "*" when it is enabled, as
+	// browsers reject credentialed responses with a wildcard origin.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache preflight results. Zero omits
+	// the header.
+	MaxAge time.Duration
+	// AllowedMethods and AllowedHeaders are returned on preflight requests.
+	// Defaults are used when empty.
+	AllowedMethods []string
+	AllowedHeaders []string
+}
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", RequestIDHeader}
+)
+
+// CORS adds cross-origin headers for requests from allowed origins and
+// answers preflight requests. Requests from other origins pass through
+// without CORS headers, so browsers block them. CORS is disabled when no
+// origins are allowed.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	if len(opts.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	methods := opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := opts.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+
+	allowAny := false
+	allowed := make(map[string]bool, len(opts.AllowedOrigins))
+	for _, origin := range opts.AllowedOrigins {
+		if origin == "*" {
+			allowAny = true
+		}
+		allowed[origin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Responses differ by origin whether or not it is allowed, so
+			// shared caches must key on it.
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin == "" || !(allowAny || allowed[origin]) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowAny && !opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			// Answer preflight requests directly.
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+				h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+				if opts.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// PublicCORS lets any origin read the wrapped route, for public documents
+// such as feeds that readers fetch from anywhere. It wraps single routes
+// inside the router, so it overrides what CORS set further out: the origin
+// becomes "*" and credentials are dropped, which a wildcard origin can't
+// carry. Preflight requests that reach it are answered for GET and HEAD.
+// It passes requests through untouched while disabled.
+func PublicCORS(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", "*")
+			h.Del("Access-Control-Allow-Credentials")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", "GET, HEAD")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
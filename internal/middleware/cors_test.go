@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := CORS(CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})(next)
+
+	t.Run("allowed origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("wrong Access-Control-Allow-Origin: got %q want %q", got, "https://app.example.com")
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("wrong Access-Control-Allow-Credentials: got %q want %q", got, "true")
+		}
+		if got := rr.Header().Values("Vary"); !reflect.DeepEqual(got, []string{"Origin"}) {
+			t.Errorf("wrong Vary: got %q want %q", got, []string{"Origin"})
+		}
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("unexpected Access-Control-Allow-Origin: %q", got)
+		}
+		if got := rr.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("wrong Vary: got %q want %q", got, "Origin")
+		}
+		if rr.Code != http.StatusOK {
+			t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("preflight with credentials", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/posts", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNoContent {
+			t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusNoContent)
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("wrong Access-Control-Allow-Origin: got %q want %q", got, "https://app.example.com")
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("wrong Access-Control-Allow-Credentials: got %q want %q", got, "true")
+		}
+		if got := rr.Header().Get("Access-Control-Max-Age"); got != "600" {
+			t.Errorf("wrong Access-Control-Max-Age: got %q want %q", got, "600")
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Methods"); got == "" {
+			t.Error("missing Access-Control-Allow-Methods")
+		}
+		want := []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}
+		if got := rr.Header().Values("Vary"); !reflect.DeepEqual(got, want) {
+			t.Errorf("wrong Vary: got %q want %q", got, want)
+		}
+	})
+
+	t.Run("wildcard without credentials", func(t *testing.T) {
+		handler := CORS(CORSOptions{AllowedOrigins: []string{"*"}})(next)
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set("Origin", "https://anywhere.example.com")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("wrong Access-Control-Allow-Origin: got %q want %q", got, "*")
+		}
+	})
+}
+
+func TestPublicCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/posts", ok)
+	mux.Handle("/feed", PublicCORS(true)(ok))
+	mux.Handle("/private-feed", PublicCORS(false)(ok))
+	handler := CORS(CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+	})(mux)
+
+	get := func(method, path, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	tests := []struct {
+		method, path, origin string
+		allow, credentials   string
+	}{
+		{http.MethodGet, "/posts", "https://app.example.com", "https://app.example.com", "true"},
+		{http.MethodGet, "/posts", "https://reader.example.net", "", ""},
+		{http.MethodGet, "/feed", "https://app.example.com", "*", ""},
+		{http.MethodGet, "/feed", "https://reader.example.net", "*", ""},
+		{http.MethodGet, "/private-feed", "https://reader.example.net", "", ""},
+	}
+	for _, tt := range tests {
+		rr := get(tt.method, tt.path, tt.origin)
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tt.allow {
+			t.Errorf("%s from %s: wrong Access-Control-Allow-Origin: got %q want %q", tt.path, tt.origin, got, tt.allow)
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+			t.Errorf("%s from %s: wrong Access-Control-Allow-Credentials: got %q want %q", tt.path, tt.origin, got, tt.credentials)
+		}
+	}
+
+	rr := get(http.MethodOptions, "/feed", "https://reader.example.net")
+	if rr.Code != http.StatusNoContent || rr.Header().Get("Access-Control-Allow-Methods") != "GET, HEAD" {
+		t.Errorf("wrong preflight response: got %v %q", rr.Code, rr.Header().Get("Access-Control-Allow-Methods"))
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes of the requests middleware rejects. They share the code field
+// of the handler package's error responses, so clients branch on them the
+// same way.
+const (
+	CodeRateLimited = "RATE_LIMITED"
+	CodeServerBusy  = "SERVER_BUSY"
+	CodeReadOnly    = "READ_ONLY"
+)
+
+// ErrorResponse is the body of every error response: a machine-readable
+// code and a human-readable message.
+type ErrorResponse struct {
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// WriteError responds with status and an ErrorResponse, for errors raised
+// before or outside the handlers.
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	body, _ := json.Marshal(ErrorResponse{Code: code, Error: message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
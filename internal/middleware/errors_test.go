@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// errorCode returns the code of the JSON error response recorded by rr, or
+// "" if it is not one.
+func errorCode(rr *httptest.ResponseRecorder) string {
+	if rr.Header().Get("Content-Type") != "application/json" {
+		return ""
+	}
+	var resp ErrorResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	return resp.Code
+}
+
+func TestWriteError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	WriteError(rr, http.StatusServiceUnavailable, CodeServerBusy, "Server is busy")
+
+	if status := rr.Code; status != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusServiceUnavailable)
+	}
+	if got, want := rr.Body.String(), `{"code":"SERVER_BUSY","error":"Server is busy"}`; got != want {
+		t.Errorf("handler returned wrong body: got %s want %s", got, want)
+	}
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultGzipMinSize is the default value of GzipOptions.MinSize.
+const DefaultGzipMinSize = 1024
+
+// defaultGzipTypes are the media types compressed when
+// GzipOptions.ContentTypes is empty. Images other than SVG, archives, and
+// other already-compressed formats are left out on purpose.
+var defaultGzipTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"application/xml",
+	"application/javascript",
+	"image/svg+xml",
+	"text/plain",
+	"text/html",
+	"text/css",
+	"text/csv",
+}
+
+// GzipOptions configures the Gzip middleware.
+type GzipOptions struct {
+	// Level is the compression level, from gzip.BestSpeed to
+	// gzip.BestCompression, or gzip.DefaultCompression. Zero disables
+	// compression.
+	Level int
+	// MinSize is the smallest body, in bytes, worth compressing. Smaller
+	// responses are sent as is. Zero uses DefaultGzipMinSize.
+	MinSize int
+	// ContentTypes lists the media types that are compressed, ignoring
+	// parameters such as charset. Defaults are used when empty.
+	ContentTypes []string
+}
+
+// Gzip compresses responses for clients that accept gzip, as long as the
+// response is at least MinSize bytes, has an allowed content type, and is
+// not already encoded. Responses that are flushed before MinSize bytes are
+// written are treated as streams and compressed if their type allows.
+func Gzip(opts GzipOptions) func(http.Handler) http.Handler {
+	if opts.Level == gzip.NoCompression {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	if opts.MinSize <= 0 {
+		opts.MinSize = DefaultGzipMinSize
+	}
+	types := opts.ContentTypes
+	if len(types) == 0 {
+		types = defaultGzipTypes
+	}
+	allowed := make(map[string]bool, len(types))
+	for _, t := range types {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipWriter{ResponseWriter: w, opts: &opts, allowed: allowed, status: http.StatusOK}
+			defer gw.close()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipWriter holds back the start of a response until it knows whether to
+// compress it: once MinSize bytes are written, the response is flushed, or
+// the handler returns.
+type gzipWriter struct {
+	http.ResponseWriter
+	opts    *GzipOptions
+	allowed map[string]bool
+
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if !w.decided {
+		w.status = status
+	}
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.opts.MinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends everything written so far, compressing it if the response
+// is eligible.
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the held-back header and body, compressing them if large
+// is set and the response is eligible.
+func (w *gzipWriter) decide(large bool) error {
+	w.decided = true
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	if large && w.compressible() {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		// The compressed bytes differ from the ones a strong ETag vouches
+		// for, so it can only be weak.
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		gz, err := gzip.NewWriterLevel(w.ResponseWriter, w.opts.Level)
+		if err != nil {
+			return err
+		}
+		w.gz = gz
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	_, err := w.Write(buf)
+	return err
+}
+
+// compressible reports whether the response may be compressed.
+func (w *gzipWriter) compressible() bool {
+	if w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && w.allowed[strings.ToLower(mediaType)]
+}
+
+// close finishes the response once the handler returns.
+func (w *gzipWriter) close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzip(t *testing.T) {
+	large := `{"content":"` + strings.Repeat("compressible ", 200) + `"}`
+	respond := func(contentType, body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			io.WriteString(w, body)
+		})
+	}
+	do := func(h http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rr := httptest.NewRecorder()
+		Gzip(GzipOptions{Level: gzip.BestSpeed, MinSize: 512})(h).ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("small JSON", func(t *testing.T) {
+		rr := do(respond("application/json", `{"id":1}`), "gzip")
+		if got := rr.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("wrong Content-Encoding: got %q want none", got)
+		}
+		if got := rr.Body.String(); got != `{"id":1}` {
+			t.Errorf("wrong body: got %q want %q", got, `{"id":1}`)
+		}
+	})
+
+	t.Run("large JSON", func(t *testing.T) {
+		rr := do(respond("application/json; charset=utf-8", large), "deflate, gzip")
+		if got := rr.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("wrong Content-Encoding: got %q want %q", got, "gzip")
+		}
+		if got := rr.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("wrong Vary: got %q want %q", got, "Accept-Encoding")
+		}
+		if rr.Body.Len() >= len(large) {
+			t.Errorf("body was not compressed: %d bytes for %d", rr.Body.Len(), len(large))
+		}
+
+		zr, err := gzip.NewReader(rr.Body)
+		if err != nil {
+			t.Fatalf("gzip.NewReader() error = %v", err)
+		}
+		body, _ := io.ReadAll(zr)
+		if string(body) != large {
+			t.Errorf("decompressed body does not match the original")
+		}
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			handler        http.Handler
+			acceptEncoding string
+		}{
+			{"not accepted", respond("application/json", large), ""},
+			{"refused", respond("application/json", large), "gzip;q=0"},
+			{"binary type", respond("image/png", large), "gzip"},
+			{"already encoded", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", "br")
+				io.WriteString(w, large)
+			}), "gzip"},
+		}
+		for _, tt := range tests {
+			rr := do(tt.handler, tt.acceptEncoding)
+			if got := rr.Header().Get("Content-Encoding"); got == "gzip" {
+				t.Errorf("%s: response was compressed", tt.name)
+			}
+			if got := rr.Body.String(); got != large {
+				t.Errorf("%s: body was altered", tt.name)
+			}
+		}
+	})
+
+	t.Run("ETag", func(t *testing.T) {
+		tests := []struct {
+			etag, body, want string
+		}{
+			{`"abc"`, large, `W/"abc"`},
+			{`W/"abc"`, large, `W/"abc"`},
+			{`"abc"`, `{"id":1}`, `"abc"`},
+		}
+		for _, tt := range tests {
+			rr := do(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", tt.etag)
+				io.WriteString(w, tt.body)
+			}), "gzip")
+			if got := rr.Header().Get("ETag"); got != tt.want {
+				t.Errorf("ETag %s on %d bytes: got %s want %s", tt.etag, len(tt.body), got, tt.want)
+			}
+		}
+	})
+
+	t.Run("status kept", func(t *testing.T) {
+		rr := do(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, large)
+		}), "gzip")
+		if rr.Code != http.StatusCreated {
+			t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := httptest.NewRecorder()
+		Gzip(GzipOptions{})(respond("application/json", large)).ServeHTTP(rr, req)
+		if got := rr.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("wrong Content-Encoding: got %q want none", got)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController, so
+// handlers behind the logger can still flush.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Logging logs one structured line per request with its method, path,
+// status, duration, client IP, and request ID. Completed requests are logged
+// at info level, or error level for 5xx responses, so a warn threshold keeps
+// only server errors. A debug line is also written when each request starts,
+// and a warn line, "slow request", when one takes at least slow. Zero slow
+// disables the warning.
+func Logging(logger *slog.Logger, slow time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			logger.Debug("request started",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"requestId", RequestIDFromContext(r.Context()),
+			)
+
+			next.ServeHTTP(rec, r)
+			duration := time.Since(start)
+
+			if slow > 0 && duration >= slow {
+				logger.Warn("slow request",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"duration", duration,
+					"threshold", slow,
+					"requestId", RequestIDFromContext(r.Context()),
+				)
+			}
+
+			level := slog.LevelInfo
+			if rec.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.Log(r.Context(), level, "request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"duration", duration,
+				"clientIp", ClientIP(r),
+				"requestId", RequestIDFromContext(r.Context()),
+			)
+		})
+	}
+}
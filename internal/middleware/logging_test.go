@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogging(t *testing.T) {
+	serve := func(level slog.Level, status int) string {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+		h := Logging(logger, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+		return buf.String()
+	}
+
+	t.Run("info suppresses debug", func(t *testing.T) {
+		out := serve(slog.LevelInfo, http.StatusOK)
+		if strings.Contains(out, "request started") {
+			t.Errorf("debug line logged at info level: %s", out)
+		}
+		if !strings.Contains(out, `"level":"INFO"`) || !strings.Contains(out, `"status":200`) {
+			t.Errorf("request line missing at info level: %s", out)
+		}
+	})
+
+	t.Run("debug", func(t *testing.T) {
+		if out := serve(slog.LevelDebug, http.StatusOK); !strings.Contains(out, "request started") {
+			t.Errorf("debug line not logged at debug level: %s", out)
+		}
+	})
+
+	t.Run("warn keeps only server errors", func(t *testing.T) {
+		if out := serve(slog.LevelWarn, http.StatusNotFound); out != "" {
+			t.Errorf("client error logged at warn level: %s", out)
+		}
+		if out := serve(slog.LevelWarn, http.StatusInternalServerError); !strings.Contains(out, `"level":"ERROR"`) {
+			t.Errorf("server error not logged at warn level: %s", out)
+		}
+	})
+}
+
+func TestLoggingSlowRequests(t *testing.T) {
+	serve := func(delay time.Duration) string {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		h := Logging(logger, 20*time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+		return buf.String()
+	}
+
+	out := serve(30 * time.Millisecond)
+	if !strings.Contains(out, `"level":"WARN"`) || !strings.Contains(out, `"msg":"slow request"`) {
+		t.Errorf("slow request not logged at warn level: %s", out)
+	}
+	if !strings.Contains(out, `"method":"GET"`) || !strings.Contains(out, `"path":"/posts"`) || !strings.Contains(out, `"duration":`) {
+		t.Errorf("slow request line missing fields: %s", out)
+	}
+
+	if out := serve(0); out != "" {
+		t.Errorf("fast request logged at warn level: %s", out)
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Rate limit headers set on every response from RateLimit.
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	// RateLimitResetHeader holds the Unix time, in seconds, at which the
+	// current window ends.
+	RateLimitResetHeader = "X-RateLimit-Reset"
+)
+
+// RateLimit allows each client IP, as reported by ClientIP, at most limit
+// requests per minute. Further requests in the same minute are rejected
+// with 429 Too Many Requests. Every response reports the client's quota in
+// the rate limit headers. A zero limit disables rate limiting.
+func RateLimit(limit int) func(http.Handler) http.Handler {
+	return rateLimit(limit, time.Now)
+}
+
+// rateLimit is RateLimit with an injectable clock.
+func rateLimit(limit int, now func() time.Time) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		l := &limiter{limit: limit, now: now}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remaining, reset, ok := l.allow(ClientIP(r))
+			h := w.Header()
+			h.Set(RateLimitLimitHeader, strconv.Itoa(limit))
+			h.Set(RateLimitRemainingHeader, strconv.Itoa(remaining))
+			h.Set(RateLimitResetHeader, strconv.FormatInt(reset.Unix(), 10))
+			if !ok {
+				retry := reset.Sub(now())
+				h.Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+				WriteError(w, http.StatusTooManyRequests, CodeRateLimited, "Too many requests")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limiter counts requests per key in fixed one-minute windows. Counts are
+// discarded wholesale when a window ends, so memory is bounded by the
+// number of clients seen in a minute.
+type limiter struct {
+	mu     sync.Mutex
+	limit  int
+	now    func() time.Time
+	window time.Time
+	counts map[string]int
+}
+
+// allow records a request for key and reports whether it is within the
+// limit, along with the requests left in the window and when it ends.
+func (l *limiter) allow(key string) (remaining int, reset time.Time, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if window := now.Truncate(time.Minute); !window.Equal(l.window) {
+		l.window = window
+		l.counts = make(map[string]int)
+	}
+	reset = l.window.Add(time.Minute)
+	if l.counts[key] >= l.limit {
+		return 0, reset, false
+	}
+	l.counts[key]++
+	return l.limit - l.counts[key], reset, true
+}
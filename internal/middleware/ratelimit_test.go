@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	h := rateLimit(2, func() time.Time { return now })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := do("203.0.113.7:5000"); rr.Code != http.StatusOK {
+			t.Fatalf("request %d: handler returned wrong status code: got %v want %v", i+1, rr.Code, http.StatusOK)
+		}
+	}
+
+	rr := do("203.0.113.7:6000")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+	if code := errorCode(rr); code != CodeRateLimited {
+		t.Errorf("handler returned wrong error code: got %q want %q", code, CodeRateLimited)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("handler returned wrong Retry-After: got %q want %q", got, "30")
+	}
+
+	if rr := do("198.51.100.1:5000"); rr.Code != http.StatusOK {
+		t.Errorf("other client: handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	now = now.Add(time.Minute)
+	if rr := do("203.0.113.7:5000"); rr.Code != http.StatusOK {
+		t.Errorf("next window: handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	h := rateLimit(3, func() time.Time { return now })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	reset := strconv.FormatInt(time.Date(2024, 1, 1, 12, 1, 0, 0, time.UTC).Unix(), 10)
+
+	for i, want := range []string{"2", "1", "0", "0"} {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.RemoteAddr = "203.0.113.7:5000"
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get(RateLimitLimitHeader); got != "3" {
+			t.Errorf("request %d: wrong %s: got %q want %q", i+1, RateLimitLimitHeader, got, "3")
+		}
+		if got := rr.Header().Get(RateLimitRemainingHeader); got != want {
+			t.Errorf("request %d: wrong %s: got %q want %q", i+1, RateLimitRemainingHeader, got, want)
+		}
+		if got := rr.Header().Get(RateLimitResetHeader); got != reset {
+			t.Errorf("request %d: wrong %s: got %q want %q", i+1, RateLimitResetHeader, got, reset)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ReadOnly rejects every request that could write, anything other than
+// GET, HEAD, or OPTIONS, with 503 Service Unavailable while enabled. Reads
+// pass through untouched, as do POSTs to readPaths, endpoints that take a
+// body but write nothing, such as a search by ID. A trailing slash on the
+// request path is ignored when matching them.
+func ReadOnly(enabled bool, readPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		reads := make(map[string]bool, len(readPaths))
+		for _, path := range readPaths {
+			reads[path] = true
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet, r.Method == http.MethodHead, r.Method == http.MethodOptions:
+				next.ServeHTTP(w, r)
+			case r.Method == http.MethodPost && reads[strings.TrimSuffix(r.URL.Path, "/")]:
+				next.ServeHTTP(w, r)
+			default:
+				WriteError(w, http.StatusServiceUnavailable, CodeReadOnly, "The API is in read-only mode for maintenance; writes are temporarily disabled")
+			}
+		})
+	}
+}
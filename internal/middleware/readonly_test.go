@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name    string
+		enabled bool
+		method  string
+		path    string
+		want    int
+	}{
+		{"enabled get", true, http.MethodGet, "/posts", http.StatusOK},
+		{"enabled head", true, http.MethodHead, "/posts", http.StatusOK},
+		{"enabled post", true, http.MethodPost, "/posts", http.StatusServiceUnavailable},
+		{"enabled put", true, http.MethodPut, "/posts/1", http.StatusServiceUnavailable},
+		{"enabled patch", true, http.MethodPatch, "/posts/1", http.StatusServiceUnavailable},
+		{"enabled delete", true, http.MethodDelete, "/posts/1", http.StatusServiceUnavailable},
+		{"enabled read post", true, http.MethodPost, "/posts/lookup", http.StatusOK},
+		{"enabled read post with slash", true, http.MethodPost, "/posts/lookup/", http.StatusOK},
+		{"enabled put to read path", true, http.MethodPut, "/posts/lookup", http.StatusServiceUnavailable},
+		{"disabled post", false, http.MethodPost, "/posts", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ReadOnly(tt.enabled, "/posts/lookup")(ok).ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if status := rr.Code; status != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+			if code := errorCode(rr); tt.want != http.StatusOK && code != CodeReadOnly {
+				t.Errorf("handler returned wrong error code: got %q want %q", code, CodeReadOnly)
+			}
+		})
+	}
+}
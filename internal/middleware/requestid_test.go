@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	t.Run("echoes supplied ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set(RequestIDHeader, "abc-123")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get(RequestIDHeader); got != "abc-123" {
+			t.Errorf("middleware returned wrong request ID: got %q want %q", got, "abc-123")
+		}
+		if seen != "abc-123" {
+			t.Errorf("context has wrong request ID: got %q want %q", seen, "abc-123")
+		}
+	})
+
+	t.Run("generates ID when absent", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		got := rr.Header().Get(RequestIDHeader)
+		if len(got) != 32 {
+			t.Errorf("middleware generated malformed request ID: %q", got)
+		}
+		if seen != got {
+			t.Errorf("context has wrong request ID: got %q want %q", seen, got)
+		}
+	})
+
+	t.Run("replaces malformed ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set(RequestIDHeader, "bad id\n")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get(RequestIDHeader); got == "bad id\n" || got == "" {
+			t.Errorf("middleware kept malformed request ID: %q", got)
+		}
+	})
+}
+
+func TestLoggingIncludesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	handler := RequestID(Logging(logger, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Header.Set(RequestIDHeader, "log-me")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	if !strings.Contains(line, `"requestId":"log-me"`) || !strings.Contains(line, `"status":418`) {
+		t.Errorf("log line missing request details: %s", line)
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Run("sets deadline", func(t *testing.T) {
+		var deadline time.Time
+		var ok bool
+		h := Timeout(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok = r.Context().Deadline()
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+		if !ok || time.Until(deadline) > time.Minute {
+			t.Errorf("request deadline = %v (set %v), want within a minute", deadline, ok)
+		}
+	})
+
+	t.Run("zero disables", func(t *testing.T) {
+		var ok bool
+		h := Timeout(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok = r.Context().Deadline()
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+		if ok {
+			t.Error("request has a deadline, want none")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Comment moderation statuses. New comments are pending until a moderator
+// approves or rejects them, and only approved comments are shown publicly.
+const (
+	CommentPending  = "pending"
+	CommentApproved = "approved"
+	CommentRejected = "rejected"
+)
+
+// DeletedCommentContent replaces the content of a deleted comment that is
+// still listed to keep its replies in place.
+const DeletedCommentContent = "[deleted]"
+
+// Comment is a reader's comment on a post.
+type Comment struct {
+	ID     int64 `json:"id" id:""`
+	PostID int64 `json:"postId" id:""`
+	// ParentID is the comment this one replies to, on the same post.
+	ParentID  *int64    `json:"parentId,omitempty" id:""`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	// DeletedAt is set when the comment is soft-deleted.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// Replies is set only when comments are listed as a tree.
+	Replies []*Comment `json:"replies,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, formatting timestamps with
+// TimeFormat and the post ID with EncodePostID.
+func (c Comment) MarshalJSON() ([]byte, error) {
+	type commentJSON Comment
+
+	return json.Marshal(struct {
+		commentJSON
+		PostID    interface{} `json:"postId"`
+		CreatedAt string      `json:"createdAt"`
+		DeletedAt *string     `json:"deletedAt,omitempty"`
+	}{
+		commentJSON: commentJSON(c),
+		PostID:      EncodePostID(c.PostID),
+		CreatedAt:   FormatTime(c.CreatedAt),
+		DeletedAt:   FormatOptionalTime(c.DeletedAt),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the parent ID as a
+// number or a string.
+func (c *Comment) UnmarshalJSON(data []byte) error {
+	type commentJSON Comment
+
+	aux := struct {
+		*commentJSON
+		ParentID json.RawMessage `json:"parentId"`
+	}{commentJSON: (*commentJSON)(c)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.ParentID) == 0 || string(aux.ParentID) == "null" {
+		return nil
+	}
+	id, err := decodeIntID(aux.ParentID)
+	if err != nil {
+		return err
+	}
+	c.ParentID = &id
+	return nil
+}
@@ -0,0 +1,44 @@
+package model
+
+import "unicode"
+
+// WordsPerMinute is the reading speed behind ReadingTime.
+const WordsPerMinute = 200
+
+// CountWords counts the words in content, ignoring HTML tags.
+func CountWords(content string) int {
+	words, inWord, inTag := 0, false, false
+	for _, r := range content {
+		switch {
+		case r == '<':
+			inTag, inWord = true, false
+		case r == '>' && inTag:
+			inTag = false
+		case inTag:
+		case unicode.IsSpace(r):
+			inWord = false
+		case !inWord:
+			inWord = true
+			words++
+		}
+	}
+	return words
+}
+
+// ReadingMinutes estimates how many minutes words take to read at
+// WordsPerMinute, rounding up. Any text takes at least a minute.
+func ReadingMinutes(words int) int {
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
+
+// Derive recomputes the fields computed from the post's content and reports
+// whether any of them changed. The slug is store-specific and left alone.
+func (p *Post) Derive() bool {
+	words := CountWords(p.Content)
+	minutes := ReadingMinutes(words)
+	if p.WordCount == words && p.ReadingTime == minutes {
+		return false
+	}
+	p.WordCount, p.ReadingTime = words, minutes
+	return true
+}
@@ -0,0 +1,32 @@
+package model
+
+import "testing"
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"", 0},
+		{"one two  three", 3},
+		{"<p>Hello <b>bold</b> world</p>", 3},
+		{"line one\nline\ttwo", 4},
+		{"<img src=\"a.png\" alt=\"many words here\">", 0},
+	}
+
+	for _, tt := range tests {
+		if got := CountWords(tt.content); got != tt.want {
+			t.Errorf("CountWords(%q) = %d, want %d", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestReadingMinutes(t *testing.T) {
+	tests := []struct{ words, want int }{{0, 0}, {1, 1}, {200, 1}, {201, 2}, {1000, 5}}
+
+	for _, tt := range tests {
+		if got := ReadingMinutes(tt.words); got != tt.want {
+			t.Errorf("ReadingMinutes(%d) = %d, want %d", tt.words, got, tt.want)
+		}
+	}
+}
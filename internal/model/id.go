@@ -0,0 +1,171 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// IDCodec converts post IDs to and from the opaque tokens that replace them
+// in the API when ID obfuscation is enabled.
+type IDCodec interface {
+	Encode(id int64) string
+	Decode(token string) (int64, error)
+}
+
+// idCodec, when set, makes posts serialize their ID as a token.
+var idCodec IDCodec
+
+// SetIDCodec makes every post serialize its id as a token from c, and
+// accept one when decoded. Nil restores plain integer IDs. It must be called
+// before serving requests.
+func SetIDCodec(c IDCodec) {
+	idCodec = c
+}
+
+// EncodePostID returns the JSON value for a post ID: the codec's token when
+// one is set, otherwise the integer itself. Posts write their id with it,
+// and so should anything else carrying a post ID.
+func EncodePostID(id int64) interface{} {
+	if idCodec == nil {
+		return id
+	}
+	return idCodec.Encode(id)
+}
+
+// decodeIntID parses a JSON integer ID written as a number or, as
+// QuoteIDs writes it, a decimal string.
+func decodeIntID(raw json.RawMessage) (int64, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strconv.ParseInt(s, 10, 64)
+	}
+	var id int64
+	err := json.Unmarshal(raw, &id)
+	return id, err
+}
+
+// decodeID parses a JSON post ID written by EncodePostID. Integers, as numbers
+// or strings, are accepted only when no codec is set, and tokens only when
+// one is.
+func decodeID(raw json.RawMessage) (int64, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return 0, nil
+	}
+	if idCodec == nil {
+		return decodeIntID(raw)
+	}
+	var token string
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return 0, errors.New("post id must be a token")
+	}
+	return idCodec.Decode(token)
+}
+
+// IDTag is the struct tag marking a field that holds an integer ID, or a
+// list of them, as in `json:"postId" id:""`. It declares the JSON keys that
+// QuoteIDs rewrites.
+const IDTag = "id"
+
+// IDKeys returns the JSON names of the fields marked with IDTag in the
+// given structs' types.
+func IDKeys(structs ...interface{}) map[string]bool {
+	keys := make(map[string]bool)
+	for _, v := range structs {
+		t := reflect.TypeOf(v)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if _, ok := field.Tag.Lookup(IDTag); !ok {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// QuoteIDs rewrites encoded JSON so that every integer value of a key in
+// keys, or in an array that is such a value, becomes a decimal string, for
+// clients that would lose precision parsing it as a number. The output is
+// compact.
+func QuoteIDs(data []byte, keys map[string]bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// Each open object or array counts the tokens written into it, which
+	// places the separators and tells object keys from values.
+	type container struct {
+		object bool
+		tokens int
+		// ids is set on an array that is the value of an ID key.
+		ids bool
+	}
+	var stack []container
+	var key string
+	var out bytes.Buffer
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			out.WriteByte(byte(delim))
+			continue
+		}
+
+		isKey, isID := false, false
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			switch {
+			case top.object && top.tokens%2 == 0:
+				isKey = true
+				if top.tokens > 0 {
+					out.WriteByte(',')
+				}
+			case top.object:
+				isID = keys[key]
+				out.WriteByte(':')
+			default:
+				isID = top.ids
+				if top.tokens > 0 {
+					out.WriteByte(',')
+				}
+			}
+			top.tokens++
+		}
+
+		switch tok := tok.(type) {
+		case json.Delim:
+			stack = append(stack, container{object: tok == '{', ids: tok == '[' && isID})
+			out.WriteByte(byte(tok))
+		case string:
+			if isKey {
+				key = tok
+			}
+			b, _ := json.Marshal(tok)
+			out.Write(b)
+		case json.Number:
+			if _, err := tok.Int64(); err == nil && isID {
+				out.WriteString(strconv.Quote(tok.String()))
+			} else {
+				out.WriteString(tok.String())
+			}
+		case bool:
+			out.WriteString(strconv.FormatBool(tok))
+		case nil:
+			out.WriteString("null")
+		}
+	}
+}
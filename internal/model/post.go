@@ -1,14 +1,134 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// TimeFormat is the layout used for every timestamp in API responses: RFC
+// 3339 in UTC with a fixed nanosecond precision, so values serialize the same
+// way no matter how a store round-trips them.
+const TimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// FormatTime formats t in UTC using TimeFormat.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(TimeFormat)
+}
+
+// FormatOptionalTime formats t with FormatTime, or returns nil if t is nil.
+func FormatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := FormatTime(*t)
+	return &formatted
+}
+
+// Post statuses.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	// StatusScheduled posts are published automatically at their PublishAt
+	// time.
+	StatusScheduled = "scheduled"
+)
 
 // Post represents a blog post.
 type Post struct {
-	ID        int64     `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Category  string    `json:"category"`
-	Tags      []string  `json:"tags"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID           int64                  `json:"id" id:""`
+	Title        string                 `json:"title"`
+	Slug         string                 `json:"slug"`
+	Content      string                 `json:"content"`
+	Category     string                 `json:"category"`
+	Tags         []string               `json:"tags"`
+	Author       string                 `json:"author"`
+	AuthorName   string                 `json:"authorName,omitempty"`
+	Status       string                 `json:"status"`
+	Translations map[string]Translation `json:"translations,omitempty"`
+	ImageURL     string                 `json:"imageUrl,omitempty"`
+	CreatedAt    time.Time              `json:"createdAt"`
+	UpdatedAt    time.Time              `json:"updatedAt"`
+	PublishedAt  *time.Time             `json:"publishedAt,omitempty"`
+	// PublishAt is when a scheduled post goes live.
+	PublishAt *time.Time `json:"publishAt,omitempty"`
+	// WordCount and ReadingTime, in minutes, are derived from Content
+	// whenever it is stored.
+	WordCount   int `json:"wordCount"`
+	ReadingTime int `json:"readingTime"`
+	// AllowComments is false when readers may not comment on the post. It
+	// defaults to true when a request body omits it.
+	AllowComments bool `json:"allowComments"`
+	// Featured highlights the post; the home order lists featured posts
+	// right after pinned ones.
+	Featured bool `json:"featured"`
+	// PinOrder is the post's 1-based position among pinned posts, which lead
+	// every listing. It is nil for unpinned posts.
+	PinOrder *int `json:"pinOrder,omitempty"`
+	// DeletedAt is set when the post is soft-deleted.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// Snippet is set only on search results, around the first match, and
+	// on previews, where it is an excerpt.
+	Snippet string `json:"snippet,omitempty"`
+	// MatchedField is set only on search results, naming the first of
+	// title, content, category, or comments that contains the term.
+	MatchedField string `json:"matchedField,omitempty"`
+	// CommentCount is set only on most-commented rankings, to the number
+	// of approved comments.
+	CommentCount int `json:"commentCount,omitempty"`
+	// Comments is set only when a listing expands comments.
+	Comments []*Comment `json:"comments,omitempty"`
+}
+
+// Translation holds a localized variant of a post's title and content.
+type Translation struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// MarshalJSON implements json.Marshaler, formatting timestamps with
+// TimeFormat and the ID as a token when an IDCodec is set.
+func (p Post) MarshalJSON() ([]byte, error) {
+	// postJSON has the same fields as Post but none of its methods, which
+	// avoids recursing into MarshalJSON.
+	type postJSON Post
+
+	return json.Marshal(struct {
+		postJSON
+		ID          interface{} `json:"id"`
+		CreatedAt   string      `json:"createdAt"`
+		UpdatedAt   string      `json:"updatedAt"`
+		PublishedAt *string     `json:"publishedAt,omitempty"`
+		PublishAt   *string     `json:"publishAt,omitempty"`
+		DeletedAt   *string     `json:"deletedAt,omitempty"`
+	}{
+		postJSON:    postJSON(p),
+		ID:          EncodePostID(p.ID),
+		CreatedAt:   FormatTime(p.CreatedAt),
+		UpdatedAt:   FormatTime(p.UpdatedAt),
+		PublishedAt: FormatOptionalTime(p.PublishedAt),
+		PublishAt:   FormatOptionalTime(p.PublishAt),
+		DeletedAt:   FormatOptionalTime(p.DeletedAt),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the ID in the form
+// MarshalJSON writes it. AllowComments is true unless the data sets it.
+func (p *Post) UnmarshalJSON(data []byte) error {
+	type postJSON Post
+
+	p.AllowComments = true
+	aux := struct {
+		*postJSON
+		ID json.RawMessage `json:"id"`
+	}{postJSON: (*postJSON)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	id, err := decodeID(aux.ID)
+	if err != nil {
+		return err
+	}
+	p.ID = id
+	return nil
 }
@@ -0,0 +1,176 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostMarshalJSONTimestamps(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	created := time.Date(2024, 3, 5, 12, 30, 0, 120000000, loc)
+	published := time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC)
+
+	data, err := json.Marshal(Post{
+		ID:          1,
+		Title:       "Title",
+		CreatedAt:   created,
+		UpdatedAt:   created,
+		PublishedAt: &published,
+	})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	var got map[string]interface{}
+	json.Unmarshal(data, &got)
+
+	if want := "2024-03-05T10:30:00.120000000Z"; got["createdAt"] != want {
+		t.Errorf("createdAt = %v, want %v", got["createdAt"], want)
+	}
+	if want := "2024-03-05T10:30:00.000000000Z"; got["publishedAt"] != want {
+		t.Errorf("publishedAt = %v, want %v", got["publishedAt"], want)
+	}
+	if got["title"] != "Title" {
+		t.Errorf("title = %v, want %v", got["title"], "Title")
+	}
+
+	// The output must still decode back into a Post.
+	var decoded Post
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !decoded.CreatedAt.Equal(created) {
+		t.Errorf("decoded CreatedAt = %v, want %v", decoded.CreatedAt, created)
+	}
+}
+
+func TestPostMarshalJSONOmitsUnpublished(t *testing.T) {
+	data, _ := json.Marshal(Post{Title: "Draft"})
+
+	var got map[string]interface{}
+	json.Unmarshal(data, &got)
+	if _, ok := got["publishedAt"]; ok {
+		t.Errorf("publishedAt present for unpublished post: %s", data)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"  Go 1.21 -- Released  ", "go-1-21-released"},
+		{"Café crème", "caf-cr-me"},
+		{"!!!", "post"},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.title); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+		if !ValidSlug(Slugify(tt.title)) {
+			t.Errorf("Slugify(%q) = %q is not a valid slug", tt.title, Slugify(tt.title))
+		}
+	}
+
+	long := Slugify(strings.Repeat("ab ", 100))
+	if len(long) > MaxSlugLength || !ValidSlug(long) {
+		t.Errorf("Slugify(long title) = %q (%d bytes), want a valid slug of at most %d", long, len(long), MaxSlugLength)
+	}
+}
+
+// prefixCodec is a trivial IDCodec for tests.
+type prefixCodec struct{}
+
+func (prefixCodec) Encode(id int64) string { return "p" + strconv.FormatInt(id, 10) }
+
+func (prefixCodec) Decode(token string) (int64, error) {
+	digits, ok := strings.CutPrefix(token, "p")
+	if !ok {
+		return 0, errors.New("bad token")
+	}
+	return strconv.ParseInt(digits, 10, 64)
+}
+
+func TestPostIDCodec(t *testing.T) {
+	SetIDCodec(prefixCodec{})
+	t.Cleanup(func() { SetIDCodec(nil) })
+
+	data, err := json.Marshal(Post{ID: 42, Title: "Title"})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"id":"p42"`) {
+		t.Errorf("json.Marshal() = %s, want the id as a token", data)
+	}
+
+	var decoded Post
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.ID != 42 || decoded.Title != "Title" {
+		t.Errorf("json.Unmarshal() = %+v, %v; want ID 42", decoded, err)
+	}
+	if err := json.Unmarshal([]byte(`{"id":42}`), &decoded); err == nil {
+		t.Error("json.Unmarshal() accepted an integer id while a codec is set")
+	}
+
+	data, _ = json.Marshal([]interface{}{Comment{ID: 7, PostID: 42}, Revision{Number: 1, PostID: 42}})
+	if strings.Count(string(data), `"postId":"p42"`) != 2 || !strings.Contains(string(data), `"id":7`) {
+		t.Errorf("json.Marshal() = %s, want post IDs as tokens and the comment ID as an integer", data)
+	}
+}
+
+func TestIDsAsStrings(t *testing.T) {
+	const id = int64(1<<53 + 1)
+	parent := id - 1
+	data, err := json.Marshal([]interface{}{Post{ID: id}, Comment{ID: id, PostID: id, ParentID: &parent}})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"id":9007199254740993`) || strings.Contains(string(data), `"9007199254740993"`) {
+		t.Errorf("json.Marshal() = %s, want numeric ids", data)
+	}
+
+	quoted, err := QuoteIDs(data, IDKeys(Post{}, Comment{}))
+	if err != nil {
+		t.Fatalf("QuoteIDs() error = %v", err)
+	}
+	for _, want := range []string{`"id":"9007199254740993"`, `"postId":"9007199254740993"`, `"parentId":"9007199254740992"`} {
+		if !strings.Contains(string(quoted), want) {
+			t.Errorf("QuoteIDs() = %s, want %s", quoted, want)
+		}
+	}
+
+	var post Post
+	if err := json.Unmarshal([]byte(`{"id":"9007199254740993"}`), &post); err != nil || post.ID != id {
+		t.Errorf("json.Unmarshal() = %+v, %v; want ID %d", post, err, id)
+	}
+	var comment Comment
+	if err := json.Unmarshal([]byte(`{"parentId":"9007199254740992"}`), &comment); err != nil || comment.ParentID == nil || *comment.ParentID != parent {
+		t.Errorf("json.Unmarshal() = %+v, %v; want parent %d", comment, err, parent)
+	}
+}
+
+func TestIDKeys(t *testing.T) {
+	keys := IDKeys(Post{}, Comment{}, Revision{})
+	for _, key := range []string{"id", "postId", "parentId"} {
+		if !keys[key] {
+			t.Errorf("IDKeys() = %v, want %q", keys, key)
+		}
+	}
+	if len(keys) != 3 {
+		t.Errorf("IDKeys() = %v, want only the tagged fields", keys)
+	}
+}
+
+func TestQuoteIDs(t *testing.T) {
+	keys := map[string]bool{"id": true, "postId": true, "parentId": true, "ids": true}
+	in := `{"id":7,"title":"a \"b\"","count":3,"tags":[1,2],"ids":[4,5],"requestId":9,"replies":[{"postId":8,"parentId":null,"score":1.5}],"ok":true}`
+	want := `{"id":"7","title":"a \"b\"","count":3,"tags":[1,2],"ids":["4","5"],"requestId":9,"replies":[{"postId":"8","parentId":null,"score":1.5}],"ok":true}`
+	got, err := QuoteIDs([]byte(in), keys)
+	if err != nil || string(got) != want {
+		t.Errorf("QuoteIDs() = %s, %v; want %s", got, err, want)
+	}
+}
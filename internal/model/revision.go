@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Revision is a snapshot of a post's editable fields, recorded when the post
+// is created and each time it is updated. Revisions are numbered from 1.
+type Revision struct {
+	Number    int       `json:"number"`
+	PostID    int64     `json:"postId" id:""`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Category  string    `json:"category"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON implements json.Marshaler, formatting timestamps with
+// TimeFormat and the post ID with EncodePostID.
+func (r Revision) MarshalJSON() ([]byte, error) {
+	type revisionJSON Revision
+
+	return json.Marshal(struct {
+		revisionJSON
+		PostID    interface{} `json:"postId"`
+		CreatedAt string      `json:"createdAt"`
+	}{
+		revisionJSON: revisionJSON(r),
+		PostID:       EncodePostID(r.PostID),
+		CreatedAt:    FormatTime(r.CreatedAt),
+	})
+}
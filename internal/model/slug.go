@@ -0,0 +1,52 @@
+package model
+
+import "strings"
+
+// MaxSlugLength is the longest slug a post may have.
+const MaxSlugLength = 100
+
+// Slugify derives a URL slug from a title: lowercase ASCII letters and
+// digits, with every other run of characters collapsed into a single hyphen.
+// It returns "post" if the title has no usable characters.
+func Slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		} else {
+			hyphen = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > MaxSlugLength {
+		slug = strings.TrimRight(slug[:MaxSlugLength], "-")
+	}
+	if slug == "" {
+		return "post"
+	}
+	return slug
+}
+
+// ValidSlug reports whether s is a well-formed slug: lowercase ASCII letters
+// and digits in hyphen-separated groups, no longer than MaxSlugLength.
+func ValidSlug(s string) bool {
+	if s == "" || len(s) > MaxSlugLength || s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-' && s[i-1] != '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package model
+
+import "strings"
+
+// FieldError describes a validation problem with a single input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors accumulates field-level validation problems so that all of
+// them can be reported at once.
+type ValidationErrors []FieldError
+
+// Add records a problem with the given field.
+func (v *ValidationErrors) Add(field, message string) {
+	*v = append(*v, FieldError{Field: field, Message: message})
+}
+
+// Error implements the error interface.
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, fe := range v {
+		msgs = append(msgs, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, ", ")
+}
@@ -0,0 +1,260 @@
+package sanitize
+
+import (
+	"html"
+	"strings"
+)
+
+// Policy describes which HTML elements and attributes survive sanitization.
+// Anything not explicitly allowed is stripped; the text inside a stripped
+// element is kept, except for elements whose content is never safe to show
+// (such as script and style), which are removed entirely.
+type Policy struct {
+	// Elements maps an allowed lowercase tag name to its allowed attributes.
+	Elements map[string][]string
+}
+
+// urlAttributes are attributes whose values are URLs and must use a safe scheme.
+var urlAttributes = map[string]bool{"href": true, "src": true, "cite": true}
+
+// rawTextElements have content that is dropped along with the element.
+var rawTextElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true,
+	"embed": true, "noscript": true, "template": true, "textarea": true,
+}
+
+// DefaultPolicy returns a policy allowing basic formatting, headings, lists,
+// and links.
+func DefaultPolicy() *Policy {
+	elements := map[string][]string{"a": {"href", "title"}}
+	for _, tag := range []string{
+		"b", "strong", "i", "em", "u", "p", "br", "blockquote", "code", "pre",
+		"ul", "ol", "li", "h1", "h2", "h3", "h4", "h5", "h6",
+	} {
+		elements[tag] = nil
+	}
+	return &Policy{Elements: elements}
+}
+
+// NewPolicy creates a policy allowing the given elements, each listed with
+// its allowed attributes.
+func NewPolicy(elements map[string][]string) *Policy {
+	p := &Policy{Elements: make(map[string][]string, len(elements))}
+	for tag, attrs := range elements {
+		lowered := make([]string, len(attrs))
+		for i, attr := range attrs {
+			lowered[i] = strings.ToLower(attr)
+		}
+		p.Elements[strings.ToLower(tag)] = lowered
+	}
+	return p
+}
+
+// Sanitize returns s with every element and attribute outside the policy
+// removed. Stray angle brackets are escaped.
+func (p *Policy) Sanitize(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		// Drop comments, which browsers may treat as conditional markup.
+		if strings.HasPrefix(s[i:], "<!--") {
+			end := strings.Index(s[i+4:], "-->")
+			if end < 0 {
+				break
+			}
+			i += 4 + end + 3
+			continue
+		}
+
+		t, n := parseTag(s[i:])
+		if n == 0 {
+			b.WriteString("&lt;")
+			i++
+			continue
+		}
+		i += n
+
+		if rawTextElements[t.name] {
+			if !t.closing {
+				i += skipRawText(s[i:], t.name)
+			}
+			continue
+		}
+
+		allowed, ok := p.Elements[t.name]
+		if !ok {
+			continue
+		}
+		b.WriteString(t.render(allowed))
+	}
+
+	return b.String()
+}
+
+// tag is a parsed start or end tag.
+type tag struct {
+	name    string
+	closing bool
+	attrs   [][2]string
+}
+
+// render writes the tag keeping only the allowed attributes.
+func (t tag) render(allowed []string) string {
+	if t.closing {
+		return "</" + t.name + ">"
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + t.name)
+	for _, attr := range t.attrs {
+		name, value := attr[0], attr[1]
+		if !contains(allowed, name) {
+			continue
+		}
+		if urlAttributes[name] && !safeURL(value) {
+			continue
+		}
+		b.WriteString(" " + name + `="` + html.EscapeString(value) + `"`)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// parseTag parses the tag at the start of s, which begins with '<'. It
+// returns the number of bytes consumed, or zero if s does not start with a
+// well-formed tag.
+func parseTag(s string) (tag, int) {
+	var t tag
+	i := 1
+	if i < len(s) && s[i] == '/' {
+		t.closing = true
+		i++
+	}
+
+	start := i
+	for i < len(s) && isNameByte(s[i], i == start) {
+		i++
+	}
+	if i == start {
+		return tag{}, 0
+	}
+	t.name = strings.ToLower(s[start:i])
+
+	for {
+		for i < len(s) && (isSpace(s[i]) || s[i] == '/') {
+			i++
+		}
+		if i >= len(s) {
+			return tag{}, 0
+		}
+		if s[i] == '>' {
+			return t, i + 1
+		}
+
+		nameStart := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(s[nameStart:i])
+
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		var value string
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				end := strings.IndexByte(s[i+1:], quote)
+				if end < 0 {
+					return tag{}, 0
+				}
+				value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				valueStart := i
+				for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[valueStart:i]
+			}
+		}
+		t.attrs = append(t.attrs, [2]string{name, html.UnescapeString(value)})
+	}
+}
+
+// skipRawText returns the number of bytes up to and including the end tag of
+// the named element, or len(s) if it is never closed.
+func skipRawText(s, name string) int {
+	end := indexFoldASCII(s, "</"+name)
+	if end < 0 {
+		return len(s)
+	}
+	closeIdx := strings.IndexByte(s[end:], '>')
+	if closeIdx < 0 {
+		return len(s)
+	}
+	return end + closeIdx + 1
+}
+
+// indexFoldASCII is strings.Index with ASCII case folding. Unlike lowering
+// the whole string first, it keeps byte offsets valid for s.
+func indexFoldASCII(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// safeURL reports whether a URL is relative or uses an allowed scheme.
+func safeURL(raw string) bool {
+	// Browsers ignore whitespace and control characters inside schemes.
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(raw))
+
+	colon := strings.IndexByte(cleaned, ':')
+	if colon < 0 || strings.ContainsAny(cleaned[:colon], "/?#") {
+		return true
+	}
+	switch cleaned[:colon] {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+func isNameByte(c byte, first bool) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+		return true
+	}
+	return !first && c >= '0' && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
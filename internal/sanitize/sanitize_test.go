@@ -0,0 +1,42 @@
+package sanitize
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	p := DefaultPolicy()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "hello world", "hello world"},
+		{"script removed", `<b>bold</b><script>alert("x")</script>`, "<b>bold</b>"},
+		{"unknown tag stripped, text kept", "<div>text</div>", "text"},
+		{"event handler removed", `<b onclick="evil()">x</b>`, "<b>x</b>"},
+		{"safe link kept", `<a href="https://example.com" target="_blank">x</a>`, `<a href="https://example.com">x</a>`},
+		{"javascript link removed", `<a href="java&#09;script:alert(1)">x</a>`, "<a>x</a>"},
+		{"heading kept", "<H2>Title</H2>", "<h2>Title</h2>"},
+		{"comment removed", "a<!-- hidden -->b", "ab"},
+		{"stray bracket escaped", "1 < 2", "1 &lt; 2"},
+		{"unclosed script drops rest", "ok<script>alert(1)", "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Sanitize(tt.in); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	p := NewPolicy(map[string][]string{"IMG": {"SRC"}})
+
+	got := p.Sanitize(`<img src="/a.png" alt="a"><b>x</b>`)
+	want := `<img src="/a.png">x`
+	if got != want {
+		t.Errorf("Sanitize() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+// Package webhook delivers post events to an external URL.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+// Event types.
+const (
+	PostCreated   = "post.created"
+	PostUpdated   = "post.updated"
+	PostDeleted   = "post.deleted"
+	PostPublished = "post.published"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, hex-encoded
+// with a "sha256=" prefix.
+const SignatureHeader = "X-Signature"
+
+// EventHeader carries the event type.
+const EventHeader = "X-Webhook-Event"
+
+// deliveryTimeout bounds each delivery made by Notify.
+const deliveryTimeout = 10 * time.Second
+
+// Event is the JSON body of a delivery.
+type Event struct {
+	Type string `json:"type"`
+	// PostID is written as posts write their id, so it is a token when
+	// post IDs are obfuscated.
+	PostID interface{} `json:"postId"`
+	// Post is the post after the change. It is omitted for deletions.
+	Post *model.Post `json:"post,omitempty"`
+	Time string      `json:"time"`
+}
+
+// Notifier posts events to a single URL, signing each body with a shared
+// secret so receivers can reject forged calls.
+type Notifier struct {
+	URL string
+	// Secret keys the X-Signature HMAC. The header is omitted when it is
+	// empty.
+	Secret string
+	Client *http.Client
+	Logger *slog.Logger
+}
+
+// New creates a Notifier for url, or returns nil if url is empty. A nil
+// logger means slog.Default().
+func New(url, secret string, logger *slog.Logger) *Notifier {
+	if url == "" {
+		return nil
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Notifier{URL: url, Secret: secret, Client: http.DefaultClient, Logger: logger}
+}
+
+// Sign returns the X-Signature value for body: "sha256=" followed by the
+// hex-encoded HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify delivers an event in the background and logs any failure. It does
+// nothing on a nil Notifier, so callers need not check whether webhooks are
+// configured. The event is encoded before Notify returns, so the delivery
+// carries the post as it was then even if the caller goes on to change it.
+func (n *Notifier) Notify(eventType string, postID int64, post *model.Post) {
+	if n == nil {
+		return
+	}
+	event := Event{Type: eventType, PostID: model.EncodePostID(postID), Post: post, Time: model.FormatTime(time.Now())}
+	body, err := json.Marshal(event)
+	if err != nil {
+		n.Logger.Error("webhook delivery failed", "event", event.Type, "postId", event.PostID, "error", err)
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+		if err := n.Deliver(ctx, eventType, body); err != nil {
+			n.Logger.Error("webhook delivery failed", "event", event.Type, "postId", event.PostID, "error", err)
+		}
+	}()
+}
+
+// Deliver sends one encoded event of the given type and reports an error
+// unless the receiver answers with a 2xx status.
+func (n *Notifier) Deliver(ctx context.Context, eventType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	if n.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.Secret, body))
+	}
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook receiver returned %s", resp.Status)
+	}
+	return nil
+}
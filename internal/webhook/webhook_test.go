@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gemini/go-blog-api/internal/model"
+)
+
+func TestSign(t *testing.T) {
+	got := Sign("key", []byte("The quick brown fox jumps over the lazy dog"))
+	want := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	var signature, eventType string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(SignatureHeader)
+		eventType = r.Header.Get(EventHeader)
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	n := New(server.URL, "secret", nil)
+	if err := n.Deliver(context.Background(), PostDeleted, []byte(`{"type":"post.deleted","postId":7}`)); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	if want := Sign("secret", body); signature != want {
+		t.Errorf("delivery has wrong signature: got %q want %q", signature, want)
+	}
+	if eventType != PostDeleted {
+		t.Errorf("delivery has wrong event header: got %q want %q", eventType, PostDeleted)
+	}
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil || event.PostID != float64(7) {
+		t.Errorf("delivery has wrong body: %s", body)
+	}
+
+	t.Run("unsigned without secret", func(t *testing.T) {
+		n := New(server.URL, "", nil)
+		if err := n.Deliver(context.Background(), PostDeleted, []byte(`{}`)); err != nil {
+			t.Fatalf("Deliver() error = %v", err)
+		}
+		if signature != "" {
+			t.Errorf("delivery has a signature without a secret: %q", signature)
+		}
+	})
+
+	t.Run("receiver error", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer failing.Close()
+
+		if err := New(failing.URL, "secret", nil).Deliver(context.Background(), PostDeleted, []byte(`{}`)); err == nil {
+			t.Error("Deliver() error = nil, want an error for a 500 response")
+		}
+	})
+}
+
+func TestNotifyEncodesBeforeReturning(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A slow receiver leaves the delivery in flight while the post
+		// changes.
+		time.Sleep(10 * time.Millisecond)
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	post := &model.Post{ID: 7, Title: "Before"}
+	New(server.URL, "secret", nil).Notify(PostUpdated, post.ID, post)
+	post.Title = "After"
+
+	var event Event
+	select {
+	case body := <-bodies:
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Fatalf("delivery has malformed body: %s", body)
+		}
+	case <-time.After(deliveryTimeout):
+		t.Fatal("no delivery received")
+	}
+	if event.Post == nil || event.Post.Title != "Before" {
+		t.Errorf("delivery has wrong post: got %+v want title %q", event.Post, "Before")
+	}
+}